@@ -5,4 +5,8 @@ go 1.25.3
 require (
 	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
+	golang.org/x/net v0.50.0
+	golang.org/x/term v0.40.0
 )
+
+require golang.org/x/sys v0.41.0 // indirect
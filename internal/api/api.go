@@ -0,0 +1,176 @@
+// Package api serves gator over a REST JSON API authenticated with per-user
+// tokens.
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/follows"
+)
+
+// Store is the subset of database.Queries the API uses.
+type Store interface {
+	follows.Store
+	GetUserByAPIToken(ctx context.Context, arg database.GetUserByAPITokenParams) (database.User, error)
+	GetUsers(ctx context.Context) ([]database.User, error)
+	GetFeeds(ctx context.Context) ([]database.GetFeedsRow, error)
+	GetFeedFollowsByUserID(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsByUserIDRow, error)
+	GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error)
+	GetPostForUser(ctx context.Context, arg database.GetPostForUserParams) (database.GetPostForUserRow, error)
+	MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error
+	MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) (int64, error)
+	CreatePostStar(ctx context.Context, arg database.CreatePostStarParams) error
+	DeletePostStar(ctx context.Context, arg database.DeletePostStarParams) (int64, error)
+}
+
+// Options configure the behaviour shared with the CLI.
+type Options struct {
+	// AllowPrivateURLs lets users follow feeds on private networks.
+	AllowPrivateURLs bool
+	// Fetch fetches feeds that are followed for the first time.
+	Fetch follows.Fetcher
+}
+
+type Server struct {
+	db   Store
+	opts Options
+	mux  *http.ServeMux
+}
+
+func New(db Store, opts Options) *Server {
+	s := &Server{db: db, opts: opts, mux: http.NewServeMux()}
+
+	s.handle("GET /api/v1/me", s.getMe)
+	s.handle("GET /api/v1/users", s.getUsers)
+	s.handle("GET /api/v1/feeds", s.getFeeds)
+	s.handle("GET /api/v1/follows", s.getFollows)
+	s.handle("POST /api/v1/follows", s.postFollow)
+	s.handle("DELETE /api/v1/follows/{feed_id}", s.deleteFollow)
+	s.handle("GET /api/v1/items", s.getItems)
+	s.handle("GET /api/v1/items/{id}", s.getItem)
+	s.handle("PUT /api/v1/items/{id}/read", s.putRead)
+	s.handle("DELETE /api/v1/items/{id}/read", s.deleteRead)
+	s.handle("PUT /api/v1/items/{id}/star", s.putStar)
+	s.handle("DELETE /api/v1/items/{id}/star", s.deleteStar)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errNotFound("no such endpoint"))
+	})
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, user database.User) error
+
+// handle registers an authenticated endpoint. Errors returned by handlers are
+// written as error bodies.
+func (s *Server) handle(pattern string, h handlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.authenticate(r)
+		if err == nil {
+			err = h(w, r, user)
+		}
+		if err != nil {
+			writeError(w, err)
+		}
+	})
+}
+
+func (s *Server) authenticate(r *http.Request) (database.User, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return database.User{}, errUnauthorized("missing bearer token")
+	}
+
+	tokenP := database.GetUserByAPITokenParams{
+		TokenHash:  HashToken(token),
+		LastUsedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	user, err := s.db.GetUserByAPIToken(r.Context(), tokenP)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, errUnauthorized("invalid token")
+	}
+	if err != nil {
+		return database.User{}, fmt.Errorf("authenticating: %w", err)
+	}
+	return user, nil
+}
+
+// NewToken returns a new random API token. Only its hash is stored.
+func NewToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "gtr_" + hex.EncodeToString(b)
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Error is an API error with the HTTP status and machine readable code it is
+// reported with.
+type Error struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func errBadRequest(format string, args ...any) error {
+	return &Error{Status: http.StatusBadRequest, Code: "bad_request", Message: fmt.Sprintf(format, args...)}
+}
+
+func errUnauthorized(msg string) error {
+	return &Error{Status: http.StatusUnauthorized, Code: "unauthorized", Message: msg}
+}
+
+func errNotFound(msg string) error {
+	return &Error{Status: http.StatusNotFound, Code: "not_found", Message: msg}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *Error
+	switch {
+	case errors.As(err, &apiErr):
+	case errors.Is(err, follows.ErrInvalidURL):
+		apiErr = &Error{Status: http.StatusBadRequest, Code: "invalid_url", Message: err.Error()}
+	case errors.Is(err, follows.ErrAlreadyFollowing):
+		apiErr = &Error{Status: http.StatusConflict, Code: "already_following", Message: err.Error()}
+	case errors.Is(err, follows.ErrNotFollowing), errors.Is(err, sql.ErrNoRows):
+		apiErr = &Error{Status: http.StatusNotFound, Code: "not_found", Message: "not found"}
+	default:
+		fmt.Fprintf(os.Stderr, "api error: %v\n", err)
+		apiErr = &Error{Status: http.StatusInternalServerError, Code: "internal", Message: "internal server error"}
+	}
+
+	if apiErr.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="gator"`)
+	}
+	writeJSON(w, apiErr.Status, map[string]*Error{"error": apiErr})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,357 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedurl"
+	"github.com/theandyeh/gator/internal/rss"
+)
+
+// fakeStore keeps just enough state in memory to exercise the API.
+type fakeStore struct {
+	users   map[string]database.User // by token hash
+	feeds   []database.Feed
+	follows map[uuid.UUID]bool // feed ids followed by the token user
+	posts   []database.Post
+	read    map[uuid.UUID]bool
+	starred map[uuid.UUID]bool
+	// categories holds the comma joined feed categories of posts.
+	categories map[uuid.UUID]string
+}
+
+func newFakeStore() (*fakeStore, string) {
+	token := NewToken()
+	user := database.User{ID: uuid.New(), Name: "kahya", CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	feed := database.Feed{ID: uuid.New(), Name: "Go Blog", Url: "https://go.dev/blog/feed.atom", UserID: user.ID}
+	store := &fakeStore{
+		users:   map[string]database.User{HashToken(token): user},
+		feeds:   []database.Feed{feed},
+		follows: map[uuid.UUID]bool{feed.ID: true},
+		read:    map[uuid.UUID]bool{},
+		starred: map[uuid.UUID]bool{},
+
+		categories: map[uuid.UUID]string{},
+	}
+	for i := range 5 {
+		store.posts = append(store.posts, database.Post{
+			ID:     uuid.New(),
+			Title:  fmt.Sprintf("Post %d", i),
+			Url:    fmt.Sprintf("https://go.dev/blog/%d", i),
+			FeedID: feed.ID,
+		})
+	}
+	return store, token
+}
+
+func (f *fakeStore) user() database.User {
+	for _, u := range f.users {
+		return u
+	}
+	return database.User{}
+}
+
+func (f *fakeStore) GetUserByAPIToken(ctx context.Context, arg database.GetUserByAPITokenParams) (database.User, error) {
+	u, ok := f.users[arg.TokenHash]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (f *fakeStore) GetUsers(ctx context.Context) ([]database.User, error) {
+	return []database.User{f.user()}, nil
+}
+
+func (f *fakeStore) GetFeeds(ctx context.Context) ([]database.GetFeedsRow, error) {
+	var rows []database.GetFeedsRow
+	for _, feed := range f.feeds {
+		rows = append(rows, database.GetFeedsRow{ID: feed.ID, Url: feed.Url, Name: feed.Name, UserID: feed.UserID})
+	}
+	return rows, nil
+}
+
+func (f *fakeStore) GetFeedFollowsByUserID(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsByUserIDRow, error) {
+	var rows []database.GetFeedFollowsByUserIDRow
+	for _, feed := range f.feeds {
+		if f.follows[feed.ID] {
+			rows = append(rows, database.GetFeedFollowsByUserIDRow{FeedID: feed.ID, FeedName: feed.Name, FeedUrl: feed.Url})
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeStore) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	var rows []database.GetPostsForUserRow
+	for _, p := range f.posts {
+		if !f.follows[p.FeedID] || (arg.UnreadOnly && f.read[p.ID]) {
+			continue
+		}
+		row := database.GetPostsForUserRow{ID: p.ID, Title: p.Title, Url: p.Url, FeedID: p.FeedID, FeedName: "Go Blog", Starred: f.starred[p.ID]}
+		row.ReadAt.Valid = f.read[p.ID]
+		rows = append(rows, row)
+	}
+	start := min(int(arg.SkipPosts), len(rows))
+	end := min(start+int(arg.MaxPosts), len(rows))
+	return rows[start:end], nil
+}
+
+func (f *fakeStore) GetPostForUser(ctx context.Context, arg database.GetPostForUserParams) (database.GetPostForUserRow, error) {
+	for _, p := range f.posts {
+		if p.ID == arg.ID && f.follows[p.FeedID] {
+			row := database.GetPostForUserRow{ID: p.ID, Title: p.Title, Url: p.Url, FeedID: p.FeedID, FeedName: "Go Blog", Starred: f.starred[p.ID]}
+			row.ReadAt.Valid = f.read[p.ID]
+			row.Guid, row.CommentsUrl, row.Categories = p.Guid, p.CommentsUrl, f.categories[p.ID]
+			return row, nil
+		}
+	}
+	return database.GetPostForUserRow{}, sql.ErrNoRows
+}
+
+func (f *fakeStore) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	f.read[arg.PostID] = true
+	return nil
+}
+
+func (f *fakeStore) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) (int64, error) {
+	delete(f.read, arg.PostID)
+	return 1, nil
+}
+
+func (f *fakeStore) CreatePostStar(ctx context.Context, arg database.CreatePostStarParams) error {
+	f.starred[arg.PostID] = true
+	return nil
+}
+
+func (f *fakeStore) DeletePostStar(ctx context.Context, arg database.DeletePostStarParams) (int64, error) {
+	delete(f.starred, arg.PostID)
+	return 1, nil
+}
+
+func (f *fakeStore) GetFeedByURL(ctx context.Context, url string) (database.Feed, error) {
+	for _, feed := range f.feeds {
+		if feedurl.SameFeed(feed.Url, url) {
+			return feed, nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (f *fakeStore) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	feed := database.Feed{ID: arg.ID, Name: arg.Name, Url: arg.Url, UserID: arg.UserID}
+	f.feeds = append(f.feeds, feed)
+	return feed, nil
+}
+
+func (f *fakeStore) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	if f.follows[arg.FeedID] {
+		return database.CreateFeedFollowRow{}, &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+	}
+	f.follows[arg.FeedID] = true
+	var name string
+	for _, feed := range f.feeds {
+		if feed.ID == arg.FeedID {
+			name = feed.Name
+		}
+	}
+	return database.CreateFeedFollowRow{FeedID: arg.FeedID, UserID: arg.UserID, FeedName: name}, nil
+}
+
+func (f *fakeStore) GetFeedFollow(ctx context.Context, arg database.GetFeedFollowParams) (database.FeedFollow, error) {
+	if !f.follows[arg.FeedID] {
+		return database.FeedFollow{}, sql.ErrNoRows
+	}
+	return database.FeedFollow{FeedID: arg.FeedID, UserID: arg.UserID}, nil
+}
+
+func (f *fakeStore) DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) error {
+	delete(f.follows, arg.FeedID)
+	return nil
+}
+
+func fakeFetch(ctx context.Context, feedURL string) (*rss.RSSFeed, error) {
+	feed := &rss.RSSFeed{}
+	feed.Channel.Title = "Fetched " + feedURL
+	return feed, nil
+}
+
+func do(t *testing.T, srv http.Handler, token, method, path, body string) (*http.Response, []byte) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	data, _ := io.ReadAll(rec.Result().Body)
+	return rec.Result(), data
+}
+
+func TestAuthentication(t *testing.T) {
+	store, token := newFakeStore()
+	srv := New(store, Options{Fetch: fakeFetch})
+
+	for _, tok := range []string{"", "gtr_wrong"} {
+		resp, body := do(t, srv, tok, "GET", "/api/v1/me", "")
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("token %q: status = %d, want 401", tok, resp.StatusCode)
+		}
+		var e struct {
+			Error Error `json:"error"`
+		}
+		if err := json.Unmarshal(body, &e); err != nil || e.Error.Code != "unauthorized" {
+			t.Errorf("token %q: error body = %s", tok, body)
+		}
+	}
+
+	resp, body := do(t, srv, token, "GET", "/api/v1/me", "")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"name":"kahya"`) {
+		t.Errorf("GET /me = %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestItemsPagination(t *testing.T) {
+	store, token := newFakeStore()
+	srv := New(store, Options{Fetch: fakeFetch})
+
+	var page Page[Item]
+	resp, body := do(t, srv, token, "GET", "/api/v1/items?limit=2&offset=2", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Data) != 2 || page.Data[0].Title != "Post 2" {
+		t.Errorf("data = %+v", page.Data)
+	}
+	if page.Pagination.NextOffset == nil || *page.Pagination.NextOffset != 4 {
+		t.Errorf("pagination = %+v, want next_offset 4", page.Pagination)
+	}
+
+	page = Page[Item]{}
+	_, body = do(t, srv, token, "GET", "/api/v1/items?limit=2&offset=4", "")
+	json.Unmarshal(body, &page)
+	if len(page.Data) != 1 || page.Pagination.NextOffset != nil {
+		t.Errorf("last page = %s", body)
+	}
+
+	resp, _ = do(t, srv, token, "GET", "/api/v1/items?limit=0", "")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("limit=0 status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestReadAndStarState(t *testing.T) {
+	store, token := newFakeStore()
+	srv := New(store, Options{Fetch: fakeFetch})
+	id := store.posts[0].ID
+
+	if resp, body := do(t, srv, token, "PUT", "/api/v1/items/"+id.String()+"/read", ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT read = %d %s", resp.StatusCode, body)
+	}
+	if resp, _ := do(t, srv, token, "PUT", "/api/v1/items/"+id.String()+"/star", ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT star = %d", resp.StatusCode)
+	}
+
+	var item Item
+	_, body := do(t, srv, token, "GET", "/api/v1/items/"+id.String(), "")
+	json.Unmarshal(body, &item)
+	if !item.Read || !item.Starred {
+		t.Errorf("item = %+v, want read and starred", item)
+	}
+
+	var page Page[Item]
+	_, body = do(t, srv, token, "GET", "/api/v1/items?unread=true", "")
+	json.Unmarshal(body, &page)
+	if len(page.Data) != 4 {
+		t.Errorf("unread items = %d, want 4", len(page.Data))
+	}
+
+	resp, _ := do(t, srv, token, "PUT", "/api/v1/items/"+uuid.NewString()+"/read", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("PUT read of unknown item = %d, want 404", resp.StatusCode)
+	}
+	resp, _ = do(t, srv, token, "GET", "/api/v1/items/not-a-uuid", "")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET invalid id = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestItemMetadata(t *testing.T) {
+	store, token := newFakeStore()
+	srv := New(store, Options{Fetch: fakeFetch})
+	post := &store.posts[0]
+	post.Guid = sql.NullString{String: "tag:go.dev,2026:0", Valid: true}
+	post.CommentsUrl = sql.NullString{String: "https://go.dev/blog/0#comments", Valid: true}
+	store.categories[post.ID] = "Go,Releases"
+
+	var item Item
+	_, body := do(t, srv, token, "GET", "/api/v1/items/"+post.ID.String(), "")
+	if err := json.Unmarshal(body, &item); err != nil {
+		t.Fatal(err)
+	}
+	if item.GUID != post.Guid.String || item.CommentsURL != post.CommentsUrl.String {
+		t.Errorf("item = %+v, want the guid and comments url", item)
+	}
+	if len(item.Categories) != 2 || item.Categories[0] != "Go" || item.Categories[1] != "Releases" {
+		t.Errorf("categories = %q", item.Categories)
+	}
+
+	_, body = do(t, srv, token, "GET", "/api/v1/items/"+store.posts[1].ID.String(), "")
+	if !strings.Contains(string(body), `"categories":[]`) {
+		t.Errorf("posts without categories should have an empty list:\n%s", body)
+	}
+}
+
+func TestFollowAndUnfollow(t *testing.T) {
+	store, token := newFakeStore()
+	srv := New(store, Options{Fetch: fakeFetch})
+
+	resp, body := do(t, srv, token, "POST", "/api/v1/follows", `{"url": "https://Example.com/feed.xml?utm_source=x"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST follows = %d %s", resp.StatusCode, body)
+	}
+	var follow Follow
+	json.Unmarshal(body, &follow)
+	if follow.FeedURL != "https://example.com/feed.xml" || follow.FeedName != "Fetched https://example.com/feed.xml" {
+		t.Errorf("follow = %+v", follow)
+	}
+
+	resp, body = do(t, srv, token, "POST", "/api/v1/follows", `{"url": "https://example.com/feed.xml"}`)
+	if resp.StatusCode != http.StatusConflict || !strings.Contains(string(body), `"code":"already_following"`) {
+		t.Errorf("POST followed feed = %d %s, want 409 already_following", resp.StatusCode, body)
+	}
+
+	resp, body = do(t, srv, token, "POST", "/api/v1/follows", `{"url": "http://127.0.0.1/feed"}`)
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "invalid_url") {
+		t.Errorf("POST private url = %d %s, want 400 invalid_url", resp.StatusCode, body)
+	}
+
+	path := "/api/v1/follows/" + follow.FeedID.String()
+	if resp, _ := do(t, srv, token, "DELETE", path, ""); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("DELETE follow = %d, want 204", resp.StatusCode)
+	}
+	if resp, _ := do(t, srv, token, "DELETE", path, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("second DELETE follow = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestUnknownEndpoint(t *testing.T) {
+	store, token := newFakeStore()
+	resp, body := do(t, New(store, Options{}), token, "GET", "/api/v1/nope", "")
+	if resp.StatusCode != http.StatusNotFound || !strings.Contains(string(body), `"code":"not_found"`) {
+		t.Errorf("GET unknown = %d %s", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,399 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/follows"
+	"github.com/theandyeh/gator/internal/sanitize"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type User struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Feed struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+type Follow struct {
+	FeedID   uuid.UUID `json:"feed_id"`
+	FeedName string    `json:"feed_name"`
+	FeedURL  string    `json:"feed_url,omitempty"`
+	Category string    `json:"category,omitempty"`
+	Unread   int64     `json:"unread"`
+	Priority int32     `json:"priority"`
+	Muted    bool      `json:"muted"`
+	Notes    string    `json:"notes,omitempty"`
+}
+
+type Item struct {
+	ID          uuid.UUID  `json:"id"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+	FeedName    string     `json:"feed_name"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description,omitempty"`
+	Content     string     `json:"content,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	GUID        string     `json:"guid,omitempty"`
+	Categories  []string   `json:"categories"`
+	CommentsURL string     `json:"comments_url,omitempty"`
+	PublishedAt *time.Time `json:"published_at"`
+	Read        bool       `json:"read"`
+	Starred     bool       `json:"starred"`
+	Tags        []string   `json:"tags"`
+}
+
+// Page is the envelope of every list response. NextOffset is omitted on the
+// last page.
+type Page[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+type Pagination struct {
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+func pageParams(r *http.Request) (limit, offset int, err error) {
+	limit, offset = defaultLimit, 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, errBadRequest("limit must be between 1 and %d", maxLimit)
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errBadRequest("offset must be a non-negative number")
+		}
+	}
+	return limit, offset, nil
+}
+
+// paginate serves a page of a list that is loaded in full.
+func paginate[T any](w http.ResponseWriter, r *http.Request, all []T) error {
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		return err
+	}
+	start := min(offset, len(all))
+	end := min(start+limit, len(all))
+	writeJSON(w, http.StatusOK, newPage(all[start:end], limit, offset, end < len(all)))
+	return nil
+}
+
+func newPage[T any](data []T, limit, offset int, more bool) Page[T] {
+	p := Page[T]{Data: data, Pagination: Pagination{Limit: limit, Offset: offset}}
+	if p.Data == nil {
+		p.Data = []T{}
+	}
+	if more {
+		next := offset + limit
+		p.Pagination.NextOffset = &next
+	}
+	return p
+}
+
+func (s *Server) getMe(w http.ResponseWriter, r *http.Request, user database.User) error {
+	writeJSON(w, http.StatusOK, User{ID: user.ID, Name: user.Name, CreatedAt: user.CreatedAt})
+	return nil
+}
+
+func (s *Server) getUsers(w http.ResponseWriter, r *http.Request, user database.User) error {
+	users, err := s.db.GetUsers(r.Context())
+	if err != nil {
+		return err
+	}
+	out := make([]User, 0, len(users))
+	for _, u := range users {
+		out = append(out, User{ID: u.ID, Name: u.Name, CreatedAt: u.CreatedAt})
+	}
+	return paginate(w, r, out)
+}
+
+func (s *Server) getFeeds(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feeds, err := s.db.GetFeeds(r.Context())
+	if err != nil {
+		return err
+	}
+	out := make([]Feed, 0, len(feeds))
+	for _, f := range feeds {
+		out = append(out, Feed{ID: f.ID, Name: f.Name, URL: f.Url, CreatedBy: f.Username.String})
+	}
+	return paginate(w, r, out)
+}
+
+func (s *Server) getFollows(w http.ResponseWriter, r *http.Request, user database.User) error {
+	rows, err := s.db.GetFeedFollowsByUserID(r.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+	out := make([]Follow, 0, len(rows))
+	for _, f := range rows {
+		out = append(out, Follow{
+			FeedID:   f.FeedID,
+			FeedName: f.FeedName,
+			FeedURL:  f.FeedUrl,
+			Category: f.CategoryName.String,
+			Unread:   f.UnreadCount,
+			Priority: f.Priority,
+			Muted:    f.Muted,
+			Notes:    f.Notes.String,
+		})
+	}
+	return paginate(w, r, out)
+}
+
+func (s *Server) postFollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		return errBadRequest("invalid JSON body: %v", err)
+	}
+	if body.URL == "" {
+		return errBadRequest("url is required")
+	}
+
+	feedUrl, err := follows.NormalizeURL(body.URL, s.opts.AllowPrivateURLs)
+	if err != nil {
+		return err
+	}
+	follow, created, err := follows.Follow(r.Context(), s.db, s.opts.Fetch, user, feedUrl, s.opts.AllowPrivateURLs)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, Follow{FeedID: follow.FeedID, FeedName: follow.FeedName, FeedURL: feedUrl})
+	return nil
+}
+
+func (s *Server) deleteFollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feedID, err := pathID(r, "feed_id")
+	if err != nil {
+		return err
+	}
+	if err := follows.UnfollowID(r.Context(), s.db, user, feedID); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) getItems(w http.ResponseWriter, r *http.Request, user database.User) error {
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		return err
+	}
+	unread, err := boolParam(r, "unread")
+	if err != nil {
+		return err
+	}
+
+	// Ask for one extra row to know whether there is a next page.
+	postsP := database.GetPostsForUserParams{
+		UserID:       user.ID,
+		UnreadOnly:   unread,
+		Category:     r.URL.Query().Get("category"),
+		Author:       r.URL.Query().Get("author"),
+		ItemCategory: r.URL.Query().Get("item_category"),
+		MaxPosts:     int32(limit + 1),
+		SkipPosts:    int32(offset),
+	}
+	posts, err := s.db.GetPostsForUser(r.Context(), postsP)
+	if err != nil {
+		return err
+	}
+
+	more := len(posts) > limit
+	if more {
+		posts = posts[:limit]
+	}
+	out := make([]Item, 0, len(posts))
+	for _, p := range posts {
+		out = append(out, listItem(p))
+	}
+	writeJSON(w, http.StatusOK, newPage(out, limit, offset, more))
+	return nil
+}
+
+func (s *Server) getItem(w http.ResponseWriter, r *http.Request, user database.User) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+	p, err := s.db.GetPostForUser(r.Context(), database.GetPostForUserParams{ID: id, UserID: user.ID})
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, detailItem(p))
+	return nil
+}
+
+func (s *Server) putRead(w http.ResponseWriter, r *http.Request, user database.User) error {
+	id, err := s.visibleItem(r, user)
+	if err != nil {
+		return err
+	}
+	if err := s.db.MarkPostRead(r.Context(), database.MarkPostReadParams{UserID: user.ID, PostID: id, ReadAt: time.Now()}); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) deleteRead(w http.ResponseWriter, r *http.Request, user database.User) error {
+	id, err := s.visibleItem(r, user)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.MarkPostUnread(r.Context(), database.MarkPostUnreadParams{UserID: user.ID, PostID: id}); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) putStar(w http.ResponseWriter, r *http.Request, user database.User) error {
+	id, err := s.visibleItem(r, user)
+	if err != nil {
+		return err
+	}
+	starP := database.CreatePostStarParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		PostID:    id,
+		UserID:    user.ID,
+	}
+	if err := s.db.CreatePostStar(r.Context(), starP); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) deleteStar(w http.ResponseWriter, r *http.Request, user database.User) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.DeletePostStar(r.Context(), database.DeletePostStarParams{PostID: id, UserID: user.ID}); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// visibleItem returns the id of the item in the path if it belongs to a feed
+// the user follows.
+func (s *Server) visibleItem(r *http.Request, user database.User) (uuid.UUID, error) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := s.db.GetPostForUser(r.Context(), database.GetPostForUserParams{ID: id, UserID: user.ID}); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func listItem(p database.GetPostsForUserRow) Item {
+	item := Item{
+		ID:          p.ID,
+		FeedID:      p.FeedID,
+		FeedName:    p.FeedName,
+		Title:       p.Title,
+		URL:         p.Url,
+		Description: sanitize.HTML(p.Description.String, p.Url),
+		Content:     sanitize.HTML(p.Content.String, p.Url),
+		Author:      p.Author.String,
+		GUID:        p.Guid.String,
+		Categories:  splitList(p.Categories),
+		CommentsURL: p.CommentsUrl.String,
+		Read:        p.ReadAt.Valid,
+		Starred:     p.Starred,
+	}
+	return withTagsAndDate(item, p.Tags, p.PublishedAt)
+}
+
+func detailItem(p database.GetPostForUserRow) Item {
+	item := Item{
+		ID:          p.ID,
+		FeedID:      p.FeedID,
+		FeedName:    p.FeedName,
+		Title:       p.Title,
+		URL:         p.Url,
+		Description: sanitize.HTML(p.Description.String, p.Url),
+		Content:     sanitize.HTML(p.Content.String, p.Url),
+		Author:      p.Author.String,
+		GUID:        p.Guid.String,
+		Categories:  splitList(p.Categories),
+		CommentsURL: p.CommentsUrl.String,
+		Read:        p.ReadAt.Valid,
+		Starred:     p.Starred,
+	}
+	return withTagsAndDate(item, p.Tags, p.PublishedAt)
+}
+
+func withTagsAndDate(item Item, tags string, published sql.NullTime) Item {
+	item.Tags = splitList(tags)
+	if published.Valid {
+		item.PublishedAt = &published.Time
+	}
+	return item
+}
+
+// splitList splits a comma joined list from the database, an empty string
+// being an empty list.
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+func pathID(r *http.Request, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(r.PathValue(name))
+	if err != nil {
+		return uuid.Nil, errBadRequest("invalid %s: %v", name, err)
+	}
+	return id, nil
+}
+
+func boolParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errBadRequest("%s must be true or false", name)
+	}
+	return b, nil
+}
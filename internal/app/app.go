@@ -1,11 +1,21 @@
 package app
 
 import (
+	"database/sql"
+	"io"
+
 	"github.com/theandyeh/gator/internal/config"
 	"github.com/theandyeh/gator/internal/database"
 )
 
 type State struct {
-	Db  *database.Queries
-	Cfg *config.Config
+	// Conn is the connection pool behind Db, for queries that have to run
+	// in a transaction.
+	Conn    *sql.DB
+	Db      *database.Queries
+	Cfg     *config.Config
+	Output  string
+	Out     io.Writer
+	Err     io.Writer
+	Verbose bool
 }
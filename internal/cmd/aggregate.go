@@ -0,0 +1,568 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/htmltext"
+	"github.com/theandyeh/gator/internal/notify"
+	"github.com/theandyeh/gator/internal/readability"
+	"github.com/theandyeh/gator/internal/rss"
+	"github.com/theandyeh/gator/internal/rules"
+	"github.com/theandyeh/gator/internal/sanitize"
+	"github.com/theandyeh/gator/internal/webhook"
+)
+
+// scrapeFeed fetches a feed and stores its items, returning only the posts
+// that were not stored before.
+func scrapeFeed(s *app.State, feed database.Feed) ([]database.Post, error) {
+	fetchedP := database.MarkFeedFetchedParams{
+		ID:            feed.ID,
+		LastFetchedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	if err := s.Db.MarkFeedFetched(context.Background(), fetchedP); err != nil {
+		return nil, fmt.Errorf("scrape error marking %s fetched: %w", feed.Url, err)
+	}
+
+	debugf(s, "fetching feed %s", feed.Url)
+	fetched, err := rss.FetchFeed(context.Background(), feed.Url)
+	if err != nil {
+		return nil, fmt.Errorf("scrape error: %w", err)
+	}
+	updateFeedMetadata(s, feed, fetched)
+
+	var posts []database.Post
+	categories := make(map[uuid.UUID][]string)
+	for _, item := range fetched.Channel.Item {
+		media := itemMedia(item)
+		if item.Link == "" {
+			item.Link = item.PermaLink()
+		}
+		if item.Link == "" && len(media) > 0 {
+			// Podcast episodes often have no page of their own.
+			item.Link = media[0].URL
+		}
+		if item.Link == "" {
+			debugf(s, "skipping item %q without link in %s", item.Title, feed.Url)
+			continue
+		}
+
+		// Feed HTML is untrusted, it is cleaned before anything else sees it.
+		description := sanitize.HTML(item.Description, item.Link)
+		postP := database.CreatePostParams{
+			ID:          uuid.New(),
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+			Title:       item.Title,
+			Url:         item.Link,
+			Description: sql.NullString{String: description, Valid: description != ""},
+			FeedID:      feed.ID,
+		}
+		if author := itemAuthor(item); author != "" {
+			postP.Author = sql.NullString{String: author, Valid: true}
+		}
+		if published, ok := rss.ParseDate(item.PubDate); ok {
+			postP.PublishedAt = sql.NullTime{Time: published, Valid: true}
+		}
+		setItemMetadata(&postP, item)
+		setEpisodeInfo(&postP, item, fetched.Channel.ItunesImage.Href, media)
+
+		post, err := s.Db.CreatePost(context.Background(), postP)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			debugf(s, "failed to store item %s: %v", item.Link, err)
+			continue
+		}
+		storeEnclosures(s, post, media)
+		categories[post.ID] = storeCategories(s, post, item.Categories)
+		posts = append(posts, post)
+	}
+
+	if feed.FetchFullContent {
+		for i := range posts {
+			posts[i] = storeFullContent(s, posts[i])
+		}
+	}
+
+	if len(posts) > 0 {
+		applyRules(s, feed, posts, categories)
+		applyWatches(s, feed, posts, categories)
+		deliverWebhooks(s, feed, posts, categories)
+	}
+
+	return posts, nil
+}
+
+// itemAuthor prefers the RSS author element and falls back to dc:creator.
+func itemAuthor(item rss.RSSItem) string {
+	if item.Author != "" {
+		return item.Author
+	}
+	return item.Creator
+}
+
+// updateFeedMetadata stores the image, language and generator the feed
+// describes itself with when they changed.
+func updateFeedMetadata(s *app.State, feed database.Feed, fetched *rss.RSSFeed) {
+	metadataP := database.UpdateFeedMetadataParams{
+		ID:        feed.ID,
+		ImageUrl:  nullString(httpURL(cmp.Or(fetched.Channel.Image.URL, fetched.Channel.ItunesImage.Href))),
+		Language:  nullString(strings.TrimSpace(fetched.Channel.Language)),
+		Generator: nullString(strings.TrimSpace(fetched.Channel.Generator)),
+		UpdatedAt: time.Now(),
+	}
+	if metadataP.ImageUrl == feed.ImageUrl && metadataP.Language == feed.Language && metadataP.Generator == feed.Generator {
+		return
+	}
+	if err := s.Db.UpdateFeedMetadata(context.Background(), metadataP); err != nil {
+		fmt.Fprintf(stderr(s), "scrape error storing metadata of %s: %v\n", feed.Url, err)
+	}
+}
+
+// setItemMetadata copies the guid, full content, comments link and source
+// of item to postP.
+func setItemMetadata(postP *database.CreatePostParams, item rss.RSSItem) {
+	postP.Guid = nullString(strings.TrimSpace(item.GUID.Value))
+	postP.Content = nullString(sanitize.HTML(item.Content, item.Link))
+	postP.CommentsUrl = nullString(httpURL(item.Comments))
+	postP.SourceTitle = nullString(strings.TrimSpace(item.Source.Title))
+	postP.SourceUrl = nullString(httpURL(item.Source.URL))
+}
+
+// storeCategories records the categories of a new post and returns their
+// names. Categories holding commas are split, so the list can be joined with
+// commas again.
+func storeCategories(s *app.State, post database.Post, categories []string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, category := range categories {
+		for _, name := range strings.Split(category, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[strings.ToLower(name)] {
+				continue
+			}
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+			categoryP := database.CreatePostCategoryParams{PostID: post.ID, Name: name}
+			if err := s.Db.CreatePostCategory(context.Background(), categoryP); err != nil {
+				fmt.Fprintf(stderr(s), "category error storing %q for %s: %v\n", name, post.Url, err)
+			}
+		}
+	}
+	return names
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// httpURL returns raw when it is an absolute http or https address.
+func httpURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if u, err := url.Parse(raw); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		return raw
+	}
+	return ""
+}
+
+// itemMedia returns the attached files of item that can be downloaded.
+func itemMedia(item rss.RSSItem) []rss.Media {
+	var media []rss.Media
+	for _, m := range item.Media() {
+		if httpURL(m.URL) != "" {
+			media = append(media, m)
+		}
+	}
+	return media
+}
+
+// setEpisodeInfo copies the podcast details of item to postP. The feed's
+// artwork stands in for episodes without their own.
+func setEpisodeInfo(postP *database.CreatePostParams, item rss.RSSItem, feedImage string, media []rss.Media) {
+	duration, ok := rss.ParseDuration(item.Duration)
+	if !ok && len(media) > 0 && media[0].Duration > 0 {
+		duration, ok = media[0].Duration, true
+	}
+	if ok {
+		postP.DurationSeconds = sql.NullInt32{Int32: int32(duration.Seconds()), Valid: true}
+	}
+	if season, err := strconv.Atoi(strings.TrimSpace(item.Season)); err == nil {
+		postP.Season = sql.NullInt32{Int32: int32(season), Valid: true}
+	}
+	if episode, err := strconv.Atoi(strings.TrimSpace(item.Episode)); err == nil {
+		postP.Episode = sql.NullInt32{Int32: int32(episode), Valid: true}
+	}
+	if len(media) > 0 {
+		postP.ImageUrl = nullString(httpURL(cmp.Or(item.ItunesImage.Href, feedImage)))
+	}
+}
+
+// storeEnclosures records the attached files of a new post. A failure only
+// loses the attachment, never the post.
+func storeEnclosures(s *app.State, post database.Post, media []rss.Media) {
+	for i, m := range media {
+		enclosureP := database.CreateEnclosureParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			PostID:    post.ID,
+			Position:  int32(i),
+			Url:       m.URL,
+			MimeType:  m.Type,
+			Length:    m.Length,
+		}
+		if err := s.Db.CreateEnclosure(context.Background(), enclosureP); err != nil {
+			fmt.Fprintf(stderr(s), "enclosure error storing %s: %v\n", m.URL, err)
+		}
+	}
+}
+
+// storeFullContent downloads the page behind post and stores its main
+// article. Posts that already carry the full text in content:encoded are
+// left alone. Failures are only reported in debug output since the summary
+// from the feed is still there to read.
+func storeFullContent(s *app.State, post database.Post) database.Post {
+	if post.Content.Valid {
+		return post
+	}
+	debugf(s, "fetching full content of %s", post.Url)
+	article, err := readability.Fetch(context.Background(), rss.HTTPClient(), post.Url)
+	if err != nil {
+		debugf(s, "skipping full content: %v", err)
+		return post
+	}
+
+	content := sql.NullString{String: article.Content, Valid: true}
+	contentP := database.UpdatePostContentParams{
+		ID:        post.ID,
+		Content:   content,
+		UpdatedAt: time.Now(),
+	}
+	if err := s.Db.UpdatePostContent(context.Background(), contentP); err != nil {
+		fmt.Fprintf(stderr(s), "full content error storing %s: %v\n", post.Url, err)
+		return post
+	}
+	post.Content = content
+	return post
+}
+
+// applyRules evaluates the rules of every user following feed against newly
+// stored posts, categories holds the item categories of each post. A broken
+// rule or failed action is reported and skipped so it cannot stop the
+// aggregator.
+func applyRules(s *app.State, feed database.Feed, posts []database.Post, categories map[uuid.UUID][]string) {
+	ruleRows, err := s.Db.GetRulesForFeedFollowers(context.Background(), feed.ID)
+	if err != nil {
+		fmt.Fprintf(stderr(s), "rules error loading rules for %s: %v\n", feed.Url, err)
+		return
+	}
+
+	for _, row := range ruleRows {
+		matcher, err := rules.Compile(ruleFromRow(row.Field, row.MatchType, row.Pattern, row.Action, row.ActionArg))
+		if err != nil {
+			debugf(s, "skipping rule %s: %v", row.ID, err)
+			continue
+		}
+
+		for _, post := range posts {
+			if !matcher.Matches(ruleItem(post, row.FeedName, row.CategoryName.String, categories[post.ID])) {
+				continue
+			}
+			debugf(s, "rule %s matched %s, applying %s", row.ID, post.Url, row.Action)
+			if err := applyRuleAction(s, row.UserID, matcher.Rule, post, row.FeedName); err != nil {
+				fmt.Fprintf(stderr(s), "rules error applying rule %s to %s: %v\n", row.ID, post.Url, err)
+			}
+		}
+	}
+}
+
+func applyRuleAction(s *app.State, userID uuid.UUID, r rules.Rule, post database.Post, feedName string) error {
+	now := time.Now()
+	switch r.Action {
+	case rules.ActionHide:
+		return s.Db.HidePost(context.Background(), database.HidePostParams{UserID: userID, PostID: post.ID, CreatedAt: now})
+	case rules.ActionMarkRead:
+		return s.Db.MarkPostRead(context.Background(), database.MarkPostReadParams{UserID: userID, PostID: post.ID, ReadAt: now})
+	case rules.ActionStar:
+		return s.Db.CreatePostStar(context.Background(), database.CreatePostStarParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			PostID:    post.ID,
+			UserID:    userID,
+		})
+	case rules.ActionTag:
+		return s.Db.TagPost(context.Background(), database.TagPostParams{UserID: userID, PostID: post.ID, Tag: r.ActionArg, CreatedAt: now})
+	case rules.ActionNotify:
+		return notify.Writer{W: stderr(s)}.Notify(context.Background(), postNotification(post, "rule", "", feedName))
+	}
+	return fmt.Errorf("%w: %q", rules.ErrUnknownAction, r.Action)
+}
+
+// applyWatches sends a notification for every new post matching a watch of a
+// user following feed. Failed deliveries are reported and do not stop the
+// remaining watches.
+func applyWatches(s *app.State, feed database.Feed, posts []database.Post, categories map[uuid.UUID][]string) {
+	watches, err := s.Db.GetWatchesForFeedFollowers(context.Background(), feed.ID)
+	if err != nil {
+		fmt.Fprintf(stderr(s), "watch error loading watches for %s: %v\n", feed.Url, err)
+		return
+	}
+
+	for _, watch := range watches {
+		matcher, err := rules.Compile(rules.Rule{
+			Field:   watch.Field,
+			Match:   watch.MatchType,
+			Pattern: watch.Pattern,
+			Action:  rules.ActionNotify,
+		})
+		if err != nil {
+			debugf(s, "skipping watch %s: %v", watch.Name, err)
+			continue
+		}
+		notifier, err := notify.New(watch.Notifier, watch.Target, notifyAllowlist(s))
+		if err != nil {
+			fmt.Fprintf(stderr(s), "watch error: skipping %s of %s: %v\n", watch.Name, watch.UserName, err)
+			continue
+		}
+
+		for _, post := range posts {
+			if !matcher.Matches(ruleItem(post, watch.FeedName, watch.CategoryName.String, categories[post.ID])) {
+				continue
+			}
+			debugf(s, "watch %s matched %s, notifying via %s", watch.Name, post.Url, watch.Notifier)
+			n := postNotification(post, watch.Name, watch.UserName, watch.FeedName)
+			if err := notifier.Notify(context.Background(), n); err != nil {
+				fmt.Fprintf(stderr(s), "watch error notifying %s about %s: %v\n", watch.Name, post.Url, err)
+			}
+		}
+	}
+}
+
+// deliverWebhooks queues every new post for the webhooks of users following
+// feed that are not limited to another feed and whose filter matches. The
+// queue is sent by sendQueuedWebhooks, so slow receivers never hold up
+// fetching.
+func deliverWebhooks(s *app.State, feed database.Feed, posts []database.Post, categories map[uuid.UUID][]string) {
+	hooks, err := s.Db.GetWebhooksForFeed(context.Background(), feed.ID)
+	if err != nil {
+		fmt.Fprintf(stderr(s), "webhook error loading webhooks for %s: %v\n", feed.Url, err)
+		return
+	}
+
+	for _, hook := range hooks {
+		var filter *rules.Matcher
+		if hook.Filter != "" {
+			filter, err = rules.ParseFilter(hook.Filter)
+			if err != nil {
+				debugf(s, "skipping webhook %s: %v", hook.ID, err)
+				continue
+			}
+		}
+
+		for _, post := range posts {
+			if filter != nil && !filter.Matches(ruleItem(post, hook.FeedName, hook.CategoryName.String, categories[post.ID])) {
+				continue
+			}
+			payload := webhook.Payload{
+				Event: webhook.EventPostCreated,
+				Feed:  webhook.Feed{Name: hook.FeedName, URL: feed.Url},
+				Post: webhook.Post{
+					ID:          post.ID.String(),
+					Title:       post.Title,
+					URL:         post.Url,
+					Description: post.Description.String,
+					Author:      post.Author.String,
+					PublishedAt: post.PublishedAt.Time,
+				},
+				Sent: time.Now().UTC(),
+			}
+			body, err := webhook.Body(hook.Template, payload)
+			if err != nil {
+				fmt.Fprintf(stderr(s), "webhook error rendering %s for %s: %v\n", post.Url, hook.Url, err)
+				continue
+			}
+			queueP := database.QueueWebhookDeliveryParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				WebhookID: hook.ID,
+				PostID:    uuid.NullUUID{UUID: post.ID, Valid: true},
+				Event:     payload.Event,
+				Body:      string(body),
+			}
+			if err := s.Db.QueueWebhookDelivery(context.Background(), queueP); err != nil {
+				fmt.Fprintf(stderr(s), "webhook error queueing %s for %s: %v\n", post.Url, hook.Url, err)
+			}
+		}
+	}
+}
+
+const (
+	// webhookAttempts is how often a queued delivery is tried before it is
+	// given up, waiting webhookBackoff after the first failure and twice as
+	// long after each further one.
+	webhookAttempts = 3
+	webhookBackoff  = time.Minute
+	// webhookLease keeps a claimed delivery from other workers while it is
+	// being sent. It is well above the 30s fetch timeout.
+	webhookLease = 5 * time.Minute
+	webhookBatch = 50
+	// webhookDeadline bounds a single run of the delivery worker, which
+	// looks for due deliveries every webhookPoll while agg keeps running.
+	webhookDeadline = 2 * time.Minute
+	webhookPoll     = 10 * time.Second
+)
+
+// sendQueuedWebhooks sends due queued deliveries until none are left or ctx
+// is done. Each run makes one attempt per delivery, failures are retried by
+// a later run.
+func sendQueuedWebhooks(ctx context.Context, s *app.State) {
+	sender := webhook.NewSender(rss.HTTPClient())
+	sender.Attempts = 1
+	for ctx.Err() == nil {
+		now := time.Now()
+		claimP := database.ClaimWebhookDeliveriesParams{
+			LeaseUntil:    sql.NullTime{Time: now.Add(webhookLease), Valid: true},
+			Now:           sql.NullTime{Time: now, Valid: true},
+			MaxDeliveries: webhookBatch,
+		}
+		deliveries, err := s.Db.ClaimWebhookDeliveries(ctx, claimP)
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Fprintf(stderr(s), "webhook error loading queued deliveries: %v\n", err)
+			}
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		for _, d := range deliveries {
+			if ctx.Err() != nil {
+				// The lease runs out and a later run picks them up.
+				return
+			}
+			debugf(s, "delivering %s %s to %s", d.Event, d.ID, d.Url)
+			res := sender.Send(ctx, d.Url, d.Secret, d.Event, d.ID.String(), []byte(d.Body))
+			recordWebhookAttempt(s, d, res)
+		}
+	}
+}
+
+// recordWebhookAttempt stores the outcome of an attempt and schedules the
+// next one when the failure may be temporary.
+func recordWebhookAttempt(s *app.State, d database.ClaimWebhookDeliveriesRow, res webhook.Result) {
+	attempts := d.Attempts + 1
+	updateP := database.UpdateWebhookDeliveryParams{
+		ID:         d.ID,
+		Attempts:   attempts,
+		StatusCode: int32(res.StatusCode),
+	}
+	if res.Err != nil {
+		updateP.Error = res.Err.Error()
+		if res.Retry && attempts < webhookAttempts {
+			updateP.Pending = true
+			next := time.Now().Add(webhookBackoff << (attempts - 1))
+			updateP.NextAttemptAt = sql.NullTime{Time: next, Valid: true}
+		} else {
+			fmt.Fprintf(stderr(s), "webhook error delivering %s to %s after %d attempts: %v\n", d.ID, d.Url, attempts, res.Err)
+		}
+	}
+	if err := s.Db.UpdateWebhookDelivery(context.Background(), updateP); err != nil {
+		fmt.Fprintf(stderr(s), "webhook error recording delivery %s: %v\n", d.ID, err)
+	}
+}
+
+// runWebhookWorker sends queued deliveries every interval until ctx is done,
+// bounding every run by webhookDeadline.
+func runWebhookWorker(ctx context.Context, s *app.State, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, webhookDeadline)
+		sendQueuedWebhooks(runCtx, s)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendWebhook delivers payload right away, retrying with backoff, and
+// records the outcome in the delivery log. It is used for test deliveries
+// where the caller waits for the result.
+func sendWebhook(s *app.State, hookID uuid.UUID, url, secret, tmpl string, payload webhook.Payload, postID uuid.NullUUID) webhook.Result {
+	deliveryID := uuid.New()
+	body, err := webhook.Body(tmpl, payload)
+	var res webhook.Result
+	if err != nil {
+		res.Err = err
+	} else {
+		debugf(s, "delivering %s %s to %s", payload.Event, deliveryID, url)
+		res = webhook.NewSender(rss.HTTPClient()).Send(context.Background(), url, secret, payload.Event, deliveryID.String(), body)
+	}
+
+	var errText string
+	if res.Err != nil {
+		errText = res.Err.Error()
+	}
+	deliveryP := database.CreateWebhookDeliveryParams{
+		ID:         deliveryID,
+		CreatedAt:  time.Now(),
+		WebhookID:  hookID,
+		PostID:     postID,
+		Event:      payload.Event,
+		Attempts:   int32(res.Attempts),
+		StatusCode: int32(res.StatusCode),
+		Error:      errText,
+	}
+	if err := s.Db.CreateWebhookDelivery(context.Background(), deliveryP); err != nil {
+		fmt.Fprintf(stderr(s), "webhook error recording delivery %s: %v\n", deliveryID, err)
+	}
+	return res
+}
+
+func postNotification(post database.Post, watch, user, feed string) notify.Notification {
+	return notify.Notification{
+		Watch:       watch,
+		User:        user,
+		Feed:        feed,
+		Title:       post.Title,
+		URL:         post.Url,
+		Description: post.Description.String,
+		Author:      post.Author.String,
+		PublishedAt: post.PublishedAt.Time,
+	}
+}
+
+func ruleFromRow(field, match, pattern, action, arg string) rules.Rule {
+	return rules.Rule{Field: field, Match: match, Pattern: pattern, Action: action, ActionArg: arg}
+}
+
+func ruleItem(post database.Post, feedName, category string, itemCategories []string) rules.Item {
+	return rules.Item{
+		Feed:        feedName,
+		Title:       post.Title,
+		Description: htmltext.Text(post.Description.String),
+		Content:     htmltext.Text(post.Content.String),
+		Author:      post.Author.String,
+		Link:        post.Url,
+		Category:    category,
+		Categories:  itemCategories,
+	}
+}
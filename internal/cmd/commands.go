@@ -2,36 +2,130 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"time"
 
 	"github.com/theandyeh/gator/internal/app"
 )
 
 type Command struct {
-	Name string
-	Args []string
+	Name  string
+	Args  []string
+	Flags map[string]any
+
+	set map[string]bool
 }
 
 type Commands struct {
-	List map[string]func(*app.State, Command) error
+	List  map[string]func(*app.State, Command) error
+	Specs map[string]Spec
 }
 
 func (c *Commands) Run(s *app.State, cm Command) error {
-	if cmdFunc, exists := c.List[cm.Name]; exists {
-		return cmdFunc(s, cm)
+	cmdFunc, exists := c.List[cm.Name]
+	if !exists {
+		return errors.New("cmd error: command not found")
+	}
+
+	if spec, ok := c.Specs[cm.Name]; ok {
+		parsed, err := spec.parse(cm)
+		if err != nil {
+			return err
+		}
+		cm = parsed
 	}
-	return errors.New("cmd error: command not found")
+
+	return cmdFunc(s, cm)
 }
 
-func (c *Commands) Register(name string, f func(*app.State, Command) error) error {
+// Register adds a command handler. An optional spec describes the command's
+// positional args and flags, which are then parsed and checked before the
+// handler runs.
+func (c *Commands) Register(name string, f func(*app.State, Command) error, spec ...Spec) error {
 	if _, exists := c.List[name]; exists {
 		return errors.New("cmd error: command already registered")
 	}
+
+	if len(spec) > 0 {
+		if err := spec[0].validate(); err != nil {
+			return fmt.Errorf("cmd error registering %s: %w", name, err)
+		}
+		if spec[0].Name == "" {
+			spec[0].Name = name
+		}
+		c.Specs[name] = spec[0]
+	}
+
 	c.List[name] = f
 	return nil
 }
 
+// HandlerHelp lists every registered command with its usage.
+func (c *Commands) HandlerHelp(s *app.State, cm Command) error {
+	if len(cm.Args) > 0 {
+		spec, ok := c.Specs[cm.Args[0]]
+		if !ok {
+			return fmt.Errorf("help handler error: no usage registered for %s", cm.Args[0])
+		}
+		out := newList("Usage", "usage", "description")
+		out.add(spec.usage(), spec.Description)
+		for _, f := range spec.Flags {
+			out.add(fmt.Sprintf("  --%s (default %v)", f.Name, f.Default), f.Usage)
+		}
+		return render(s, out)
+	}
+
+	names := make([]string, 0, len(c.List))
+	for name := range c.List {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Global flags are only read in front of the command name, everything
+	// after it belongs to the command.
+	out := newList("Commands, run as gator [--config <path>] [--user <name>] [--output <format>] [--verbose] <command>", "command", "usage", "description")
+	for _, name := range names {
+		spec, ok := c.Specs[name]
+		if !ok {
+			out.add(name, name, "")
+			continue
+		}
+		out.add(name, spec.usage(), spec.Description)
+	}
+	return render(s, out)
+}
+
 func CreateCommandsList() *Commands {
 	return &Commands{
-		List: make(map[string]func(*app.State, Command) error),
+		List:  make(map[string]func(*app.State, Command) error),
+		Specs: make(map[string]Spec),
 	}
 }
+
+// String returns the value of a string flag, or "" if it was not declared.
+func (c Command) String(name string) string {
+	v, _ := c.Flags[name].(string)
+	return v
+}
+
+func (c Command) Int(name string) int {
+	v, _ := c.Flags[name].(int)
+	return v
+}
+
+func (c Command) Bool(name string) bool {
+	v, _ := c.Flags[name].(bool)
+	return v
+}
+
+func (c Command) Duration(name string) time.Duration {
+	v, _ := c.Flags[name].(time.Duration)
+	return v
+}
+
+// IsSet reports whether a flag was given on the command line rather than
+// left at its default.
+func (c Command) IsSet(name string) bool {
+	return c.set[name]
+}
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/theandyeh/gator/internal/app"
 	"github.com/theandyeh/gator/internal/config"
@@ -131,3 +133,162 @@ func TestRunWithArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestRunWithSpec(t *testing.T) {
+	cmdList := CreateCommandsList()
+	var received Command
+	mockHandler := func(s *app.State, c Command) error {
+		received = c
+		return nil
+	}
+
+	err := cmdList.Register("test", mockHandler, Spec{
+		Args: []Arg{{Name: "url"}, {Name: "rest", Variadic: true}},
+		Flags: []Flag{
+			{Name: "limit", Default: 10},
+			{Name: "dry-run", Default: false},
+			{Name: "every", Default: time.Minute},
+			{Name: "feed", Default: ""},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register command: %v", err)
+	}
+
+	state := &app.State{Cfg: &config.Config{}}
+	cmd := Command{Name: "test", Args: []string{"https://x.com", "--limit", "5", "extra", "--dry-run", "--", "--feed"}}
+	if err := cmdList.Run(state, cmd); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	expectedArgs := []string{"https://x.com", "extra", "--feed"}
+	if len(received.Args) != len(expectedArgs) {
+		t.Fatalf("Expected args %v, got %v", expectedArgs, received.Args)
+	}
+	for i, arg := range expectedArgs {
+		if received.Args[i] != arg {
+			t.Errorf("Arg %d: expected %s, got %s", i, arg, received.Args[i])
+		}
+	}
+	if received.Int("limit") != 5 {
+		t.Errorf("Expected limit 5, got %d", received.Int("limit"))
+	}
+	if !received.Bool("dry-run") {
+		t.Error("Expected dry-run to be set")
+	}
+	if received.Duration("every") != time.Minute {
+		t.Errorf("Expected default every of 1m, got %s", received.Duration("every"))
+	}
+	if received.String("feed") != "" {
+		t.Errorf("Expected empty feed flag, got %q", received.String("feed"))
+	}
+	if !received.IsSet("limit") || received.IsSet("every") {
+		t.Error("Expected only flags given on the command line to be reported as set")
+	}
+}
+
+func TestRunWithVariadicExclusions(t *testing.T) {
+	cmdList := CreateCommandsList()
+	var received Command
+	mockHandler := func(s *app.State, c Command) error {
+		received = c
+		return nil
+	}
+
+	cmdList.Register("search", mockHandler, Spec{
+		Args:  []Arg{{Name: "query", Variadic: true}},
+		Flags: []Flag{{Name: "limit", Default: 20}, {Name: "all", Default: false}},
+	})
+	state := &app.State{Cfg: &config.Config{}}
+	cmd := Command{Name: "search", Args: []string{"postgres", "-vacuum", "--limit", "5", "--", "-index", "--all"}}
+	if err := cmdList.Run(state, cmd); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	expectedArgs := []string{"postgres", "-vacuum", "-index", "--all"}
+	if strings.Join(received.Args, " ") != strings.Join(expectedArgs, " ") {
+		t.Errorf("Expected args %v, got %v", expectedArgs, received.Args)
+	}
+	if received.Int("limit") != 5 || received.Bool("all") {
+		t.Errorf("Expected limit 5 and all unset, got %d %t", received.Int("limit"), received.Bool("all"))
+	}
+
+	if err := cmdList.Run(state, Command{Name: "search", Args: []string{"postgres", "--limit"}}); err == nil {
+		t.Error("Expected error for a flag without its value")
+	}
+}
+
+func TestRunWithSpecMissingArgs(t *testing.T) {
+	cmdList := CreateCommandsList()
+	called := false
+	mockHandler := func(s *app.State, c Command) error {
+		called = true
+		return nil
+	}
+
+	cmdList.Register("test", mockHandler, Spec{Args: []Arg{{Name: "name"}, {Name: "url"}}})
+	state := &app.State{Cfg: &config.Config{}}
+
+	if err := cmdList.Run(state, Command{Name: "test", Args: []string{"only-name"}}); err == nil {
+		t.Error("Expected error for missing positional arg")
+	}
+	if err := cmdList.Run(state, Command{Name: "test", Args: []string{"a", "b", "c"}}); err == nil {
+		t.Error("Expected error for too many positional args")
+	}
+	if err := cmdList.Run(state, Command{Name: "test", Args: []string{"a", "b", "--unknown"}}); err == nil {
+		t.Error("Expected error for unknown flag")
+	}
+	if called {
+		t.Error("Handler should not run when args do not match the spec")
+	}
+}
+
+func TestRegisterInvalidSpec(t *testing.T) {
+	cmdList := CreateCommandsList()
+	mockHandler := func(s *app.State, c Command) error {
+		return nil
+	}
+
+	err := cmdList.Register("test", mockHandler, Spec{Flags: []Flag{{Name: "ratio", Default: 1.5}}})
+	if err == nil {
+		t.Error("Expected error for unsupported flag type")
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	args := []string{"--config", "/tmp/gator.json", "--user=alice", "--output", "json", "-v", "rules", "add", "title", "contains", "-o", "--user"}
+
+	g, rest, err := ParseGlobalFlags(args)
+	if err != nil {
+		t.Fatalf("ParseGlobalFlags failed: %v", err)
+	}
+	if g.Config != "/tmp/gator.json" || g.User != "alice" || g.Output != OutputJSON || !g.Verbose {
+		t.Errorf("Unexpected globals: %+v", g)
+	}
+
+	// Args after the command name belong to the command.
+	expected := []string{"rules", "add", "title", "contains", "-o", "--user"}
+	if len(rest) != len(expected) {
+		t.Fatalf("Expected remaining args %v, got %v", expected, rest)
+	}
+	for i, arg := range expected {
+		if rest[i] != arg {
+			t.Errorf("Arg %d: expected %s, got %s", i, arg, rest[i])
+		}
+	}
+
+	g, rest, err = ParseGlobalFlags([]string{"-v", "--", "-o"})
+	if err != nil || !g.Verbose || len(rest) != 1 || rest[0] != "-o" {
+		t.Errorf("Expected -- to end the global flags, got %+v %v %v", g, rest, err)
+	}
+
+	if _, _, err := ParseGlobalFlags([]string{"--output", "yaml", "users"}); err == nil {
+		t.Error("Expected error for unknown output format")
+	}
+	if _, _, err := ParseGlobalFlags([]string{"--user"}); err == nil {
+		t.Error("Expected error for missing flag value")
+	}
+	if _, _, err := ParseGlobalFlags([]string{"--dry-run", "feeds", "dedupe"}); err == nil {
+		t.Error("Expected error for a command flag before the command name")
+	}
+}
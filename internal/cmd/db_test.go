@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+)
+
+// testState connects to the database in GATOR_TEST_DB_URL and migrates a
+// schema of its own that is dropped when the test ends. Tests that need a
+// database are skipped without it.
+func testState(t *testing.T) *app.State {
+	t.Helper()
+	dbURL := os.Getenv("GATOR_TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("GATOR_TEST_DB_URL is not set")
+	}
+
+	admin, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	schema := fmt.Sprintf("gator_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	u, err := url.Parse(dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob(filepath.Join("..", "..", "sql", "schema", "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(src), "-- +goose Down")
+		if _, err := db.Exec(up); err != nil {
+			t.Fatalf("migrating %s: %v", filepath.Base(file), err)
+		}
+	}
+
+	return &app.State{Conn: db, Db: database.New(db), Out: &strings.Builder{}, Err: &strings.Builder{}}
+}
+
+// mustExec runs statements that set up a test and fails the test on error.
+func mustExec(t *testing.T, s *app.State, query string, args ...any) {
+	t.Helper()
+	if _, err := s.Conn.ExecContext(context.Background(), query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+// count returns the single number query selects.
+func count(t *testing.T, s *app.State, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := s.Conn.QueryRowContext(context.Background(), query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Spec describes how a command is invoked. Args and Flags are parsed from the
+// raw command line before the handler runs; flags may appear before, between
+// or after positional args, and everything after "--" is positional. When
+// the last arg is variadic, words starting with "-" that are not flags of the
+// command are positional too, like the exclusions of a search query.
+type Spec struct {
+	Name        string
+	Description string
+	Args        []Arg
+	Flags       []Flag
+}
+
+type Arg struct {
+	Name     string
+	Optional bool
+	Variadic bool
+}
+
+// Flag is a typed command flag. The type of Default decides how the value is
+// parsed and must be a string, int, bool or time.Duration.
+type Flag struct {
+	Name    string
+	Usage   string
+	Default any
+}
+
+// Globals are options accepted by every command.
+type Globals struct {
+	Config  string
+	User    string
+	Output  string
+	Verbose bool
+}
+
+func (sp Spec) validate() error {
+	for i, a := range sp.Args {
+		if a.Variadic && i != len(sp.Args)-1 {
+			return fmt.Errorf("variadic arg %s must be last", a.Name)
+		}
+		if !a.Optional && !a.Variadic && i > 0 && (sp.Args[i-1].Optional || sp.Args[i-1].Variadic) {
+			return fmt.Errorf("required arg %s follows an optional arg", a.Name)
+		}
+	}
+
+	for _, f := range sp.Flags {
+		switch f.Default.(type) {
+		case string, int, bool, time.Duration:
+		default:
+			return fmt.Errorf("flag %s has unsupported type %T", f.Name, f.Default)
+		}
+	}
+	return nil
+}
+
+func (sp Spec) usage() string {
+	parts := []string{sp.Name}
+	for _, a := range sp.Args {
+		switch {
+		case a.Variadic:
+			parts = append(parts, fmt.Sprintf("[%s...]", a.Name))
+		case a.Optional:
+			parts = append(parts, fmt.Sprintf("[%s]", a.Name))
+		default:
+			parts = append(parts, fmt.Sprintf("<%s>", a.Name))
+		}
+	}
+	for _, f := range sp.Flags {
+		if _, ok := f.Default.(bool); ok {
+			parts = append(parts, fmt.Sprintf("[--%s]", f.Name))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("[--%s %s]", f.Name, f.Name))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (sp Spec) parse(cm Command) (Command, error) {
+	fs := flag.NewFlagSet(sp.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	values := make(map[string]any, len(sp.Flags))
+	for _, f := range sp.Flags {
+		switch def := f.Default.(type) {
+		case string:
+			values[f.Name] = fs.String(f.Name, def, f.Usage)
+		case int:
+			values[f.Name] = fs.Int(f.Name, def, f.Usage)
+		case bool:
+			values[f.Name] = fs.Bool(f.Name, def, f.Usage)
+		case time.Duration:
+			values[f.Name] = fs.Duration(f.Name, def, f.Usage)
+		}
+	}
+
+	args, rest := cm.Args, []string(nil)
+	for i, a := range cm.Args {
+		if a == "--" {
+			args, rest = cm.Args[:i], cm.Args[i+1:]
+			break
+		}
+	}
+
+	// Flags are parsed one at a time, so an arg like -vacuum that is not a
+	// flag of the command can be kept as a positional of a variadic query.
+	variadic := len(sp.Args) > 0 && sp.Args[len(sp.Args)-1].Variadic
+	var positional []string
+	for len(args) > 0 {
+		arg := args[0]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			args = args[1:]
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil && variadic && name != "h" && name != "help" {
+			positional = append(positional, arg)
+			args = args[1:]
+			continue
+		}
+
+		n := 1
+		if f != nil && !hasValue && !isBoolFlag(f) && len(args) > 1 {
+			n = 2
+		}
+		if err := fs.Parse(args[:n]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return cm, fmt.Errorf("usage: %s", sp.usage())
+			}
+			return cm, fmt.Errorf("cmd error: %w, usage: %s", err, sp.usage())
+		}
+		args = args[n:]
+	}
+	positional = append(positional, rest...)
+
+	var required, max int
+	for _, a := range sp.Args {
+		if a.Variadic {
+			max = -1
+			continue
+		}
+		if !a.Optional {
+			required++
+		}
+		max++
+	}
+	if len(positional) < required {
+		return cm, fmt.Errorf("cmd error: %s expects at least %d args, usage: %s", sp.Name, required, sp.usage())
+	}
+	if max >= 0 && len(positional) > max {
+		return cm, fmt.Errorf("cmd error: %s expects at most %d args, usage: %s", sp.Name, max, sp.usage())
+	}
+
+	flags := make(map[string]any, len(values))
+	for name, ptr := range values {
+		switch v := ptr.(type) {
+		case *string:
+			flags[name] = *v
+		case *int:
+			flags[name] = *v
+		case *bool:
+			flags[name] = *v
+		case *time.Duration:
+			flags[name] = *v
+		}
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	cm.Args = positional
+	cm.Flags = flags
+	cm.set = set
+	return cm, nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// ParseGlobalFlags reads the global --config, --user, --output and
+// --verbose flags in front of the command name and returns them along with
+// the command and its args. Everything from the command name on is left to
+// the command, so its own args are never taken for global flags. A "--"
+// ends the global flags early.
+func ParseGlobalFlags(args []string) (Globals, []string, error) {
+	g := Globals{Output: OutputText}
+	rest := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = args[i+1:]
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			rest = args[i:]
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "config", "user", "output", "o":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return g, nil, fmt.Errorf("cmd error: --%s requires a value", name)
+				}
+				value = args[i+1]
+				i++
+			}
+			switch name {
+			case "config":
+				g.Config = value
+			case "user":
+				g.User = value
+			default:
+				g.Output = value
+			}
+		case "verbose", "v":
+			g.Verbose = !hasValue || value == "true"
+		default:
+			return g, nil, fmt.Errorf("cmd error: unknown global flag %s, command flags go after the command name", arg)
+		}
+	}
+
+	if !ValidOutputFormat(g.Output) {
+		return g, nil, fmt.Errorf("cmd error: unknown output format %q, expected text, json, csv or table", g.Output)
+	}
+
+	return g, rest, nil
+}
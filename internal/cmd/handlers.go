@@ -2,14 +2,18 @@ package cmd
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"net/url"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/theandyeh/gator/internal/app"
 	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedurl"
+	"github.com/theandyeh/gator/internal/follows"
 	"github.com/theandyeh/gator/internal/rss"
 )
 
@@ -41,8 +45,9 @@ func HandlerLogin(s *app.State, c Command) error {
 		return fmt.Errorf("login handler error: %w", err)
 	}
 
-	fmt.Printf("Successfuly set DB User: %s", c.Args[0])
-	return nil
+	out := newRecord("Successfully set DB user", "user")
+	out.add(c.Args[0])
+	return render(s, out)
 }
 
 func HandlerRegister(s *app.State, c Command) error {
@@ -73,9 +78,9 @@ func HandlerRegister(s *app.State, c Command) error {
 	s.Cfg.Current_db_user = c.Args[0]
 	s.Cfg.SetUser(c.Args[0])
 
-	fmt.Printf("Successfuly registered and set DB User: %s\n", c.Args[0])
-	fmt.Printf("User details:\n %v", user)
-	return nil
+	out := newRecord("Successfully registered and set DB user", "id", "name", "created_at")
+	out.add(user.ID, user.Name, user.CreatedAt)
+	return render(s, out)
 }
 
 func HandlerReset(s *app.State, c Command) error {
@@ -86,8 +91,9 @@ func HandlerReset(s *app.State, c Command) error {
 	s.Cfg.Current_db_user = ""
 	s.Cfg.SetUser("")
 
-	fmt.Println("Successfully reset database and cleared current user in config")
-	return nil
+	out := newRecord("Successfully reset database and cleared current user in config", "reset")
+	out.add(true)
+	return render(s, out)
 }
 
 func HandlerUsers(s *app.State, c Command) error {
@@ -96,37 +102,79 @@ func HandlerUsers(s *app.State, c Command) error {
 		return fmt.Errorf("users handler error: %w", err)
 	}
 
-	fmt.Println("Registered Users:")
+	out := newList("Registered Users", "name", "current")
 	for _, user := range users {
-		if user.Name == s.Cfg.Current_db_user {
-			fmt.Printf("- %s (current)\n", user.Name)
-			continue
-		}
-		fmt.Printf("- %s\n", user.Name)
+		out.add(user.Name, user.Name == s.Cfg.Current_db_user)
 	}
 
-	return nil
+	return render(s, out)
 }
 
 func HandlerAgg(s *app.State, c Command) error {
-	feed, err := rss.FetchFeed(context.Background(), "https://www.wagslane.dev/index.xml")
-	if err != nil {
-		return fmt.Errorf("agg handler error: %w", err)
+	every := c.Duration("every")
+	digestEvery := c.Duration("digest-every")
+	if every <= 0 {
+		feeds, err := s.Db.GetFeedsByAge(context.Background())
+		if err != nil {
+			return fmt.Errorf("agg handler error retrieving feeds: %w", err)
+		}
+
+		out := newList("New Posts", "feed_name", "title", "url", "published_at")
+		for _, feed := range feeds {
+			posts, err := scrapeFeed(s, feed)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "agg handler error: %v\n", err)
+				continue
+			}
+			for _, post := range posts {
+				out.add(feed.Name, post.Title, post.Url, post.PublishedAt.Time)
+			}
+		}
+		if digestEvery > 0 {
+			sendDueDigests(s, digestEvery)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), webhookDeadline)
+		sendQueuedWebhooks(ctx, s)
+		cancel()
+		return render(s, out)
 	}
 
-	fmt.Printf("Feed Title: %s\n", feed.Channel.Title)
-	fmt.Printf("Feed Link: %s\n", feed.Channel.Link)
-	fmt.Printf("Feed Description: %s\n", feed.Channel.Description)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runWebhookWorker(ctx, s, webhookPoll)
+
+	fmt.Fprintf(os.Stderr, "Collecting feeds every %s\n", every)
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		if digestEvery > 0 {
+			sendDueDigests(s, digestEvery)
+		}
 
-	fmt.Println("Feed Items:")
-	for _, item := range feed.Channel.Item {
-		fmt.Printf("- Title: %s\n", item.Title)
-		fmt.Printf("  Link: %s\n", item.Link)
-		fmt.Printf("  Description: %s\n", item.Description)
-		fmt.Printf("  Published Date: %s\n", item.PubDate)
-		fmt.Println("--------------------------")
+		feed, err := s.Db.GetNextFeedToFetch(context.Background())
+		if errors.Is(err, sql.ErrNoRows) {
+			debugf(s, "no feeds to fetch yet")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("agg handler error retrieving next feed: %w", err)
+		}
+
+		posts, err := scrapeFeed(s, feed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "agg handler error: %v\n", err)
+			continue
+		}
+
+		out := newList(fmt.Sprintf("New posts in %s", feed.Name), "feed_name", "title", "url", "published_at")
+		for _, post := range posts {
+			out.add(feed.Name, post.Title, post.Url, post.PublishedAt.Time)
+		}
+		if err := render(s, out); err != nil {
+			return err
+		}
 	}
-	return nil
 }
 
 func HandlerAddFeed(s *app.State, c Command, user database.User) error {
@@ -134,8 +182,19 @@ func HandlerAddFeed(s *app.State, c Command, user database.User) error {
 		return fmt.Errorf("addfeed handler error: not enough arguments provided, expected feed name and URL")
 	}
 
-	if !isValidUrl(c.Args[1]) {
-		return fmt.Errorf("addfeed handler error: invalid feed URL provided, check args (addfeed <name> <url>")
+	if err := validateUrl(s, c.Args[1]); err != nil {
+		return fmt.Errorf("addfeed handler error: invalid feed URL provided, check args (addfeed <name> <url>): %w", err)
+	}
+
+	feedUrl, err := feedurl.Normalize(c.Args[1])
+	if err != nil {
+		return fmt.Errorf("addfeed handler error: %w", err)
+	}
+
+	if existing, err := s.Db.GetFeedByURL(context.Background(), feedUrl); err == nil {
+		return fmt.Errorf("addfeed handler error: feed %s is already registered as %s, use follow instead", feedUrl, existing.Name)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("addfeed handler error checking existing feeds: %w", err)
 	}
 
 	feed := database.CreateFeedParams{
@@ -143,7 +202,7 @@ func HandlerAddFeed(s *app.State, c Command, user database.User) error {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		Name:      c.Args[0],
-		Url:       c.Args[1],
+		Url:       feedUrl,
 		UserID:    user.ID,
 	}
 
@@ -163,26 +222,27 @@ func HandlerAddFeed(s *app.State, c Command, user database.User) error {
 		return fmt.Errorf("addfeed handler error creating feed follow: %w", err)
 	}
 
-	fmt.Printf("Successfully added and followed feed:\n%v", feed)
-
-	return nil
+	out := newRecord("Successfully added and followed feed", "id", "name", "url", "user_name")
+	out.add(feed.ID, feed.Name, feed.Url, user.Name)
+	return render(s, out)
 }
 
 func HandlerFeeds(s *app.State, c Command) error {
+	if len(c.Args) > 0 && c.Args[0] == "dedupe" {
+		return handlerFeedsDedupe(s, c.Bool("dry-run"))
+	}
+
 	feeds, err := s.Db.GetFeeds(context.Background())
 	if err != nil {
 		return fmt.Errorf("feeds handler error retrieving data: %w", err)
 	}
 
-	fmt.Println("Registered Feeds:")
+	out := newList("Registered Feeds", "name", "url", "user_name")
 	for _, feed := range feeds {
-		fmt.Printf("- Name: %s\n", feed.Name)
-		fmt.Printf("  URL: %s\n", feed.Url)
-		fmt.Printf("  User Name: %s\n", feed.Username.String)
-		fmt.Println("--------------------------")
+		out.add(feed.Name, feed.Url, feed.Username.String)
 	}
 
-	return nil
+	return render(s, out)
 }
 
 func HandlerFollow(s *app.State, c Command, user database.User) error {
@@ -190,98 +250,298 @@ func HandlerFollow(s *app.State, c Command, user database.User) error {
 		return fmt.Errorf("follow handler error: no feed url provided to follow")
 	}
 
-	feedUrl := c.Args[0]
-	if !isValidUrl(feedUrl) {
-		return fmt.Errorf("follow handler error: invalid feed URL provided")
+	followRow, created, err := follows.Follow(context.Background(), s.Db, rss.FetchFeed, user, c.Args[0], s.Cfg.Allow_private_urls)
+	if err != nil {
+		return fmt.Errorf("follow handler error: %w", err)
 	}
 
-	feeds, err := s.Db.GetFeeds(context.Background())
+	if created {
+		return renderFollow(s, "Successfully added and followed new feed", followRow)
+	}
+	return renderFollow(s, "Successfully followed feed", followRow)
+}
+
+func HandlerUnfollow(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("unfollow handler error: no feed url provided to unfollow")
+	}
+
+	feed, err := follows.Unfollow(context.Background(), s.Db, user, c.Args[0])
 	if err != nil {
-		return fmt.Errorf("follow handler error retrieving feeds: %w", err)
+		return fmt.Errorf("unfollow handler error: %w", err)
 	}
 
-	var feedRegistered bool
+	out := newRecord("Successfully unfollowed feed", "feed_name", "url", "user_name")
+	out.add(feed.Name, feed.Url, user.Name)
+	return render(s, out)
+}
 
-	for _, f := range feeds {
-		if f.Url == feedUrl {
-			feedRegistered = true
+func HandlerFollowing(s *app.State, c Command, user database.User) error {
+	following, err := s.Db.GetFeedFollowsByUserID(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("following handler error retrieving data: %w", err)
+	}
 
-			followP := database.CreateFeedFollowParams{
-				ID:        uuid.New(),
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
-				FeedID:    f.ID,
-				UserID:    user.ID,
+	if c.Bool("by-category") {
+		sort.SliceStable(following, func(i, j int) bool {
+			a, b := following[i].CategoryName, following[j].CategoryName
+			if a.String != b.String {
+				// uncategorized feeds go last
+				return b.String == "" || (a.String != "" && a.String < b.String)
 			}
+			return following[i].FeedName < following[j].FeedName
+		})
+	}
 
-			followRow, err := s.Db.CreateFeedFollow(context.Background(), followP)
-			if err != nil {
-				return fmt.Errorf("follow handler error creating feed follow: %w", err)
-			}
+	out := newList(fmt.Sprintf("Feeds followed by user %s", user.Name), "feed_name", "feed_id", "user_name", "category", "unread", "priority", "muted", "notes")
+	for _, follow := range following {
+		out.add(follow.FeedName, follow.FeedID, follow.UserName, follow.CategoryName.String, follow.UnreadCount, follow.Priority, follow.Muted, follow.Notes.String)
+	}
 
-			fmt.Printf("Successfully followed feed:\n%v", followRow)
-			return nil
-		}
+	return render(s, out)
+}
+
+// HandlerFollowEdit changes how a followed feed is shown to the current user
+// only. Flags that are not given keep their current value.
+func HandlerFollowEdit(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("follow-edit handler error: no feed url provided")
+	}
+
+	feedUrl, err := feedurl.Normalize(c.Args[0])
+	if err != nil {
+		return fmt.Errorf("follow-edit handler error: %w", err)
+	}
+	feed, err := s.Db.GetFeedByURL(context.Background(), feedUrl)
+	if err != nil {
+		return fmt.Errorf("follow-edit handler error retrieving feed %s: %w", feedUrl, err)
 	}
 
-	if !feedRegistered {
-		feed, err := rss.FetchFeed(context.Background(), feedUrl)
+	follow, err := s.Db.GetFeedFollow(context.Background(), database.GetFeedFollowParams{UserID: user.ID, FeedID: feed.ID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("follow-edit handler error: not following %s", feedUrl)
+	} else if err != nil {
+		return fmt.Errorf("follow-edit handler error: %w", err)
+	}
+
+	if c.IsSet("mute") && c.IsSet("unmute") {
+		return fmt.Errorf("follow-edit handler error: --mute and --unmute are mutually exclusive")
+	}
+
+	updateP := database.UpdateFeedFollowOverridesParams{
+		Title:     follow.Title,
+		Muted:     follow.Muted,
+		Priority:  follow.Priority,
+		Notes:     follow.Notes,
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	}
+	if c.IsSet("title") {
+		updateP.Title = sql.NullString{String: c.String("title"), Valid: c.String("title") != ""}
+	}
+	if c.IsSet("notes") {
+		updateP.Notes = sql.NullString{String: c.String("notes"), Valid: c.String("notes") != ""}
+	}
+	if c.IsSet("priority") {
+		updateP.Priority = int32(c.Int("priority"))
+	}
+	if c.IsSet("mute") {
+		updateP.Muted = true
+	}
+	if c.IsSet("unmute") {
+		updateP.Muted = false
+	}
+
+	updated, err := s.Db.UpdateFeedFollowOverrides(context.Background(), updateP)
+	if err != nil {
+		return fmt.Errorf("follow-edit handler error: %w", err)
+	}
+
+	title := feed.Name
+	if updated.Title.Valid {
+		title = updated.Title.String
+	}
+	out := newRecord("Updated feed follow", "feed_url", "title", "muted", "priority", "notes")
+	out.add(feed.Url, title, updated.Muted, updated.Priority, updated.Notes.String)
+	return render(s, out)
+}
+
+func handlerFeedsDedupe(s *app.State, dryRun bool) error {
+	feeds, err := s.Db.GetFeedsByAge(context.Background())
+	if err != nil {
+		return fmt.Errorf("feeds dedupe handler error retrieving data: %w", err)
+	}
+
+	// Feeds are ordered oldest first. The http and https spellings of a url
+	// are one feed and https is preferred as the target. The oldest feed of
+	// each group is kept unless a later one is already stored as the target.
+	out := newList("Feed dedupe", "action", "from_url", "to_url")
+	canonicals := make(map[string]string)
+	targets := make(map[string]string)
+	var order []string
+	for _, feed := range feeds {
+		canonical, err := feedurl.Normalize(feed.Url)
 		if err != nil {
-			return fmt.Errorf("follow handler error fetching feed: %w", err)
+			out.add("skipped", feed.Url, "")
+			continue
 		}
+		canonicals[feed.Url] = canonical
 
-		newFeed := database.CreateFeedParams{
-			ID:        uuid.New(),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-			Name:      feed.Channel.Title,
-			Url:       feedUrl,
-			UserID:    user.ID,
+		key := feedurl.Key(canonical)
+		target, exists := targets[key]
+		if !exists {
+			order = append(order, key)
+			target = canonical
 		}
+		targets[key] = feedurl.Prefer(target, canonical)
+	}
 
-		createdFeed, err := s.Db.CreateFeed(context.Background(), newFeed)
-		if err != nil {
-			return fmt.Errorf("follow handler error creating new feed: %w", err)
+	keep := make(map[string]database.Feed)
+	var duplicates []database.Feed
+	for _, feed := range feeds {
+		canonical, ok := canonicals[feed.Url]
+		if !ok {
+			continue
 		}
 
-		followP := database.CreateFeedFollowParams{
-			ID:        uuid.New(),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-			FeedID:    createdFeed.ID,
-			UserID:    user.ID,
+		key := feedurl.Key(canonical)
+		kept, exists := keep[key]
+		if !exists {
+			keep[key] = feed
+			continue
 		}
+		if feed.Url == targets[key] && kept.Url != targets[key] {
+			keep[key] = feed
+			duplicates = append(duplicates, kept)
+			continue
+		}
+		duplicates = append(duplicates, feed)
+	}
 
-		followRow, err := s.Db.CreateFeedFollow(context.Background(), followP)
-		if err != nil {
-			return fmt.Errorf("follow handler error creating feed follow for new feed: %w", err)
+	for _, dup := range duplicates {
+		kept := keep[feedurl.Key(canonicals[dup.Url])]
+		if dryRun {
+			out.add("would merge", dup.Url, kept.Url)
+			continue
 		}
 
-		fmt.Printf("Successfully added and followed new feed:\n%v", followRow)
-	}
-	return nil
-}
+		if err := mergeFeed(s, dup, kept); err != nil {
+			return fmt.Errorf("feeds dedupe handler error merging %s: %w", dup.Url, err)
+		}
 
-func HandlerFollowing(s *app.State, c Command, user database.User) error {
-	following, err := s.Db.GetFeedFollowsByUserID(context.Background(), user.ID)
-	if err != nil {
-		return fmt.Errorf("following handler error retrieving data: %w", err)
+		out.add("merged", dup.Url, kept.Url)
 	}
 
-	fmt.Printf("Feeds followed by user %s:\n", user.Name)
-	for _, follow := range following {
-		fmt.Printf("- Feed Name: %s\n", follow.FeedName)
-		fmt.Printf("  Feed ID: %s\n", follow.FeedID)
-		fmt.Printf("  User Name: %s\n", follow.UserName)
-		fmt.Println("--------------------------")
+	for _, key := range order {
+		kept, canonical := keep[key], targets[key]
+		if kept.Url == canonical {
+			continue
+		}
+		if dryRun {
+			out.add("would normalize", kept.Url, canonical)
+			continue
+		}
+
+		updateP := database.UpdateFeedURLParams{
+			ID:        kept.ID,
+			Url:       canonical,
+			UpdatedAt: time.Now(),
+		}
+		if err := s.Db.UpdateFeedURL(context.Background(), updateP); err != nil {
+			return fmt.Errorf("feeds dedupe handler error updating %s: %w", kept.Url, err)
+		}
+
+		out.add("normalized", kept.Url, canonical)
 	}
 
-	return nil
+	return render(s, out)
 }
 
 //HELPERS
 
-func isValidUrl(s string) bool {
-	_, err := url.ParseRequestURI(s)
-	return err == nil
+// mergeFeed moves everything that belongs to dup over to kept and deletes
+// dup in one transaction, so a failure leaves both feeds untouched.
+func mergeFeed(s *app.State, dup, kept database.Feed) error {
+	ctx := context.Background()
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := s.Db.WithTx(tx)
+
+	now := time.Now()
+	mergeP := database.MergeFeedFollowsParams{
+		UpdatedAt:   now,
+		KeepID:      kept.ID,
+		DuplicateID: dup.ID,
+	}
+	if err := q.MergeFeedFollows(ctx, mergeP); err != nil {
+		return fmt.Errorf("merging follows: %w", err)
+	}
+	moveP := database.MoveFeedFollowsParams{
+		KeepID:      kept.ID,
+		UpdatedAt:   now,
+		DuplicateID: dup.ID,
+	}
+	if err := q.MoveFeedFollows(ctx, moveP); err != nil {
+		return fmt.Errorf("moving follows: %w", err)
+	}
+	postsP := database.MovePostsParams{
+		KeepID:      kept.ID,
+		UpdatedAt:   now,
+		DuplicateID: dup.ID,
+	}
+	if err := q.MovePosts(ctx, postsP); err != nil {
+		return fmt.Errorf("moving posts: %w", err)
+	}
+	// Posts the kept feed already has stay behind, what users did with
+	// them must survive the duplicate being deleted.
+	starsP := database.MoveDuplicatePostStarsParams{
+		UpdatedAt:   now,
+		KeepID:      kept.ID,
+		DuplicateID: dup.ID,
+	}
+	if err := q.MoveDuplicatePostStars(ctx, starsP); err != nil {
+		return fmt.Errorf("moving stars: %w", err)
+	}
+	readsP := database.MoveDuplicatePostReadsParams{KeepID: kept.ID, DuplicateID: dup.ID}
+	if err := q.MoveDuplicatePostReads(ctx, readsP); err != nil {
+		return fmt.Errorf("moving reads: %w", err)
+	}
+	tagsP := database.MoveDuplicatePostTagsParams{KeepID: kept.ID, DuplicateID: dup.ID}
+	if err := q.MoveDuplicatePostTags(ctx, tagsP); err != nil {
+		return fmt.Errorf("moving tags: %w", err)
+	}
+	hidesP := database.MoveDuplicatePostHidesParams{KeepID: kept.ID, DuplicateID: dup.ID}
+	if err := q.MoveDuplicatePostHides(ctx, hidesP); err != nil {
+		return fmt.Errorf("moving hides: %w", err)
+	}
+	hooksP := database.MoveWebhooksParams{
+		KeepID:      kept.ID,
+		UpdatedAt:   now,
+		DuplicateID: dup.ID,
+	}
+	if err := q.MoveWebhooks(ctx, hooksP); err != nil {
+		return fmt.Errorf("moving webhooks: %w", err)
+	}
+	if err := q.DeleteFeed(ctx, dup.ID); err != nil {
+		return fmt.Errorf("deleting feed: %w", err)
+	}
+	return tx.Commit()
+}
+
+func renderFollow(s *app.State, title string, follow database.CreateFeedFollowRow) error {
+	out := newRecord(title, "id", "feed_id", "feed_name", "user_name", "created_at")
+	out.add(follow.ID, follow.FeedID, follow.FeedName, follow.UserName, follow.CreatedAt)
+	return render(s, out)
+}
+
+func validateUrl(s *app.State, raw string) error {
+	if err := feedurl.Validate(raw, s.Cfg.Allow_private_urls); err != nil {
+		return err
+	}
+	_, err := feedurl.Normalize(raw)
+	return err
 }
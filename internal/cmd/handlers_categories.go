@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedurl"
+	"github.com/theandyeh/gator/internal/opml"
+)
+
+func HandlerCategory(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("category handler error: expected add, list, rm, assign or unassign")
+	}
+
+	switch c.Args[0] {
+	case "add":
+		if len(c.Args) < 2 {
+			return fmt.Errorf("category handler error: no category name provided (category add <name>)")
+		}
+		category, err := getOrCreateCategory(s, user, c.Args[1])
+		if err != nil {
+			return fmt.Errorf("category handler error: %w", err)
+		}
+		out := newRecord("Category", "id", "name")
+		out.add(category.ID, category.Name)
+		return render(s, out)
+
+	case "list":
+		categories, err := s.Db.GetCategoriesForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("category handler error retrieving categories: %w", err)
+		}
+		out := newList(fmt.Sprintf("Categories of user %s", user.Name), "name", "feeds")
+		for _, category := range categories {
+			out.add(category.Name, category.FeedCount)
+		}
+		return render(s, out)
+
+	case "rm":
+		if len(c.Args) < 2 {
+			return fmt.Errorf("category handler error: no category name provided (category rm <name>)")
+		}
+		removed, err := s.Db.DeleteCategory(context.Background(), database.DeleteCategoryParams{UserID: user.ID, Name: c.Args[1]})
+		if err != nil {
+			return fmt.Errorf("category handler error: %w", err)
+		}
+		if removed == 0 {
+			return fmt.Errorf("category handler error: no category named %s", c.Args[1])
+		}
+		out := newRecord("Removed category, its feeds are now uncategorized", "name")
+		out.add(c.Args[1])
+		return render(s, out)
+
+	case "assign", "unassign":
+		if c.Args[0] == "assign" && len(c.Args) < 3 {
+			return fmt.Errorf("category handler error: expected feed url and category (category assign <feed> <category>)")
+		}
+		if len(c.Args) < 2 {
+			return fmt.Errorf("category handler error: no feed url provided (category unassign <feed>)")
+		}
+
+		feedUrl, err := feedurl.Normalize(c.Args[1])
+		if err != nil {
+			return fmt.Errorf("category handler error: %w", err)
+		}
+		feed, err := s.Db.GetFeedByURL(context.Background(), feedUrl)
+		if err != nil {
+			return fmt.Errorf("category handler error retrieving feed %s: %w", feedUrl, err)
+		}
+
+		var categoryID uuid.NullUUID
+		var categoryName string
+		if c.Args[0] == "assign" {
+			category, err := getOrCreateCategory(s, user, c.Args[2])
+			if err != nil {
+				return fmt.Errorf("category handler error: %w", err)
+			}
+			categoryID = uuid.NullUUID{UUID: category.ID, Valid: true}
+			categoryName = category.Name
+		}
+
+		if err := setFeedCategory(s, user, feed.ID, categoryID); err != nil {
+			return fmt.Errorf("category handler error: %w", err)
+		}
+
+		out := newRecord("Updated feed category", "feed_url", "category")
+		out.add(feed.Url, categoryName)
+		return render(s, out)
+	}
+
+	return fmt.Errorf("category handler error: unknown action %s, expected add, list, rm, assign or unassign", c.Args[0])
+}
+
+// HandlerImport follows every feed listed in an OPML file, filing them into
+// categories named after the folders they appear in.
+func HandlerImport(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("import handler error: no opml file provided")
+	}
+
+	file, err := os.Open(c.Args[0])
+	if err != nil {
+		return fmt.Errorf("import handler error: %w", err)
+	}
+	defer file.Close()
+
+	doc, err := opml.Parse(file)
+	if err != nil {
+		return fmt.Errorf("import handler error: %w", err)
+	}
+
+	following, err := s.Db.GetFeedFollowsByUserID(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("import handler error retrieving follows: %w", err)
+	}
+	followed := make(map[uuid.UUID]bool, len(following))
+	for _, follow := range following {
+		followed[follow.FeedID] = true
+	}
+
+	out := newList("Imported subscriptions", "url", "category", "status")
+	for _, sub := range doc.Subscriptions() {
+		if err := validateUrl(s, sub.URL); err != nil {
+			out.add(sub.URL, sub.Category, fmt.Sprintf("skipped: %v", err))
+			continue
+		}
+		feedUrl, _ := feedurl.Normalize(sub.URL)
+
+		feed, err := s.Db.GetFeedByURL(context.Background(), feedUrl)
+		if errors.Is(err, sql.ErrNoRows) {
+			name := sub.Title
+			if name == "" {
+				name = feedUrl
+			}
+			feed, err = s.Db.CreateFeed(context.Background(), database.CreateFeedParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				Url:       feedUrl,
+				Name:      name,
+				UserID:    user.ID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("import handler error for %s: %w", feedUrl, err)
+		}
+
+		status := "already following"
+		if !followed[feed.ID] {
+			followP := database.CreateFeedFollowParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				FeedID:    feed.ID,
+				UserID:    user.ID,
+			}
+			if _, err := s.Db.CreateFeedFollow(context.Background(), followP); err != nil {
+				return fmt.Errorf("import handler error following %s: %w", feedUrl, err)
+			}
+			followed[feed.ID] = true
+			status = "followed"
+		}
+
+		if sub.Category != "" {
+			category, err := getOrCreateCategory(s, user, sub.Category)
+			if err != nil {
+				return fmt.Errorf("import handler error: %w", err)
+			}
+			if err := setFeedCategory(s, user, feed.ID, uuid.NullUUID{UUID: category.ID, Valid: true}); err != nil {
+				return fmt.Errorf("import handler error: %w", err)
+			}
+		}
+
+		out.add(feedUrl, sub.Category, status)
+	}
+
+	return render(s, out)
+}
+
+// HandlerExport writes the user's subscriptions as OPML, with one folder per
+// category.
+func HandlerExport(s *app.State, c Command, user database.User) error {
+	following, err := s.Db.GetFeedFollowsByUserID(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("export handler error retrieving follows: %w", err)
+	}
+
+	subs := make([]opml.Subscription, 0, len(following))
+	for _, follow := range following {
+		subs = append(subs, opml.Subscription{
+			Title:    follow.FeedName,
+			URL:      follow.FeedUrl,
+			Category: follow.CategoryName.String,
+		})
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Title < subs[j].Title })
+
+	var w io.Writer = os.Stdout
+	if s.Out != nil {
+		w = s.Out
+	}
+	if path := c.String("file"); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("export handler error: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := opml.Build(fmt.Sprintf("gator subscriptions of %s", user.Name), subs).Write(w); err != nil {
+		return fmt.Errorf("export handler error: %w", err)
+	}
+	return nil
+}
+
+func getOrCreateCategory(s *app.State, user database.User, name string) (database.Category, error) {
+	category, err := s.Db.GetCategoryByName(context.Background(), database.GetCategoryByNameParams{UserID: user.ID, Name: name})
+	if err == nil {
+		return category, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return category, err
+	}
+
+	return s.Db.CreateCategory(context.Background(), database.CreateCategoryParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      name,
+		UserID:    user.ID,
+	})
+}
+
+func setFeedCategory(s *app.State, user database.User, feedID uuid.UUID, categoryID uuid.NullUUID) error {
+	updated, err := s.Db.SetFeedFollowCategory(context.Background(), database.SetFeedFollowCategoryParams{
+		CategoryID: categoryID,
+		UpdatedAt:  time.Now(),
+		UserID:     user.ID,
+		FeedID:     feedID,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("not following feed %s", feedID)
+	}
+	return nil
+}
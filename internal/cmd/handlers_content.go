@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedurl"
+)
+
+// HandlerFullContent shows or switches whether the aggregator downloads the
+// full article behind each new post of a feed. With --backfill it also
+// fetches the articles of posts stored before.
+func HandlerFullContent(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("full-content handler error: no feed url provided")
+	}
+
+	feedUrl, err := feedurl.Normalize(c.Args[0])
+	if err != nil {
+		return fmt.Errorf("full-content handler error: %w", err)
+	}
+	feed, err := s.Db.GetFeedByURL(context.Background(), feedUrl)
+	if err != nil {
+		return fmt.Errorf("full-content handler error retrieving feed %s: %w", feedUrl, err)
+	}
+
+	_, err = s.Db.GetFeedFollow(context.Background(), database.GetFeedFollowParams{UserID: user.ID, FeedID: feed.ID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("full-content handler error: not following %s", feedUrl)
+	} else if err != nil {
+		return fmt.Errorf("full-content handler error: %w", err)
+	}
+
+	if len(c.Args) > 1 {
+		switch c.Args[1] {
+		case "on":
+			feed.FetchFullContent = true
+		case "off":
+			feed.FetchFullContent = false
+		default:
+			return fmt.Errorf("full-content handler error: state must be on or off, got %q", c.Args[1])
+		}
+		setP := database.SetFeedFullContentParams{
+			ID:               feed.ID,
+			FetchFullContent: feed.FetchFullContent,
+			UpdatedAt:        time.Now(),
+		}
+		if err := s.Db.SetFeedFullContent(context.Background(), setP); err != nil {
+			return fmt.Errorf("full-content handler error: %w", err)
+		}
+	}
+
+	var backfilled int
+	if n := c.Int("backfill"); n > 0 {
+		posts, err := s.Db.GetPostsWithoutContent(context.Background(), database.GetPostsWithoutContentParams{
+			FeedID: feed.ID,
+			Limit:  int32(n),
+		})
+		if err != nil {
+			return fmt.Errorf("full-content handler error: %w", err)
+		}
+		for _, post := range posts {
+			if storeFullContent(s, post).Content.Valid {
+				backfilled++
+			}
+		}
+	}
+
+	out := newRecord("Full content", "feed_url", "fetch_full_content", "backfilled")
+	out.add(feed.Url, feed.FetchFullContent, backfilled)
+	return render(s, out)
+}
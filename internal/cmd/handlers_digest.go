@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/mail"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/digest"
+	"github.com/theandyeh/gator/internal/htmltext"
+)
+
+// digestMaxPosts caps the number of posts in a single digest email.
+const digestMaxPosts = 200
+
+func HandlerEmail(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		out := newRecord(fmt.Sprintf("Email of user %s", user.Name), "email", "last_digest_at")
+		out.add(user.Email.String, user.LastDigestAt.Time)
+		return render(s, out)
+	}
+
+	email := sql.NullString{}
+	if c.Args[0] != "none" {
+		addr, err := mail.ParseAddress(c.Args[0])
+		if err != nil {
+			return fmt.Errorf("email handler error: invalid address: %w", err)
+		}
+		email = sql.NullString{String: addr.Address, Valid: true}
+	}
+
+	emailP := database.SetUserEmailParams{ID: user.ID, Email: email, UpdatedAt: time.Now()}
+	user, err := s.Db.SetUserEmail(context.Background(), emailP)
+	if err != nil {
+		return fmt.Errorf("email handler error: %w", err)
+	}
+	out := newRecord("Updated email", "name", "email")
+	out.add(user.Name, user.Email.String)
+	return render(s, out)
+}
+
+// HandlerDigest emails the current user their unread posts since the last
+// digest. With --stdout or --mbox the digest is only previewed and the next
+// digest still covers the same posts.
+func HandlerDigest(s *app.State, c Command, user database.User) error {
+	preview := c.Bool("stdout") || c.String("mbox") != ""
+	d, until, err := buildDigest(s, user, previewAddress(s, user, preview))
+	if err != nil {
+		return fmt.Errorf("digest handler error: %w", err)
+	}
+	if len(d.Items) == 0 {
+		fmt.Fprintf(os.Stderr, "No unread posts since the last digest\n")
+		return nil
+	}
+
+	if preview {
+		msg, err := d.Message()
+		if err != nil {
+			return fmt.Errorf("digest handler error: %w", err)
+		}
+		if c.Bool("stdout") {
+			if _, err := s.Out.Write(msg); err != nil {
+				return fmt.Errorf("digest handler error: %w", err)
+			}
+		}
+		if path := c.String("mbox"); path != "" {
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+			if err != nil {
+				return fmt.Errorf("digest handler error: %w", err)
+			}
+			defer file.Close()
+			if err := digest.WriteMbox(file, d.From, d.Generated, msg); err != nil {
+				return fmt.Errorf("digest handler error: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if err := sendDigest(s, user, d, until); err != nil {
+		return fmt.Errorf("digest handler error: %w", err)
+	}
+	out := newRecord("Sent digest", "to", "posts")
+	out.add(d.To, len(d.Items))
+	return render(s, out)
+}
+
+// previewAddress returns the address a previewed digest is shown as sent to,
+// so previews work before an email address is set.
+func previewAddress(s *app.State, user database.User, preview bool) string {
+	if !preview || user.Email.Valid {
+		return user.Email.String
+	}
+	return user.Name + "@localhost"
+}
+
+// buildDigest collects the unread posts of user since their last digest and
+// returns the time the digest covers posts up to. That is the time the
+// digest was built, unless there were more than digestMaxPosts posts and the
+// rest is left for the next digest.
+func buildDigest(s *app.State, user database.User, to string) (digest.Digest, time.Time, error) {
+	postsP := database.GetDigestPostsForUserParams{
+		UserID:   user.ID,
+		Since:    user.LastDigestAt.Time,
+		MaxPosts: digestMaxPosts,
+	}
+	posts, err := s.Db.GetDigestPostsForUser(context.Background(), postsP)
+	if err != nil {
+		return digest.Digest{}, time.Time{}, fmt.Errorf("retrieving posts: %w", err)
+	}
+
+	from := s.Cfg.Digest_from
+	if from == "" {
+		from = "gator <gator@localhost>"
+	}
+	d := digest.Digest{
+		User:      user.Name,
+		From:      from,
+		To:        to,
+		Since:     user.LastDigestAt.Time,
+		Generated: time.Now(),
+	}
+	until := d.Generated
+	if len(posts) == digestMaxPosts {
+		// Posts come oldest first, the newest one included marks the end.
+		until = posts[len(posts)-1].CreatedAt
+	}
+
+	slices.SortStableFunc(posts, func(a, b database.GetDigestPostsForUserRow) int {
+		if a.Priority != b.Priority {
+			return cmp.Compare(b.Priority, a.Priority)
+		}
+		if a.PublishedAt.Valid != b.PublishedAt.Valid {
+			if a.PublishedAt.Valid {
+				return -1
+			}
+			return 1
+		}
+		return b.PublishedAt.Time.Compare(a.PublishedAt.Time)
+	})
+	for _, post := range posts {
+		d.Items = append(d.Items, digest.Item{
+			Title:       post.Title,
+			URL:         post.Url,
+			Summary:     digest.Summarize(htmltext.Text(post.Description.String), 280),
+			Feed:        post.FeedName,
+			Category:    post.CategoryName.String,
+			PublishedAt: post.PublishedAt.Time,
+		})
+	}
+	return d, until, nil
+}
+
+// sendDigest mails d and records that the user's posts up to until have been
+// sent.
+func sendDigest(s *app.State, user database.User, d digest.Digest, until time.Time) error {
+	if d.To == "" {
+		return fmt.Errorf("user %s has no email address, set one with the email command", user.Name)
+	}
+	if s.Cfg.Smtp_host == "" || s.Cfg.Digest_from == "" {
+		return fmt.Errorf("smtp_host and digest_from must be set in the config to send digests")
+	}
+
+	msg, err := d.Message()
+	if err != nil {
+		return err
+	}
+	smtpCfg := digest.SMTPConfig{
+		Host:     s.Cfg.Smtp_host,
+		Port:     s.Cfg.Smtp_port,
+		Username: s.Cfg.Smtp_username,
+		Password: s.Cfg.Smtp_password,
+		StartTLS: s.Cfg.Smtp_starttls,
+	}
+	debugf(s, "sending digest with %d posts to %s via %s", len(d.Items), d.To, smtpCfg.Host)
+	if err := digest.Send(smtpCfg, d.From, d.To, msg); err != nil {
+		return err
+	}
+	return markDigested(s, user, until)
+}
+
+func markDigested(s *app.State, user database.User, at time.Time) error {
+	digestedP := database.MarkUserDigestedParams{ID: user.ID, LastDigestAt: sql.NullTime{Time: at, Valid: true}}
+	if err := s.Db.MarkUserDigested(context.Background(), digestedP); err != nil {
+		return fmt.Errorf("recording digest of %s: %w", user.Name, err)
+	}
+	return nil
+}
+
+// sendDueDigests sends a digest to every user with an email address whose
+// last digest is at least every old. Users without new posts are skipped
+// until the next period.
+func sendDueDigests(s *app.State, every time.Duration) {
+	dueP := sql.NullTime{Time: time.Now().Add(-every), Valid: true}
+	users, err := s.Db.GetUsersDueForDigest(context.Background(), dueP)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "digest error retrieving users: %v\n", err)
+		return
+	}
+
+	for _, user := range users {
+		d, until, err := buildDigest(s, user, user.Email.String)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "digest error for %s: %v\n", user.Name, err)
+			continue
+		}
+		if len(d.Items) == 0 {
+			err = markDigested(s, user, until)
+		} else {
+			err = sendDigest(s, user, d, until)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "digest error for %s: %v\n", user.Name, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/digest"
+	"github.com/theandyeh/gator/internal/htmltext"
+	"github.com/theandyeh/gator/internal/planet"
+)
+
+// HandlerPlanet builds a static Planet style site from the latest posts of
+// the followed feeds of the current user, or of one of their categories.
+func HandlerPlanet(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 || c.Args[0] != "build" {
+		return fmt.Errorf("planet handler error: expected build")
+	}
+	dir := c.String("out")
+	if dir == "" {
+		return fmt.Errorf("planet handler error: no output directory provided (--out <dir>)")
+	}
+	limit := c.Int("limit")
+	if limit <= 0 {
+		return fmt.Errorf("planet handler error: limit must be positive, got %d", limit)
+	}
+	category := c.String("category")
+
+	follows, err := s.Db.GetFeedFollowsByUserID(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("planet handler error retrieving follows: %w", err)
+	}
+	postsP := database.GetOutputPostsForUserParams{
+		UserID:   user.ID,
+		Category: category,
+		MaxPosts: int32(limit),
+	}
+	posts, err := s.Db.GetOutputPostsForUser(context.Background(), postsP)
+	if err != nil {
+		return fmt.Errorf("planet handler error retrieving posts: %w", err)
+	}
+
+	site := &planet.Site{
+		Title:     c.String("title"),
+		URL:       c.String("url"),
+		Generated: time.Now(),
+		PerPage:   c.Int("per-page"),
+	}
+	if site.Title == "" {
+		site.Title = "Planet " + user.Name
+		if category != "" {
+			site.Title = "Planet " + category
+		}
+	}
+
+	feeds := map[uuid.UUID]*planet.Feed{}
+	for _, follow := range follows {
+		if follow.Muted || (category != "" && follow.CategoryName.String != category) {
+			continue
+		}
+		feed := &planet.Feed{ID: follow.FeedID, Name: follow.FeedName, URL: follow.FeedUrl}
+		feeds[follow.FeedID] = feed
+		site.Feeds = append(site.Feeds, feed)
+	}
+
+	for _, post := range posts {
+		published := post.PublishedAt.Time
+		if !post.PublishedAt.Valid {
+			published = post.CreatedAt
+		}
+		site.Items = append(site.Items, planet.Item{
+			ID:        post.ID,
+			Title:     post.Title,
+			Link:      post.Url,
+			Summary:   digest.Summarize(htmltext.Text(post.Description.String), 500),
+			Author:    post.Author.String,
+			Published: published.Local(),
+			Updated:   post.UpdatedAt,
+			Feed:      feeds[post.FeedID],
+		})
+	}
+
+	if err := planet.Build(dir, site); err != nil {
+		return fmt.Errorf("planet handler error: %w", err)
+	}
+
+	out := newRecord("Built planet", "dir", "feeds", "posts")
+	out.add(filepath.Clean(dir), len(site.Feeds), len(site.Items))
+	return render(s, out)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedurl"
+	"github.com/theandyeh/gator/internal/podcast"
+	"github.com/theandyeh/gator/internal/rss"
+)
+
+// HandlerEpisodes lists the latest posts with attached media from followed
+// feeds, newest first.
+func HandlerEpisodes(s *app.State, c Command, user database.User) error {
+	limit := c.Int("limit")
+	if limit <= 0 {
+		return fmt.Errorf("episodes handler error: limit must be positive, got %d", limit)
+	}
+
+	episodesP := database.GetEpisodesForUserParams{
+		UserID:         user.ID,
+		DownloadedOnly: c.Bool("downloaded"),
+		MaxResults:     int32(limit),
+	}
+	if c.String("feed") != "" {
+		feedUrl, err := feedurl.Normalize(c.String("feed"))
+		if err != nil {
+			return fmt.Errorf("episodes handler error: %w", err)
+		}
+		episodesP.FeedUrl = feedUrl
+	}
+
+	episodes, err := s.Db.GetEpisodesForUser(context.Background(), episodesP)
+	if err != nil {
+		return fmt.Errorf("episodes handler error retrieving episodes: %w", err)
+	}
+
+	out := newList(fmt.Sprintf("Episodes for user %s", user.Name), "id", "feed_name", "title", "published_at", "episode", "duration", "type", "size", "url", "downloaded_path")
+	for _, e := range episodes {
+		var duration string
+		if e.DurationSeconds.Valid {
+			duration = (time.Duration(e.DurationSeconds.Int32) * time.Second).String()
+		}
+		out.add(e.PostID, e.FeedName, e.Title, e.PublishedAt.Time, episodeNumber(e.Season, e.Episode), duration, e.MimeType, e.Length, e.Url, e.DownloadedPath.String)
+	}
+
+	return render(s, out)
+}
+
+// episodeNumber formats season and episode like S2E12, leaving out what the
+// feed does not say.
+func episodeNumber(season, episode sql.NullInt32) string {
+	var number string
+	if season.Valid {
+		number = fmt.Sprintf("S%d", season.Int32)
+	}
+	if episode.Valid {
+		number += fmt.Sprintf("E%d", episode.Int32)
+	}
+	return number
+}
+
+// HandlerDownload fetches the media file of a post into the download
+// directory. An interrupted download is resumed by running it again.
+func HandlerDownload(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("download handler error: no post id provided")
+	}
+
+	postID, err := uuid.Parse(c.Args[0])
+	if err != nil {
+		return fmt.Errorf("download handler error: invalid post id: %w", err)
+	}
+
+	post, err := s.Db.GetPostForUser(context.Background(), database.GetPostForUserParams{ID: postID, UserID: user.ID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("download handler error: no post %s in followed feeds", postID)
+	} else if err != nil {
+		return fmt.Errorf("download handler error: %w", err)
+	}
+
+	enclosures, err := s.Db.GetEnclosuresForPost(context.Background(), post.ID)
+	if err != nil {
+		return fmt.Errorf("download handler error: %w", err)
+	}
+	if len(enclosures) == 0 {
+		return fmt.Errorf("download handler error: post %s has no media attached", postID)
+	}
+	enclosure := enclosures[0]
+
+	dir, err := downloadDir(s, c)
+	if err != nil {
+		return fmt.Errorf("download handler error: %w", err)
+	}
+	dest := podcast.Path(dir, post.FeedName, post.Title, post.PublishedAt.Time, post.ID.String(), enclosure.Url, enclosure.MimeType)
+
+	var written int64
+	if _, err := os.Stat(dest); err == nil {
+		debugf(s, "%s already exists, skipping download", dest)
+	} else {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// Media files take longer than the fetch timeout allows, the
+		// download runs until it completes or is interrupted.
+		client := *rss.HTTPClient()
+		client.Timeout = 0
+
+		debugf(s, "downloading %s to %s", enclosure.Url, dest)
+		written, err = podcast.Download(ctx, &client, enclosure.Url, dest)
+		if err != nil {
+			return fmt.Errorf("download handler error: %w", err)
+		}
+	}
+
+	downloadedP := database.MarkEnclosureDownloadedParams{
+		ID:             enclosure.ID,
+		DownloadedPath: sql.NullString{String: dest, Valid: true},
+		DownloadedAt:   sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	if err := s.Db.MarkEnclosureDownloaded(context.Background(), downloadedP); err != nil {
+		return fmt.Errorf("download handler error: %w", err)
+	}
+
+	out := newRecord("Downloaded episode", "id", "title", "path", "bytes_written")
+	out.add(post.ID, post.Title, dest, written)
+	return render(s, out)
+}
+
+// downloadDir is the --dir flag, the download_dir config setting or
+// ~/Podcasts, in that order, made absolute so stored paths stay valid.
+func downloadDir(s *app.State, c Command) (string, error) {
+	dir := c.String("dir")
+	if dir == "" {
+		dir = s.Cfg.Download_dir
+	}
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, "Podcasts")
+	}
+	return filepath.Abs(dir)
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedurl"
+	"github.com/theandyeh/gator/internal/htmltext"
+)
+
+func HandlerBrowse(s *app.State, c Command, user database.User) error {
+	limit := c.Int("limit")
+	if limit <= 0 {
+		return fmt.Errorf("browse handler error: limit must be positive, got %d", limit)
+	}
+
+	postsP := database.GetPostsForUserParams{
+		UserID:       user.ID,
+		UnreadOnly:   c.Bool("unread"),
+		Category:     c.String("category"),
+		Author:       c.String("author"),
+		ItemCategory: c.String("item-category"),
+		MaxPosts:     int32(limit),
+	}
+	posts, err := s.Db.GetPostsForUser(context.Background(), postsP)
+	if err != nil {
+		return fmt.Errorf("browse handler error retrieving posts: %w", err)
+	}
+
+	out := newList(fmt.Sprintf("Posts for user %s", user.Name), "id", "feed_name", "title", "url", "published_at", "author", "categories", "read", "tags", "description")
+	for _, post := range posts {
+		out.add(post.ID, post.FeedName, post.Title, post.Url, post.PublishedAt.Time, post.Author.String, post.Categories, post.ReadAt.Valid, post.Tags, htmltext.Text(post.Description.String))
+	}
+
+	return render(s, out)
+}
+
+func HandlerRead(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("read handler error: no post id provided")
+	}
+
+	postID, err := uuid.Parse(c.Args[0])
+	if err != nil {
+		return fmt.Errorf("read handler error: invalid post id: %w", err)
+	}
+	if err := checkPost(s, user, postID); err != nil {
+		return fmt.Errorf("read handler error: %w", err)
+	}
+
+	readP := database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: postID,
+		ReadAt: time.Now(),
+	}
+	if err := s.Db.MarkPostRead(context.Background(), readP); err != nil {
+		return fmt.Errorf("read handler error: %w", err)
+	}
+
+	out := newRecord("Marked post as read", "id", "read")
+	out.add(postID, true)
+	return render(s, out)
+}
+
+func HandlerUnread(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("unread handler error: no post id provided")
+	}
+
+	postID, err := uuid.Parse(c.Args[0])
+	if err != nil {
+		return fmt.Errorf("unread handler error: invalid post id: %w", err)
+	}
+	if err := checkPost(s, user, postID); err != nil {
+		return fmt.Errorf("unread handler error: %w", err)
+	}
+
+	unreadP := database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: postID,
+	}
+	if _, err := s.Db.MarkPostUnread(context.Background(), unreadP); err != nil {
+		return fmt.Errorf("unread handler error: %w", err)
+	}
+
+	out := newRecord("Marked post as unread", "id", "read")
+	out.add(postID, false)
+	return render(s, out)
+}
+
+func HandlerMarkAllRead(s *app.State, c Command, user database.User) error {
+	readP := database.MarkAllPostsReadParams{
+		ReadAt: time.Now(),
+		UserID: user.ID,
+	}
+
+	if raw := c.String("feed"); raw != "" {
+		feedUrl, err := feedurl.Normalize(raw)
+		if err != nil {
+			return fmt.Errorf("mark-all-read handler error: %w", err)
+		}
+		readP.FeedUrl = feedUrl
+	}
+
+	if raw := c.String("before"); raw != "" {
+		before, err := parseDateArg(raw)
+		if err != nil {
+			return fmt.Errorf("mark-all-read handler error: %w", err)
+		}
+		readP.Before = sql.NullTime{Time: before, Valid: true}
+	}
+
+	marked, err := s.Db.MarkAllPostsRead(context.Background(), readP)
+	if err != nil {
+		return fmt.Errorf("mark-all-read handler error: %w", err)
+	}
+
+	out := newRecord("Marked posts as read", "marked")
+	out.add(marked)
+	return render(s, out)
+}
+
+func HandlerStar(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("star handler error: no post id provided")
+	}
+
+	postID, err := uuid.Parse(c.Args[0])
+	if err != nil {
+		return fmt.Errorf("star handler error: invalid post id: %w", err)
+	}
+	if err := checkPost(s, user, postID); err != nil {
+		return fmt.Errorf("star handler error: %w", err)
+	}
+
+	starP := database.CreatePostStarParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		PostID:    postID,
+		UserID:    user.ID,
+	}
+	if err := s.Db.CreatePostStar(context.Background(), starP); err != nil {
+		return fmt.Errorf("star handler error: %w", err)
+	}
+
+	out := newRecord("Starred post", "id", "starred")
+	out.add(postID, true)
+	return render(s, out)
+}
+
+func HandlerUnstar(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("unstar handler error: no post id provided")
+	}
+
+	postID, err := uuid.Parse(c.Args[0])
+	if err != nil {
+		return fmt.Errorf("unstar handler error: invalid post id: %w", err)
+	}
+
+	unstarP := database.DeletePostStarParams{
+		PostID: postID,
+		UserID: user.ID,
+	}
+	removed, err := s.Db.DeletePostStar(context.Background(), unstarP)
+	if err != nil {
+		return fmt.Errorf("unstar handler error: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("unstar handler error: post %s is not starred", postID)
+	}
+
+	out := newRecord("Unstarred post", "id", "starred")
+	out.add(postID, false)
+	return render(s, out)
+}
+
+// checkPost makes sure user may change the state of the post, which takes
+// following its feed or having starred it, like reading it does.
+func checkPost(s *app.State, user database.User, postID uuid.UUID) error {
+	_, err := s.Db.GetPostForUser(context.Background(), database.GetPostForUserParams{UserID: user.ID, ID: postID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no post %s in followed feeds", postID)
+	}
+	return err
+}
+
+func HandlerStarred(s *app.State, c Command, user database.User) error {
+	limit := c.Int("limit")
+	if limit <= 0 {
+		return fmt.Errorf("starred handler error: limit must be positive, got %d", limit)
+	}
+
+	starredP := database.GetStarredPostsForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	}
+	posts, err := s.Db.GetStarredPostsForUser(context.Background(), starredP)
+	if err != nil {
+		return fmt.Errorf("starred handler error retrieving posts: %w", err)
+	}
+
+	out := newList(fmt.Sprintf("Posts starred by user %s", user.Name), "id", "feed_name", "title", "url", "published_at", "starred_at")
+	for _, post := range posts {
+		out.add(post.ID, post.FeedName, post.Title, post.Url, post.PublishedAt.Time, post.StarredAt)
+	}
+
+	return render(s, out)
+}
+
+// HandlerSearch runs a web search style query over stored posts: quoted
+// phrases, -excluded words and "or" are supported.
+func HandlerSearch(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("search handler error: no query provided")
+	}
+
+	limit := c.Int("limit")
+	if limit <= 0 {
+		return fmt.Errorf("search handler error: limit must be positive, got %d", limit)
+	}
+
+	searchP := database.SearchPostsParams{
+		Query:      strings.Join(c.Args, " "),
+		AllFeeds:   c.Bool("all"),
+		UserID:     user.ID,
+		MaxResults: int32(limit),
+	}
+	results, err := s.Db.SearchPosts(context.Background(), searchP)
+	if err != nil {
+		return fmt.Errorf("search handler error: %w", err)
+	}
+
+	out := newList(fmt.Sprintf("Results for %q", searchP.Query), "id", "feed_name", "title", "url", "published_at", "rank", "snippet")
+	for _, r := range results {
+		out.add(r.ID, r.FeedName, r.Title, r.Url, r.PublishedAt.Time, r.Rank, htmltext.Text(r.Snippet))
+	}
+
+	return render(s, out)
+}
+
+// HandlerPrune deletes old posts. Starred posts are always kept.
+func HandlerPrune(s *app.State, c Command) error {
+	olderThan := c.Duration("older-than")
+	if olderThan <= 0 {
+		return fmt.Errorf("prune handler error: older-than must be positive, got %s", olderThan)
+	}
+
+	pruned, err := s.Db.DeletePostsBefore(context.Background(), time.Now().Add(-olderThan))
+	if err != nil {
+		return fmt.Errorf("prune handler error: %w", err)
+	}
+
+	out := newRecord("Pruned posts", "deleted")
+	out.add(pruned)
+	return render(s, out)
+}
+
+// parseDateArg accepts either a plain date or a full RFC 3339 timestamp.
+func parseDateArg(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC 3339", raw)
+	}
+	return t, nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedgen"
+)
+
+// HandlerPublish writes the posts of the current user as an RSS, Atom or
+// JSON feed, to stdout or a file other readers can subscribe to.
+func HandlerPublish(s *app.State, c Command, user database.User) error {
+	format := c.String("format")
+	q := feedgen.Query{
+		Category: c.String("category"),
+		Starred:  c.Bool("starred"),
+		Filter:   c.String("filter"),
+		Limit:    c.Int("limit"),
+	}
+	feed, err := feedgen.Build(context.Background(), s.Db, user, q)
+	if err != nil {
+		return fmt.Errorf("publish handler error: %w", err)
+	}
+	feed.Self = c.String("url")
+	if title := c.String("title"); title != "" {
+		feed.Title = title
+	}
+
+	path := c.String("out")
+	if path == "" {
+		var w io.Writer = os.Stdout
+		if s.Out != nil {
+			w = s.Out
+		}
+		if err := feedgen.Write(w, format, feed); err != nil {
+			return fmt.Errorf("publish handler error: %w", err)
+		}
+		return nil
+	}
+
+	// Write next to the target and rename, so a web server never serves a
+	// half written feed.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".gator-publish-*")
+	if err != nil {
+		return fmt.Errorf("publish handler error: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := feedgen.Write(tmp, format, feed); err != nil {
+		tmp.Close()
+		return fmt.Errorf("publish handler error: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("publish handler error: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("publish handler error: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("publish handler error: %w", err)
+	}
+
+	out := newRecord("Published feed", "file", "format", "items")
+	out.add(path, format, len(feed.Items))
+	return render(s, out)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/rules"
+)
+
+func HandlerRule(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("rule handler error: expected add, list, rm or test")
+	}
+
+	switch c.Args[0] {
+	case "add":
+		r := rules.Rule{
+			Field:     c.String("field"),
+			Match:     c.String("match"),
+			Pattern:   c.String("pattern"),
+			Action:    c.String("action"),
+			ActionArg: c.String("arg"),
+		}
+		if _, err := rules.Compile(r); err != nil {
+			return fmt.Errorf("rule handler error: %w", err)
+		}
+
+		ruleP := database.CreateRuleParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			Field:     r.Field,
+			MatchType: r.Match,
+			Pattern:   r.Pattern,
+			Action:    r.Action,
+			ActionArg: r.ActionArg,
+		}
+		rule, err := s.Db.CreateRule(context.Background(), ruleP)
+		if err != nil {
+			return fmt.Errorf("rule handler error: %w", err)
+		}
+		out := newRecord("Rule added", "id", "field", "match", "pattern", "action", "arg")
+		out.add(rule.ID, rule.Field, rule.MatchType, rule.Pattern, rule.Action, rule.ActionArg)
+		return render(s, out)
+
+	case "list":
+		userRules, err := s.Db.GetRulesForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("rule handler error retrieving rules: %w", err)
+		}
+		out := newList(fmt.Sprintf("Rules of user %s", user.Name), "id", "field", "match", "pattern", "action", "arg")
+		for _, rule := range userRules {
+			out.add(rule.ID, rule.Field, rule.MatchType, rule.Pattern, rule.Action, rule.ActionArg)
+		}
+		return render(s, out)
+
+	case "rm":
+		rule, err := userRule(s, c, user)
+		if err != nil {
+			return fmt.Errorf("rule handler error: %w", err)
+		}
+		if _, err := s.Db.DeleteRule(context.Background(), database.DeleteRuleParams{ID: rule.ID, UserID: user.ID}); err != nil {
+			return fmt.Errorf("rule handler error: %w", err)
+		}
+		out := newRecord("Removed rule", "id")
+		out.add(rule.ID)
+		return render(s, out)
+
+	case "test":
+		rule, err := userRule(s, c, user)
+		if err != nil {
+			return fmt.Errorf("rule handler error: %w", err)
+		}
+		matcher, err := rules.Compile(ruleFromRow(rule.Field, rule.MatchType, rule.Pattern, rule.Action, rule.ActionArg))
+		if err != nil {
+			return fmt.Errorf("rule handler error: %w", err)
+		}
+
+		limit := c.Int("limit")
+		if limit <= 0 {
+			return fmt.Errorf("rule handler error: limit must be positive, got %d", limit)
+		}
+		recentP := database.GetRecentPostsForRulesParams{UserID: user.ID, Limit: int32(limit)}
+		posts, err := s.Db.GetRecentPostsForRules(context.Background(), recentP)
+		if err != nil {
+			return fmt.Errorf("rule handler error retrieving posts: %w", err)
+		}
+
+		out := newList(fmt.Sprintf("Recent posts matching rule %s (would %s)", rule.ID, rule.Action), "id", "feed_name", "title", "url")
+		for _, p := range posts {
+			post := database.Post{
+				ID:          p.ID,
+				Title:       p.Title,
+				Url:         p.Url,
+				Description: p.Description,
+				Content:     p.Content,
+				Author:      p.Author,
+			}
+			if matcher.Matches(ruleItem(post, p.FeedName, p.CategoryName.String, strings.Split(p.ItemCategories, ","))) {
+				out.add(p.ID, p.FeedName, p.Title, p.Url)
+			}
+		}
+		return render(s, out)
+	}
+
+	return fmt.Errorf("rule handler error: unknown action %s, expected add, list, rm or test", c.Args[0])
+}
+
+// userRule looks up the rule named by the second argument, only returning
+// rules owned by user.
+func userRule(s *app.State, c Command, user database.User) (database.Rule, error) {
+	if len(c.Args) < 2 {
+		return database.Rule{}, fmt.Errorf("no rule id provided (rule %s <id>)", c.Args[0])
+	}
+	ruleID, err := uuid.Parse(c.Args[1])
+	if err != nil {
+		return database.Rule{}, fmt.Errorf("invalid rule id: %w", err)
+	}
+	rule, err := s.Db.GetRule(context.Background(), database.GetRuleParams{ID: ruleID, UserID: user.ID})
+	if err != nil {
+		return database.Rule{}, fmt.Errorf("retrieving rule %s: %w", ruleID, err)
+	}
+	return rule, nil
+}
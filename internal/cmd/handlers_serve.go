@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/api"
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedgen"
+	"github.com/theandyeh/gator/internal/fever"
+	"github.com/theandyeh/gator/internal/rss"
+	"github.com/theandyeh/gator/internal/web"
+)
+
+func HandlerToken(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("token handler error: expected add, list or rm")
+	}
+
+	switch c.Args[0] {
+	case "add":
+		if len(c.Args) < 2 {
+			return fmt.Errorf("token handler error: no token name provided (token add <name>)")
+		}
+		token := api.NewToken()
+		tokenP := database.CreateAPITokenParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UserID:    user.ID,
+			Name:      c.Args[1],
+			TokenHash: api.HashToken(token),
+		}
+		created, err := s.Db.CreateAPIToken(context.Background(), tokenP)
+		if err != nil {
+			return fmt.Errorf("token handler error: %w", err)
+		}
+		out := newRecord("Token created, it is only shown once", "id", "name", "token")
+		out.add(created.ID, created.Name, token)
+		return render(s, out)
+
+	case "list":
+		tokens, err := s.Db.GetAPITokensForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("token handler error retrieving tokens: %w", err)
+		}
+		out := newList(fmt.Sprintf("API tokens of user %s", user.Name), "id", "name", "created_at", "last_used_at")
+		for _, token := range tokens {
+			out.add(token.ID, token.Name, token.CreatedAt, token.LastUsedAt.Time)
+		}
+		return render(s, out)
+
+	case "rm":
+		if len(c.Args) < 2 {
+			return fmt.Errorf("token handler error: no token id provided (token rm <id>)")
+		}
+		tokenID, err := uuid.Parse(c.Args[1])
+		if err != nil {
+			return fmt.Errorf("token handler error: invalid token id: %w", err)
+		}
+		removed, err := s.Db.DeleteAPIToken(context.Background(), database.DeleteAPITokenParams{ID: tokenID, UserID: user.ID})
+		if err != nil {
+			return fmt.Errorf("token handler error: %w", err)
+		}
+		if removed == 0 {
+			return fmt.Errorf("token handler error: no token with id %s", tokenID)
+		}
+		out := newRecord("Revoked token", "id")
+		out.add(tokenID)
+		return render(s, out)
+	}
+
+	return fmt.Errorf("token handler error: unknown action %s, expected add, list or rm", c.Args[0])
+}
+
+// HandlerFever sets the password Fever clients log in with, using the user
+// name as email. "none" turns Fever access off.
+func HandlerFever(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		out := newRecord(fmt.Sprintf("Fever access of user %s", user.Name), "enabled")
+		out.add(user.FeverApiKey.Valid)
+		return render(s, out)
+	}
+
+	key := sql.NullString{}
+	if c.Args[0] != "none" {
+		key = sql.NullString{String: fever.APIKey(user.Name, c.Args[0]), Valid: true}
+	}
+
+	feverP := database.SetUserFeverKeyParams{ID: user.ID, FeverApiKey: key, UpdatedAt: time.Now()}
+	if err := s.Db.SetUserFeverKey(context.Background(), feverP); err != nil {
+		return fmt.Errorf("fever handler error: %w", err)
+	}
+	out := newRecord("Updated Fever access", "name", "enabled")
+	out.add(user.Name, key.Valid)
+	return render(s, out)
+}
+
+// HandlerFeedToken shows whether the served output feeds of the user are
+// enabled, "new" replaces the token they are served with and "none" turns
+// them off.
+func HandlerFeedToken(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		out := newRecord(fmt.Sprintf("Output feeds of user %s", user.Name), "enabled")
+		out.add(user.FeedTokenHash.Valid)
+		return render(s, out)
+	}
+
+	var token string
+	hash := sql.NullString{}
+	switch c.Args[0] {
+	case "new":
+		token = feedgen.NewToken()
+		hash = sql.NullString{String: feedgen.HashToken(token), Valid: true}
+	case "none":
+	default:
+		return fmt.Errorf("feed-token handler error: unknown action %s, expected new or none", c.Args[0])
+	}
+
+	tokenP := database.SetUserFeedTokenParams{ID: user.ID, FeedTokenHash: hash, UpdatedAt: time.Now()}
+	if err := s.Db.SetUserFeedToken(context.Background(), tokenP); err != nil {
+		return fmt.Errorf("feed-token handler error: %w", err)
+	}
+	if token == "" {
+		out := newRecord("Output feeds disabled", "name")
+		out.add(user.Name)
+		return render(s, out)
+	}
+	out := newRecord("Feed token created, it is only shown once", "name", "token", "path")
+	out.add(user.Name, token, fmt.Sprintf("/feeds/%s.atom?token=%s", url.PathEscape(user.Name), token))
+	return render(s, out)
+}
+
+func HandlerServe(s *app.State, c Command) error {
+	mux := http.NewServeMux()
+	mux.Handle("/api/", api.New(s.Db, api.Options{
+		AllowPrivateURLs: s.Cfg.Allow_private_urls,
+		Fetch:            rss.FetchFeed,
+	}))
+	mux.Handle("/fever/", fever.New(s.Db))
+	if c.Bool("feeds") {
+		mux.Handle("/feeds/", feedgen.NewServer(s.Db))
+	}
+	if c.Bool("web") {
+		ui, err := web.New(s.Db, web.Options{
+			AllowPrivateURLs: s.Cfg.Allow_private_urls,
+			Fetch:            rss.FetchFeed,
+			Secret:           []byte(s.Cfg.Web_secret),
+		})
+		if err != nil {
+			return fmt.Errorf("serve handler error: %w", err)
+		}
+		mux.Handle("/", ui)
+	}
+
+	if err := listenAndServe(c.String("addr"), mux); err != nil {
+		return fmt.Errorf("serve handler error: %w", err)
+	}
+	return nil
+}
+
+// listenAndServe serves handler on addr until interrupted, then gives open
+// requests a few seconds to finish.
+func listenAndServe(addr string, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, "Listening on %s\n", addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"database/sql"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/theandyeh/gator/internal/app"
 	"github.com/theandyeh/gator/internal/config"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/rules"
 )
 
 func TestHandlerLoginNoArgs(t *testing.T) {
@@ -50,3 +55,103 @@ func TestHandlerLoginSuccess(t *testing.T) {
 		t.Errorf("Expected user 'testuser', got '%s'", state.Cfg.Current_db_user)
 	}
 }
+
+func TestMergeFeedKeepsStateOfCollidingPosts(t *testing.T) {
+	s := testState(t)
+	now := time.Now()
+	user, kept, dup := uuid.New(), uuid.New(), uuid.New()
+	keptPost, dupPost := uuid.New(), uuid.New()
+
+	mustExec(t, s, `INSERT INTO users (id, created_at, updated_at, name) VALUES ($1, $2, $2, 'kahya')`, user, now)
+	for _, feed := range []struct {
+		id  uuid.UUID
+		url string
+	}{{kept, "https://example.com/feed"}, {dup, "http://example.com/feed"}} {
+		mustExec(t, s, `INSERT INTO feeds (id, created_at, updated_at, url, name, user_id) VALUES ($1, $2, $2, $3, 'Example', $4)`, feed.id, now, feed.url, user)
+	}
+	mustExec(t, s, `INSERT INTO feed_follows (id, created_at, updated_at, feed_id, user_id) VALUES ($1, $2, $2, $3, $4)`, uuid.New(), now, dup, user)
+	mustExec(t, s, `INSERT INTO posts (id, created_at, updated_at, title, url, feed_id, guid) VALUES ($1, $2, $2, 'Hello', 'https://example.com/hello', $3, 'hello')`, keptPost, now, kept)
+	mustExec(t, s, `INSERT INTO posts (id, created_at, updated_at, title, url, feed_id, guid) VALUES ($1, $2, $2, 'Hello', 'http://example.com/hello', $3, 'hello')`, dupPost, now, dup)
+	mustExec(t, s, `INSERT INTO post_stars (id, created_at, updated_at, post_id, user_id) VALUES ($1, $2, $2, $3, $4)`, uuid.New(), now, dupPost, user)
+	mustExec(t, s, `INSERT INTO post_reads (user_id, post_id, read_at) VALUES ($1, $2, $3)`, user, dupPost, now)
+	mustExec(t, s, `INSERT INTO post_tags (user_id, post_id, tag, created_at) VALUES ($1, $2, 'keep', $3)`, user, dupPost, now)
+	mustExec(t, s, `INSERT INTO post_hides (user_id, post_id, created_at) VALUES ($1, $2, $3)`, user, dupPost, now)
+
+	err := mergeFeed(s, database.Feed{ID: dup, Url: "http://example.com/feed"}, database.Feed{ID: kept, Url: "https://example.com/feed"})
+	if err != nil {
+		t.Fatalf("mergeFeed failed: %v", err)
+	}
+
+	if n := count(t, s, `SELECT count(*) FROM posts WHERE id = $1`, dupPost); n != 0 {
+		t.Errorf("Expected the colliding post to be deleted with the duplicate feed")
+	}
+	for _, table := range []string{"post_stars", "post_reads", "post_tags", "post_hides"} {
+		if n := count(t, s, `SELECT count(*) FROM `+table+` WHERE post_id = $1 AND user_id = $2`, keptPost, user); n != 1 {
+			t.Errorf("Expected %s of the colliding post to move to the kept post, got %d rows", table, n)
+		}
+	}
+	if n := count(t, s, `SELECT count(*) FROM feed_follows WHERE feed_id = $1 AND user_id = $2`, kept, user); n != 1 {
+		t.Errorf("Expected the follow to move to the kept feed")
+	}
+}
+
+func TestPostStateNeedsFollowOrStar(t *testing.T) {
+	s := testState(t)
+	now := time.Now()
+	user, feed, post := uuid.New(), uuid.New(), uuid.New()
+	mustExec(t, s, `INSERT INTO users (id, created_at, updated_at, name) VALUES ($1, $2, $2, 'kahya')`, user, now)
+	mustExec(t, s, `INSERT INTO feeds (id, created_at, updated_at, url, name, user_id) VALUES ($1, $2, $2, 'https://example.com/feed', 'Example', $3)`, feed, now, user)
+	mustExec(t, s, `INSERT INTO posts (id, created_at, updated_at, title, url, feed_id) VALUES ($1, $2, $2, 'Hello', 'https://example.com/hello', $3)`, post, now, feed)
+	dbUser := database.User{ID: user, Name: "kahya"}
+
+	for name, handler := range map[string]func(*app.State, Command, database.User) error{
+		"read":   HandlerRead,
+		"unread": HandlerUnread,
+		"star":   HandlerStar,
+	} {
+		if err := handler(s, Command{Name: name, Args: []string{post.String()}}, dbUser); err == nil {
+			t.Errorf("Expected %s of a post in an unfollowed feed to fail", name)
+		}
+	}
+	if n := count(t, s, `SELECT (SELECT count(*) FROM post_reads) + (SELECT count(*) FROM post_stars)`); n != 0 {
+		t.Errorf("Expected no state to be stored for an unfollowed feed, got %d rows", n)
+	}
+
+	mustExec(t, s, `INSERT INTO feed_follows (id, created_at, updated_at, feed_id, user_id) VALUES ($1, $2, $2, $3, $4)`, uuid.New(), now, feed, user)
+	if err := HandlerStar(s, Command{Name: "star", Args: []string{post.String()}}, dbUser); err != nil {
+		t.Fatalf("star failed: %v", err)
+	}
+	mustExec(t, s, `DELETE FROM feed_follows WHERE user_id = $1`, user)
+	if err := HandlerRead(s, Command{Name: "read", Args: []string{post.String()}}, dbUser); err != nil {
+		t.Errorf("Expected a starred post to stay readable after unfollowing: %v", err)
+	}
+}
+
+func TestRuleItemMatchesTextOfHTML(t *testing.T) {
+	post := database.Post{
+		Title:       "Vacuum",
+		Description: sql.NullString{String: `<p class="lead">Postgres <em>vacuum</em> &amp; more</p>`, Valid: true},
+		Content:     sql.NullString{String: `<div><script>track()</script><p>Tuning autovacuum</p></div>`, Valid: true},
+	}
+	item := ruleItem(post, "Engineering", "", nil)
+
+	tests := []struct {
+		rule    rules.Rule
+		matches bool
+	}{
+		{rules.Rule{Field: rules.FieldDescription, Match: rules.MatchRegex, Pattern: `^Postgres vacuum & more$`, Action: rules.ActionStar}, true},
+		{rules.Rule{Field: rules.FieldDescription, Match: rules.MatchSubstring, Pattern: "lead", Action: rules.ActionStar}, false},
+		{rules.Rule{Field: rules.FieldDescription, Match: rules.MatchKeywords, Pattern: "em, amp", Action: rules.ActionStar}, false},
+		{rules.Rule{Field: rules.FieldText, Match: rules.MatchSubstring, Pattern: "tuning autovacuum", Action: rules.ActionStar}, true},
+		{rules.Rule{Field: rules.FieldText, Match: rules.MatchSubstring, Pattern: "track", Action: rules.ActionStar}, false},
+	}
+	for _, tt := range tests {
+		m, err := rules.Compile(tt.rule)
+		if err != nil {
+			t.Fatalf("Compile(%+v) failed: %v", tt.rule, err)
+		}
+		if got := m.Matches(item); got != tt.matches {
+			t.Errorf("Rule %+v on %+v: expected match %v, got %v", tt.rule, item, tt.matches, got)
+		}
+	}
+}
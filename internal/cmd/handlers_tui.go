@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/tui"
+)
+
+func HandlerTUI(s *app.State, c Command, user database.User) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	m := tui.NewModel(s.Db, user, tui.Options{
+		Refresh: func(ctx context.Context) error {
+			return refreshFollows(s, ctx, user)
+		},
+		Limit: c.Int("limit"),
+	})
+	if err := tui.Run(ctx, m, os.Stdin, os.Stdout); err != nil {
+		if errors.Is(err, tui.ErrNotTerminal) {
+			return fmt.Errorf("tui handler error: %w, use browse instead", err)
+		}
+		return fmt.Errorf("tui handler error: %w", err)
+	}
+	return nil
+}
+
+// refreshFollows fetches the feeds user follows, skipping muted ones. It
+// runs while the tui owns the terminal, so messages that would go to stderr
+// are collected and the first problem is returned for the status line.
+func refreshFollows(s *app.State, ctx context.Context, user database.User) error {
+	follows, err := s.Db.GetFeedFollowsByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	var log bytes.Buffer
+	quiet := *s
+	quiet.Err = &log
+	quiet.Verbose = false
+
+	var problems []string
+	total := 0
+	for _, follow := range follows {
+		if follow.Muted {
+			continue
+		}
+		total++
+		feed, err := s.Db.GetFeedByURL(ctx, follow.FeedUrl)
+		if err == nil {
+			_, err = scrapeFeed(&quiet, feed)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", follow.FeedUrl, err))
+		}
+	}
+	failed := len(problems)
+	if warnings := strings.TrimSpace(log.String()); warnings != "" {
+		problems = append(problems, strings.Split(warnings, "\n")...)
+	}
+
+	switch {
+	case failed > 0:
+		return fmt.Errorf("could not refresh %d of %d feeds, %d problems, first: %s", failed, total, len(problems), problems[0])
+	case len(problems) > 0:
+		return fmt.Errorf("refreshed %d feeds with %d problems, first: %s", total, len(problems), problems[0])
+	}
+	return nil
+}
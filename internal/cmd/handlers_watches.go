@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/notify"
+	"github.com/theandyeh/gator/internal/rules"
+)
+
+func HandlerWatch(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("watch handler error: expected add, list or rm")
+	}
+
+	switch c.Args[0] {
+	case "add":
+		if len(c.Args) < 2 {
+			return fmt.Errorf("watch handler error: no watch name provided (watch add <name> --pattern <pattern>)")
+		}
+
+		r := rules.Rule{
+			Field:   c.String("field"),
+			Match:   c.String("match"),
+			Pattern: c.String("pattern"),
+			Action:  rules.ActionNotify,
+		}
+		if _, err := rules.Compile(r); err != nil {
+			return fmt.Errorf("watch handler error: %w", err)
+		}
+		if _, err := notify.New(c.String("notifier"), c.String("target"), notifyAllowlist(s)); err != nil {
+			return fmt.Errorf("watch handler error: %w", err)
+		}
+
+		watchP := database.CreateWatchParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			Name:      c.Args[1],
+			Field:     r.Field,
+			MatchType: r.Match,
+			Pattern:   r.Pattern,
+			Notifier:  c.String("notifier"),
+			Target:    c.String("target"),
+		}
+		watch, err := s.Db.CreateWatch(context.Background(), watchP)
+		if err != nil {
+			return fmt.Errorf("watch handler error: %w", err)
+		}
+		out := newRecord("Watch added", "name", "field", "match", "pattern", "notifier", "target")
+		out.add(watch.Name, watch.Field, watch.MatchType, watch.Pattern, watch.Notifier, watch.Target)
+		return render(s, out)
+
+	case "list":
+		watches, err := s.Db.GetWatchesForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("watch handler error retrieving watches: %w", err)
+		}
+		out := newList(fmt.Sprintf("Watches of user %s", user.Name), "name", "field", "match", "pattern", "notifier", "target")
+		for _, watch := range watches {
+			out.add(watch.Name, watch.Field, watch.MatchType, watch.Pattern, watch.Notifier, watch.Target)
+		}
+		return render(s, out)
+
+	case "rm":
+		if len(c.Args) < 2 {
+			return fmt.Errorf("watch handler error: no watch name provided (watch rm <name>)")
+		}
+		removed, err := s.Db.DeleteWatch(context.Background(), database.DeleteWatchParams{UserID: user.ID, Name: c.Args[1]})
+		if err != nil {
+			return fmt.Errorf("watch handler error: %w", err)
+		}
+		if removed == 0 {
+			return fmt.Errorf("watch handler error: no watch named %s", c.Args[1])
+		}
+		out := newRecord("Removed watch", "name")
+		out.add(c.Args[1])
+		return render(s, out)
+	}
+
+	return fmt.Errorf("watch handler error: unknown action %s, expected add, list or rm", c.Args[0])
+}
+
+// notifyAllowlist returns the command and file targets the operator allows
+// watches to use. Everything else is limited to desktop notifications.
+func notifyAllowlist(s *app.State) notify.Allowlist {
+	if s.Cfg == nil {
+		return notify.Allowlist{}
+	}
+	return notify.Allowlist{Commands: s.Cfg.Notify_commands, Files: s.Cfg.Notify_files}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/app"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedurl"
+	"github.com/theandyeh/gator/internal/rules"
+	"github.com/theandyeh/gator/internal/webhook"
+)
+
+func HandlerWebhook(s *app.State, c Command, user database.User) error {
+	if len(c.Args) < 1 {
+		return fmt.Errorf("webhook handler error: expected add, list, rm, test or log")
+	}
+
+	switch c.Args[0] {
+	case "add":
+		if len(c.Args) < 2 {
+			return fmt.Errorf("webhook handler error: no url provided (webhook add <url>)")
+		}
+		if err := feedurl.Validate(c.Args[1], s.Cfg.Allow_private_urls); err != nil {
+			return fmt.Errorf("webhook handler error: %w", err)
+		}
+
+		var feedID uuid.NullUUID
+		if c.String("feed") != "" {
+			feedUrl, err := feedurl.Normalize(c.String("feed"))
+			if err != nil {
+				return fmt.Errorf("webhook handler error: %w", err)
+			}
+			feed, err := s.Db.GetFeedByURL(context.Background(), feedUrl)
+			if err != nil {
+				return fmt.Errorf("webhook handler error retrieving feed %s: %w", feedUrl, err)
+			}
+			feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		}
+		if filter := c.String("filter"); filter != "" {
+			if _, err := rules.ParseFilter(filter); err != nil {
+				return fmt.Errorf("webhook handler error: %w", err)
+			}
+		}
+
+		var tmpl string
+		if path := c.String("template"); path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("webhook handler error: %w", err)
+			}
+			if _, err := webhook.ParseTemplate(string(data)); err != nil {
+				return fmt.Errorf("webhook handler error: %w", err)
+			}
+			tmpl = string(data)
+		}
+
+		secret := c.String("secret")
+		if secret == "" {
+			secret = webhook.NewSecret()
+		}
+
+		hookP := database.CreateWebhookParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			Url:       c.Args[1],
+			FeedID:    feedID,
+			Filter:    c.String("filter"),
+			Secret:    secret,
+			Template:  tmpl,
+		}
+		hook, err := s.Db.CreateWebhook(context.Background(), hookP)
+		if err != nil {
+			return fmt.Errorf("webhook handler error: %w", err)
+		}
+		out := newRecord("Webhook added, verify deliveries with the secret", "id", "url", "feed", "filter", "secret")
+		out.add(hook.ID, hook.Url, c.String("feed"), hook.Filter, hook.Secret)
+		return render(s, out)
+
+	case "list":
+		hooks, err := s.Db.GetWebhooksForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("webhook handler error retrieving webhooks: %w", err)
+		}
+		out := newList(fmt.Sprintf("Webhooks of user %s", user.Name), "id", "url", "feed", "filter", "template")
+		for _, hook := range hooks {
+			out.add(hook.ID, hook.Url, hook.FeedUrl.String, hook.Filter, hook.Template != "")
+		}
+		return render(s, out)
+
+	case "rm":
+		hook, err := userWebhook(s, c, user)
+		if err != nil {
+			return fmt.Errorf("webhook handler error: %w", err)
+		}
+		if _, err := s.Db.DeleteWebhook(context.Background(), database.DeleteWebhookParams{ID: hook.ID, UserID: user.ID}); err != nil {
+			return fmt.Errorf("webhook handler error: %w", err)
+		}
+		out := newRecord("Removed webhook", "id", "url")
+		out.add(hook.ID, hook.Url)
+		return render(s, out)
+
+	case "test":
+		hook, err := userWebhook(s, c, user)
+		if err != nil {
+			return fmt.Errorf("webhook handler error: %w", err)
+		}
+		payload := webhook.Payload{
+			Event: webhook.EventPing,
+			Feed:  webhook.Feed{Name: "gator", URL: "https://example.com/feed.xml"},
+			Post:  webhook.Post{ID: uuid.Nil.String(), Title: "Test delivery from gator", URL: "https://example.com/"},
+			Sent:  time.Now().UTC(),
+		}
+		res := sendWebhook(s, hook.ID, hook.Url, hook.Secret, hook.Template, payload, uuid.NullUUID{})
+		if !res.Succeeded() {
+			return fmt.Errorf("webhook handler error: delivery failed after %d attempts: %w", res.Attempts, res.Err)
+		}
+		out := newRecord("Test delivery succeeded", "url", "status", "attempts")
+		out.add(hook.Url, res.StatusCode, res.Attempts)
+		return render(s, out)
+
+	case "log":
+		hook, err := userWebhook(s, c, user)
+		if err != nil {
+			return fmt.Errorf("webhook handler error: %w", err)
+		}
+		limit := c.Int("limit")
+		if limit <= 0 {
+			return fmt.Errorf("webhook handler error: limit must be positive, got %d", limit)
+		}
+		deliveriesP := database.GetWebhookDeliveriesParams{WebhookID: hook.ID, Limit: int32(limit)}
+		deliveries, err := s.Db.GetWebhookDeliveries(context.Background(), deliveriesP)
+		if err != nil {
+			return fmt.Errorf("webhook handler error retrieving deliveries: %w", err)
+		}
+		out := newList(fmt.Sprintf("Deliveries to %s", hook.Url), "id", "created_at", "event", "post", "attempts", "status", "error", "pending")
+		for _, d := range deliveries {
+			out.add(d.ID, d.CreatedAt, d.Event, d.PostTitle.String, d.Attempts, d.StatusCode, d.Error, d.Pending)
+		}
+		return render(s, out)
+	}
+
+	return fmt.Errorf("webhook handler error: unknown action %s, expected add, list, rm, test or log", c.Args[0])
+}
+
+// userWebhook looks up the webhook named by the second argument, only
+// returning webhooks owned by user.
+func userWebhook(s *app.State, c Command, user database.User) (database.Webhook, error) {
+	if len(c.Args) < 2 {
+		return database.Webhook{}, fmt.Errorf("no webhook id provided (webhook %s <id>)", c.Args[0])
+	}
+	hookID, err := uuid.Parse(c.Args[1])
+	if err != nil {
+		return database.Webhook{}, fmt.Errorf("invalid webhook id: %w", err)
+	}
+	hook, err := s.Db.GetWebhook(context.Background(), database.GetWebhookParams{ID: hookID, UserID: user.ID})
+	if err != nil {
+		return database.Webhook{}, fmt.Errorf("retrieving webhook %s: %w", hookID, err)
+	}
+	return hook, nil
+}
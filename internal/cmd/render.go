@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/theandyeh/gator/internal/app"
+)
+
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+	OutputTable = "table"
+)
+
+// Output is what every handler hands to the renderer instead of printing
+// directly. Columns are snake_case keys, used as JSON keys and CSV headers
+// and turned into labels for text and table output.
+type Output struct {
+	Title   string
+	Columns []string
+	Rows    [][]any
+	single  bool
+}
+
+func newList(title string, columns ...string) *Output {
+	return &Output{Title: title, Columns: columns}
+}
+
+// newRecord creates an output holding exactly one row, rendered as an object
+// rather than an array in JSON.
+func newRecord(title string, columns ...string) *Output {
+	return &Output{Title: title, Columns: columns, single: true}
+}
+
+func (o *Output) add(values ...any) {
+	o.Rows = append(o.Rows, values)
+}
+
+func ValidOutputFormat(format string) bool {
+	switch format {
+	case OutputText, OutputJSON, OutputCSV, OutputTable:
+		return true
+	}
+	return false
+}
+
+func render(s *app.State, o *Output) error {
+	var w io.Writer = os.Stdout
+	if s.Out != nil {
+		w = s.Out
+	}
+
+	switch s.Output {
+	case OutputJSON:
+		return renderJSON(w, o)
+	case OutputCSV:
+		return renderCSV(w, o)
+	case OutputTable:
+		return renderTable(w, o)
+	case OutputText, "":
+		return renderText(w, o)
+	}
+	return fmt.Errorf("render error: unknown output format %q", s.Output)
+}
+
+func renderText(w io.Writer, o *Output) error {
+	if o.Title != "" {
+		fmt.Fprintf(w, "%s:\n", o.Title)
+	}
+
+	for _, row := range o.Rows {
+		for i, col := range o.Columns {
+			prefix := "  "
+			if i == 0 {
+				prefix = "- "
+			}
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, columnLabel(col), formatValue(row[i]))
+		}
+		if len(o.Columns) > 1 {
+			fmt.Fprintln(w, "--------------------------")
+		}
+	}
+	return nil
+}
+
+func renderTable(w io.Writer, o *Output) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	labels := make([]string, len(o.Columns))
+	for i, col := range o.Columns {
+		labels[i] = strings.ToUpper(columnLabel(col))
+	}
+	fmt.Fprintln(tw, strings.Join(labels, "\t"))
+
+	for _, row := range o.Rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = strings.ReplaceAll(formatValue(v), "\n", " ")
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func renderCSV(w io.Writer, o *Output) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(o.Columns); err != nil {
+		return fmt.Errorf("render error: %w", err)
+	}
+
+	for _, row := range o.Rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = formatValue(v)
+		}
+		if err := cw.Write(cells); err != nil {
+			return fmt.Errorf("render error: %w", err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderJSON writes objects with keys in column order so the output is
+// stable between runs.
+func renderJSON(w io.Writer, o *Output) error {
+	objects := make([]json.RawMessage, 0, len(o.Rows))
+	for _, row := range o.Rows {
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for i, col := range o.Columns {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(col)
+			val, err := json.Marshal(row[i])
+			if err != nil {
+				return fmt.Errorf("render error: %w", err)
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(val)
+		}
+		buf.WriteByte('}')
+		objects = append(objects, buf.Bytes())
+	}
+
+	var data []byte
+	var err error
+	if o.single && len(objects) == 1 {
+		data, err = json.MarshalIndent(objects[0], "", "  ")
+	} else {
+		data, err = json.MarshalIndent(objects, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("render error: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func columnLabel(col string) string {
+	words := strings.Split(col, "_")
+	for i, word := range words {
+		switch word {
+		case "id", "url":
+			words[i] = strings.ToUpper(word)
+		default:
+			if word != "" {
+				words[i] = strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.Format(time.RFC3339)
+	case string:
+		return val
+	case fmt.Stringer:
+		return val.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// debugf writes diagnostics to stderr when --verbose is set, keeping stdout
+// clean for machine readable output.
+func debugf(s *app.State, format string, args ...any) {
+	if s.Verbose {
+		fmt.Fprintf(stderr(s), format+"\n", args...)
+	}
+}
+
+// stderr is where diagnostics and non-fatal errors go, os.Stderr unless
+// the caller collects them, like the tui does while the terminal is in use.
+func stderr(s *app.State) io.Writer {
+	if s.Err != nil {
+		return s.Err
+	}
+	return os.Stderr
+}
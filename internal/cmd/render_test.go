@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/theandyeh/gator/internal/app"
+)
+
+func testOutput() *Output {
+	out := newList("Registered Feeds", "name", "url", "current")
+	out.add("Blog", "https://example.com/feed", true)
+	out.add("News, daily", "https://news.example.com/rss", false)
+	return out
+}
+
+func TestRenderJSON(t *testing.T) {
+	var buf bytes.Buffer
+	state := &app.State{Output: OutputJSON, Out: &buf}
+
+	if err := render(state, testOutput()); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	expected := `[
+  {
+    "name": "Blog",
+    "url": "https://example.com/feed",
+    "current": true
+  },
+  {
+    "name": "News, daily",
+    "url": "https://news.example.com/rss",
+    "current": false
+  }
+]
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected JSON output:\n%s", buf.String())
+	}
+}
+
+func TestRenderJSONRecord(t *testing.T) {
+	var buf bytes.Buffer
+	state := &app.State{Output: OutputJSON, Out: &buf}
+
+	out := newRecord("Successfully set DB user", "user")
+	out.add("alice")
+	if err := render(state, out); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	if strings.TrimSpace(buf.String()) != "{\n  \"user\": \"alice\"\n}" {
+		t.Errorf("Expected a single JSON object, got:\n%s", buf.String())
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	var buf bytes.Buffer
+	state := &app.State{Output: OutputCSV, Out: &buf}
+
+	if err := render(state, testOutput()); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	expected := "name,url,current\nBlog,https://example.com/feed,true\n\"News, daily\",https://news.example.com/rss,false\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected CSV output:\n%s", buf.String())
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	var buf bytes.Buffer
+	state := &app.State{Out: &buf}
+
+	if err := render(state, testOutput()); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	for _, want := range []string{"Registered Feeds:\n", "- Name: Blog\n", "  URL: https://example.com/feed\n", "  Current: true\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected text output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	var buf bytes.Buffer
+	state := &app.State{Output: OutputTable, Out: &buf}
+
+	if err := render(state, testOutput()); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "NAME") {
+		t.Errorf("Expected table header, got %q", lines[0])
+	}
+}
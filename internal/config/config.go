@@ -9,8 +9,23 @@ import (
 const Config_file_name = ".gatorconfig.json"
 
 type Config struct {
-	Db_url          string `json:"db_url"`
-	Current_db_user string `json:"current_user_name"`
+	Db_url             string   `json:"db_url"`
+	Current_db_user    string   `json:"current_user_name"`
+	Allow_private_urls bool     `json:"allow_private_urls"`
+	Fetch_allow_cidrs  []string `json:"fetch_allow_cidrs"`
+	Fetch_deny_cidrs   []string `json:"fetch_deny_cidrs"`
+	Smtp_host          string   `json:"smtp_host"`
+	Smtp_port          int      `json:"smtp_port"`
+	Smtp_username      string   `json:"smtp_username"`
+	Smtp_password      string   `json:"smtp_password"`
+	Smtp_starttls      bool     `json:"smtp_starttls"`
+	Digest_from        string   `json:"digest_from"`
+	Download_dir       string   `json:"download_dir"`
+	Web_secret         string   `json:"web_secret"`
+	Notify_commands    []string `json:"notify_commands"`
+	Notify_files       []string `json:"notify_files"`
+
+	path string
 }
 
 func Read() (*Config, error) {
@@ -19,6 +34,12 @@ func Read() (*Config, error) {
 		return &Config{}, err
 	}
 
+	return ReadFrom(config_file_path)
+}
+
+// ReadFrom reads the config at config_file_path. Later writes go back to the
+// same file.
+func ReadFrom(config_file_path string) (*Config, error) {
 	file, err := os.ReadFile(config_file_path)
 	if err != nil {
 		return &Config{}, fmt.Errorf("config error reading config file: %w", err)
@@ -29,6 +50,7 @@ func Read() (*Config, error) {
 	if err != nil {
 		return &Config{}, fmt.Errorf("config error parsing config file: %w", err)
 	}
+	config.path = config_file_path
 
 	return &config, nil
 }
@@ -49,9 +71,13 @@ func (c *Config) SetUser(username string) error {
 }
 
 func (c *Config) WriteConfig() error {
-	config_file_path, err := GetConfigPath()
-	if err != nil {
-		return fmt.Errorf("config error nable to get config file path to write: %w", err)
+	config_file_path := c.path
+	if config_file_path == "" {
+		var err error
+		config_file_path, err = GetConfigPath()
+		if err != nil {
+			return fmt.Errorf("config error nable to get config file path to write: %w", err)
+		}
 	}
 
 	data, err := json.Marshal(c)
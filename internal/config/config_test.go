@@ -126,3 +126,33 @@ func TestConfigJSONMarshaling(t *testing.T) {
 		t.Errorf("Expected user '%s', got '%s'", cfg.Current_db_user, unmarshaled.Current_db_user)
 	}
 }
+
+func TestReadFromCustomPath(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "custom.json")
+
+	err := os.WriteFile(configPath, []byte(`{"db_url":"postgresql://custom","current_user_name":"alice"}`), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	cfg, err := ReadFrom(configPath)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	if cfg.Db_url != "postgresql://custom" {
+		t.Errorf("Expected db_url 'postgresql://custom', got '%s'", cfg.Db_url)
+	}
+
+	if err := cfg.SetUser("bob"); err != nil {
+		t.Fatalf("SetUser failed: %v", err)
+	}
+
+	reread, err := ReadFrom(configPath)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	if reread.Current_db_user != "bob" {
+		t.Errorf("Expected user 'bob' written back to custom path, got '%s'", reread.Current_db_user)
+	}
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,10 +17,10 @@ const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
     INSERT INTO feed_follows (id, created_at, updated_at, feed_id, user_id)
     VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, created_at, updated_at, feed_id, user_id
+    RETURNING id, created_at, updated_at, feed_id, user_id, category_id, title, muted, priority, notes
 )
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.feed_id, inserted_feed_follow.user_id,
+    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.feed_id, inserted_feed_follow.user_id, inserted_feed_follow.category_id, inserted_feed_follow.title, inserted_feed_follow.muted, inserted_feed_follow.priority, inserted_feed_follow.notes,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
@@ -36,13 +37,18 @@ type CreateFeedFollowParams struct {
 }
 
 type CreateFeedFollowRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	FeedID    uuid.UUID
-	UserID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FeedID     uuid.UUID
+	UserID     uuid.UUID
+	CategoryID uuid.NullUUID
+	Title      sql.NullString
+	Muted      bool
+	Priority   int32
+	Notes      sql.NullString
+	FeedName   string
+	UserName   string
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
@@ -60,6 +66,11 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.FeedID,
 		&i.UserID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Muted,
+		&i.Priority,
+		&i.Notes,
 		&i.FeedName,
 		&i.UserName,
 	)
@@ -81,25 +92,61 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 	return err
 }
 
+const getFeedFollow = `-- name: GetFeedFollow :one
+SELECT id, created_at, updated_at, feed_id, user_id, category_id, title, muted, priority, notes FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type GetFeedFollowParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow,
+		arg.UserID,
+		arg.FeedID,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Muted,
+		&i.Priority,
+		&i.Notes,
+	)
+	return i, err
+}
+
 const getFeedFollowsByURL = `-- name: GetFeedFollowsByURL :many
 SELECT
-    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.feed_id, feed_follows.user_id,
+    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.feed_id, feed_follows.user_id, feed_follows.category_id, feed_follows.title, feed_follows.muted, feed_follows.priority, feed_follows.notes,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 INNER JOIN users ON feed_follows.user_id = users.id
-WHERE feeds.url = $1
+WHERE feeds.url_key = regexp_replace($1, '^https?://', '')
 `
 
 type GetFeedFollowsByURLRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	FeedID    uuid.UUID
-	UserID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FeedID     uuid.UUID
+	UserID     uuid.UUID
+	CategoryID uuid.NullUUID
+	Title      sql.NullString
+	Muted      bool
+	Priority   int32
+	Notes      sql.NullString
+	FeedName   string
+	UserName   string
 }
 
 func (q *Queries) GetFeedFollowsByURL(ctx context.Context, url string) ([]GetFeedFollowsByURLRow, error) {
@@ -117,6 +164,11 @@ func (q *Queries) GetFeedFollowsByURL(ctx context.Context, url string) ([]GetFee
 			&i.UpdatedAt,
 			&i.FeedID,
 			&i.UserID,
+			&i.CategoryID,
+			&i.Title,
+			&i.Muted,
+			&i.Priority,
+			&i.Notes,
 			&i.FeedName,
 			&i.UserName,
 		); err != nil {
@@ -135,23 +187,45 @@ func (q *Queries) GetFeedFollowsByURL(ctx context.Context, url string) ([]GetFee
 
 const getFeedFollowsByUserID = `-- name: GetFeedFollowsByUserID :many
 SELECT
-    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.feed_id, feed_follows.user_id,
-    feeds.name AS feed_name,
-    users.name AS user_name
+    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.feed_id, feed_follows.user_id, feed_follows.category_id, feed_follows.title, feed_follows.muted, feed_follows.priority, feed_follows.notes,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    users.name AS user_name,
+    (
+        SELECT COUNT(*)
+        FROM posts
+        LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+        WHERE posts.feed_id = feed_follows.feed_id AND post_reads.post_id IS NULL
+        AND NOT EXISTS (
+            SELECT 1 FROM post_hides
+            WHERE post_hides.post_id = posts.id AND post_hides.user_id = feed_follows.user_id
+        )
+    ) AS unread_count,
+    categories.name AS category_name,
+    feeds.url AS feed_url
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 INNER JOIN users ON feed_follows.user_id = users.id
+LEFT JOIN categories ON feed_follows.category_id = categories.id
 WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.priority DESC, feed_name
 `
 
 type GetFeedFollowsByUserIDRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	FeedID    uuid.UUID
-	UserID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	FeedID       uuid.UUID
+	UserID       uuid.UUID
+	CategoryID   uuid.NullUUID
+	Title        sql.NullString
+	Muted        bool
+	Priority     int32
+	Notes        sql.NullString
+	FeedName     string
+	UserName     string
+	UnreadCount  int64
+	CategoryName sql.NullString
+	FeedUrl      string
 }
 
 func (q *Queries) GetFeedFollowsByUserID(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsByUserIDRow, error) {
@@ -169,8 +243,16 @@ func (q *Queries) GetFeedFollowsByUserID(ctx context.Context, userID uuid.UUID)
 			&i.UpdatedAt,
 			&i.FeedID,
 			&i.UserID,
+			&i.CategoryID,
+			&i.Title,
+			&i.Muted,
+			&i.Priority,
+			&i.Notes,
 			&i.FeedName,
 			&i.UserName,
+			&i.UnreadCount,
+			&i.CategoryName,
+			&i.FeedUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -187,7 +269,7 @@ func (q *Queries) GetFeedFollowsByUserID(ctx context.Context, userID uuid.UUID)
 
 const getFeedFollowsByUserName = `-- name: GetFeedFollowsByUserName :many
 SELECT
-    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.feed_id, feed_follows.user_id,
+    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.feed_id, feed_follows.user_id, feed_follows.category_id, feed_follows.title, feed_follows.muted, feed_follows.priority, feed_follows.notes,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM feed_follows
@@ -197,13 +279,18 @@ WHERE users.name = $1
 `
 
 type GetFeedFollowsByUserNameRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	FeedID    uuid.UUID
-	UserID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FeedID     uuid.UUID
+	UserID     uuid.UUID
+	CategoryID uuid.NullUUID
+	Title      sql.NullString
+	Muted      bool
+	Priority   int32
+	Notes      sql.NullString
+	FeedName   string
+	UserName   string
 }
 
 func (q *Queries) GetFeedFollowsByUserName(ctx context.Context, name string) ([]GetFeedFollowsByUserNameRow, error) {
@@ -221,6 +308,11 @@ func (q *Queries) GetFeedFollowsByUserName(ctx context.Context, name string) ([]
 			&i.UpdatedAt,
 			&i.FeedID,
 			&i.UserID,
+			&i.CategoryID,
+			&i.Title,
+			&i.Muted,
+			&i.Priority,
+			&i.Notes,
 			&i.FeedName,
 			&i.UserName,
 		); err != nil {
@@ -236,3 +328,98 @@ func (q *Queries) GetFeedFollowsByUserName(ctx context.Context, name string) ([]
 	}
 	return items, nil
 }
+
+const mergeFeedFollows = `-- name: MergeFeedFollows :exec
+UPDATE feed_follows AS kept
+SET title = COALESCE(kept.title, dup.title),
+    notes = COALESCE(kept.notes, dup.notes),
+    category_id = COALESCE(kept.category_id, dup.category_id),
+    muted = kept.muted OR dup.muted,
+    priority = GREATEST(kept.priority, dup.priority),
+    updated_at = $1
+FROM feed_follows AS dup
+-- Users who follow both feeds keep their follow of the kept feed, which
+-- takes over the overrides only set on the duplicate.
+WHERE kept.feed_id = $2
+AND dup.feed_id = $3
+AND dup.user_id = kept.user_id
+`
+
+type MergeFeedFollowsParams struct {
+	UpdatedAt   time.Time
+	KeepID      uuid.UUID
+	DuplicateID uuid.UUID
+}
+
+func (q *Queries) MergeFeedFollows(ctx context.Context, arg MergeFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, mergeFeedFollows, arg.UpdatedAt, arg.KeepID, arg.DuplicateID)
+	return err
+}
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = $1, updated_at = $2
+WHERE feed_id = $3
+AND user_id NOT IN (
+    SELECT user_id FROM feed_follows WHERE feed_id = $1
+)
+`
+
+type MoveFeedFollowsParams struct {
+	KeepID      uuid.UUID
+	UpdatedAt   time.Time
+	DuplicateID uuid.UUID
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.KeepID, arg.UpdatedAt, arg.DuplicateID)
+	return err
+}
+
+const updateFeedFollowOverrides = `-- name: UpdateFeedFollowOverrides :one
+UPDATE feed_follows
+SET
+    title = $1,
+    muted = $2,
+    priority = $3,
+    notes = $4,
+    updated_at = $5
+WHERE user_id = $6 AND feed_id = $7
+RETURNING id, created_at, updated_at, feed_id, user_id, category_id, title, muted, priority, notes
+`
+
+type UpdateFeedFollowOverridesParams struct {
+	Title     sql.NullString
+	Muted     bool
+	Priority  int32
+	Notes     sql.NullString
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+}
+
+func (q *Queries) UpdateFeedFollowOverrides(ctx context.Context, arg UpdateFeedFollowOverridesParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, updateFeedFollowOverrides,
+		arg.Title,
+		arg.Muted,
+		arg.Priority,
+		arg.Notes,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Muted,
+		&i.Priority,
+		&i.Notes,
+	)
+	return i, err
+}
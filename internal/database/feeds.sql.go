@@ -23,7 +23,7 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, created_at, updated_at, url, name, user_id
+RETURNING id, created_at, updated_at, url, name, user_id, last_fetched_at, seq, fetch_full_content, image_url, language, generator, url_key
 `
 
 type CreateFeedParams struct {
@@ -52,10 +52,27 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.Name,
 		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Seq,
+		&i.FetchFullContent,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.UrlKey,
 	)
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
 const deleteFeeds = `-- name: DeleteFeeds :exec
 DELETE FROM feeds
 `
@@ -65,6 +82,35 @@ func (q *Queries) DeleteFeeds(ctx context.Context) error {
 	return err
 }
 
+const getFeedByURL = `-- name: GetFeedByURL :one
+SELECT id, created_at, updated_at, url, name, user_id, last_fetched_at, seq, fetch_full_content, image_url, language, generator, url_key FROM feeds
+-- http and https spellings name the same feed, an exact match wins.
+WHERE url_key = regexp_replace($1, '^https?://', '')
+ORDER BY url = $1 DESC
+LIMIT 1
+`
+
+func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURL, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Url,
+		&i.Name,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Seq,
+		&i.FetchFullContent,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.UrlKey,
+	)
+	return i, err
+}
+
 const getFeeds = `-- name: GetFeeds :many
 SELECT feeds.id, feeds.url, feeds.name, feeds.user_id, users.name AS username
 FROM feeds
@@ -107,3 +153,141 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	}
 	return items, nil
 }
+
+const getFeedsByAge = `-- name: GetFeedsByAge :many
+SELECT id, created_at, updated_at, url, name, user_id, last_fetched_at, seq, fetch_full_content, image_url, language, generator, url_key FROM feeds
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetFeedsByAge(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByAge)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Url,
+			&i.Name,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Seq,
+			&i.FetchFullContent,
+			&i.ImageUrl,
+			&i.Language,
+			&i.Generator,
+			&i.UrlKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
+SELECT id, created_at, updated_at, url, name, user_id, last_fetched_at, seq, fetch_full_content, image_url, language, generator, url_key FROM feeds
+ORDER BY last_fetched_at ASC NULLS FIRST
+LIMIT 1
+`
+
+func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Url,
+		&i.Name,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Seq,
+		&i.FetchFullContent,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.UrlKey,
+	)
+	return i, err
+}
+
+const markFeedFetched = `-- name: MarkFeedFetched :exec
+UPDATE feeds
+SET last_fetched_at = $2, updated_at = $2
+WHERE id = $1
+`
+
+type MarkFeedFetchedParams struct {
+	ID            uuid.UUID
+	LastFetchedAt sql.NullTime
+}
+
+func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.ID, arg.LastFetchedAt)
+	return err
+}
+
+const setFeedFullContent = `-- name: SetFeedFullContent :exec
+UPDATE feeds
+SET fetch_full_content = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetFeedFullContentParams struct {
+	ID               uuid.UUID
+	FetchFullContent bool
+	UpdatedAt        time.Time
+}
+
+func (q *Queries) SetFeedFullContent(ctx context.Context, arg SetFeedFullContentParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFullContent, arg.ID, arg.FetchFullContent, arg.UpdatedAt)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET image_url = $2, language = $3, generator = $4, updated_at = $5
+WHERE id = $1
+`
+
+type UpdateFeedMetadataParams struct {
+	ID        uuid.UUID
+	ImageUrl  sql.NullString
+	Language  sql.NullString
+	Generator sql.NullString
+	UpdatedAt time.Time
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata, arg.ID, arg.ImageUrl, arg.Language, arg.Generator, arg.UpdatedAt)
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2, updated_at = $3
+WHERE id = $1
+`
+
+type UpdateFeedURLParams struct {
+	ID        uuid.UUID
+	Url       string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg UpdateFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedURL, arg.ID, arg.Url, arg.UpdatedAt)
+	return err
+}
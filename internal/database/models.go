@@ -5,31 +5,185 @@
 package database
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-type Feed struct {
+type ApiToken struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	LastUsedAt sql.NullTime
+}
+
+type Category struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Url       string
 	Name      string
 	UserID    uuid.UUID
+	Seq       int64
+}
+
+type Enclosure struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	PostID         uuid.UUID
+	Position       int32
+	Url            string
+	MimeType       string
+	Length         int64
+	DownloadedPath sql.NullString
+	DownloadedAt   sql.NullTime
+}
+
+type Feed struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Url              string
+	Name             string
+	UserID           uuid.UUID
+	LastFetchedAt    sql.NullTime
+	Seq              int64
+	FetchFullContent bool
+	ImageUrl         sql.NullString
+	Language         sql.NullString
+	Generator        sql.NullString
+	UrlKey           sql.NullString
 }
 
 type FeedFollow struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FeedID     uuid.UUID
+	UserID     uuid.UUID
+	CategoryID uuid.NullUUID
+	Title      sql.NullString
+	Muted      bool
+	Priority   int32
+	Notes      sql.NullString
+}
+
+type Post struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	Author          sql.NullString
+	Seq             int64
+	Content         sql.NullString
+	Search          interface{}
+	DurationSeconds sql.NullInt32
+	Season          sql.NullInt32
+	Episode         sql.NullInt32
+	ImageUrl        sql.NullString
+	Guid            sql.NullString
+	CommentsUrl     sql.NullString
+	SourceTitle     sql.NullString
+	SourceUrl       sql.NullString
+}
+
+type PostCategory struct {
+	PostID uuid.UUID
+	Name   string
+}
+
+type PostHide struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostStar struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	FeedID    uuid.UUID
+	PostID    uuid.UUID
 	UserID    uuid.UUID
 }
 
+type PostTag struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type Rule struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Field     string
+	MatchType string
+	Pattern   string
+	Action    string
+	ActionArg string
+}
+
 type User struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Name          string
+	Email         sql.NullString
+	LastDigestAt  sql.NullTime
+	FeverApiKey   sql.NullString
+	FeedTokenHash sql.NullString
+}
+
+type Watch struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	UserID    uuid.UUID
 	Name      string
+	Field     string
+	MatchType string
+	Pattern   string
+	Notifier  string
+	Target    string
+}
+
+type Webhook struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Url       string
+	FeedID    uuid.NullUUID
+	Filter    string
+	Secret    string
+	Template  string
+}
+
+type WebhookDelivery struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	WebhookID     uuid.UUID
+	PostID        uuid.NullUUID
+	Event         string
+	Attempts      int32
+	StatusCode    int32
+	Error         string
+	Body          string
+	Pending       bool
+	NextAttemptAt sql.NullTime
 }
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,7 +21,7 @@ VALUES (
     $3,
     $4
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, email, last_digest_at, fever_api_key, feed_token_hash
 `
 
 type CreateUserParams struct {
@@ -43,6 +44,10 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.FeverApiKey,
+		&i.FeedTokenHash,
 	)
 	return i, err
 }
@@ -57,7 +62,7 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, email, last_digest_at, fever_api_key, feed_token_hash FROM users
 WHERE name = $1 LIMIT 1
 `
 
@@ -69,12 +74,16 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.FeverApiKey,
+		&i.FeedTokenHash,
 	)
 	return i, err
 }
 
 const getUsers = `-- name: GetUsers :many
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, email, last_digest_at, fever_api_key, feed_token_hash FROM users
 `
 
 func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
@@ -91,6 +100,10 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.Email,
+			&i.LastDigestAt,
+			&i.FeverApiKey,
+			&i.FeedTokenHash,
 		); err != nil {
 			return nil, err
 		}
@@ -104,3 +117,108 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 	}
 	return items, nil
 }
+
+const getUsersDueForDigest = `-- name: GetUsersDueForDigest :many
+SELECT id, created_at, updated_at, name, email, last_digest_at, fever_api_key, feed_token_hash FROM users
+WHERE email IS NOT NULL
+AND (last_digest_at IS NULL OR last_digest_at <= $1)
+ORDER BY name
+`
+
+func (q *Queries) GetUsersDueForDigest(ctx context.Context, lastDigestAt sql.NullTime) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersDueForDigest, lastDigestAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Email,
+			&i.LastDigestAt,
+			&i.FeverApiKey,
+			&i.FeedTokenHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markUserDigested = `-- name: MarkUserDigested :exec
+UPDATE users
+SET last_digest_at = $2
+WHERE id = $1
+`
+
+type MarkUserDigestedParams struct {
+	ID           uuid.UUID
+	LastDigestAt sql.NullTime
+}
+
+func (q *Queries) MarkUserDigested(ctx context.Context, arg MarkUserDigestedParams) error {
+	_, err := q.db.ExecContext(ctx, markUserDigested, arg.ID, arg.LastDigestAt)
+	return err
+}
+
+const setUserEmail = `-- name: SetUserEmail :one
+UPDATE users
+SET email = $2, updated_at = $3
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, email, last_digest_at, fever_api_key, feed_token_hash
+`
+
+type SetUserEmailParams struct {
+	ID        uuid.UUID
+	Email     sql.NullString
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetUserEmail(ctx context.Context, arg SetUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserEmail,
+		arg.ID,
+		arg.Email,
+		arg.UpdatedAt,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.FeverApiKey,
+		&i.FeedTokenHash,
+	)
+	return i, err
+}
+
+const setUserFeedToken = `-- name: SetUserFeedToken :exec
+UPDATE users
+SET feed_token_hash = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetUserFeedTokenParams struct {
+	ID            uuid.UUID
+	FeedTokenHash sql.NullString
+	UpdatedAt     time.Time
+}
+
+func (q *Queries) SetUserFeedToken(ctx context.Context, arg SetUserFeedTokenParams) error {
+	_, err := q.db.ExecContext(ctx, setUserFeedToken, arg.ID, arg.FeedTokenHash, arg.UpdatedAt)
+	return err
+}
@@ -0,0 +1,188 @@
+package digest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Item is a single unread post included in a digest.
+type Item struct {
+	Title       string
+	URL         string
+	Summary     string
+	Feed        string
+	Category    string
+	PublishedAt time.Time
+}
+
+// Group holds the items of one feed, in the order they were added.
+type Group struct {
+	Category string
+	Feed     string
+	Items    []Item
+}
+
+// Digest is the email sent to one user.
+type Digest struct {
+	User      string
+	From      string
+	To        string
+	Since     time.Time
+	Generated time.Time
+	Items     []Item
+}
+
+// Groups sorts the items by category and feed. Uncategorized feeds come last.
+func (d Digest) Groups() []Group {
+	var groups []Group
+	index := map[[2]string]int{}
+	for _, item := range d.Items {
+		key := [2]string{item.Category, item.Feed}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Category: item.Category, Feed: item.Feed})
+		}
+		groups[i].Items = append(groups[i].Items, item)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if (a.Category == "") != (b.Category == "") {
+			return b.Category == ""
+		}
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		return a.Feed < b.Feed
+	})
+	return groups
+}
+
+func (d Digest) Subject() string {
+	noun := "posts"
+	if len(d.Items) == 1 {
+		noun = "post"
+	}
+	return fmt.Sprintf("gator digest: %d new %s", len(d.Items), noun)
+}
+
+var textTemplate = template.Must(template.New("text").Parse(`Unread posts for {{.Digest.User}}{{if not .Digest.Since.IsZero}} since {{.Digest.Since.Format "Mon, 02 Jan 2006 15:04"}}{{end}}
+{{range .Groups}}
+== {{if .Category}}{{.Category}} / {{end}}{{.Feed}} ==
+{{range .Items}}
+* {{.Title}}
+  {{.URL}}
+{{- if .Summary}}
+  {{.Summary}}
+{{- end}}
+{{end}}{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Digest.Subject}}</title></head>
+<body style="font-family: sans-serif; max-width: 40em;">
+<p>Unread posts for {{.Digest.User}}{{if not .Digest.Since.IsZero}} since {{.Digest.Since.Format "Mon, 02 Jan 2006 15:04"}}{{end}}</p>
+{{range .Groups}}
+<h2>{{if .Category}}{{.Category}} / {{end}}{{.Feed}}</h2>
+<ul>
+{{range .Items}}<li><a href="{{.URL}}">{{.Title}}</a>{{if .Summary}}<br><small>{{.Summary}}</small>{{end}}</li>
+{{end}}</ul>
+{{end}}
+</body>
+</html>
+`))
+
+// Message renders the digest as a multipart/alternative email with a plain
+// text and an HTML part.
+func (d Digest) Message() ([]byte, error) {
+	data := struct {
+		Digest Digest
+		Groups []Group
+	}{d, d.Groups()}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("digest error rendering text: %w", err)
+	}
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("digest error rendering html: %w", err)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("digest error: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.content); err != nil {
+			return nil, fmt.Errorf("digest error: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("digest error: %w", err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("digest error: %w", err)
+	}
+
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", d.From},
+		{"To", d.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", d.Subject())},
+		{"Date", d.Generated.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(d.From)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", h[0], h[1])
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// Summarize collapses whitespace in s and cuts it to at most n runes.
+func Summarize(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
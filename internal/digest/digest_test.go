@@ -0,0 +1,217 @@
+package digest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testDigest = Digest{
+	User:      "kahya",
+	From:      "gator <gator@example.com>",
+	To:        "kahya@example.com",
+	Generated: time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC),
+	Items: []Item{
+		{Title: "Untitled <script>", URL: "https://misc.example.com/1", Feed: "Misc"},
+		{Title: "Vacuum tuning", URL: "https://db.example.com/vacuum", Feed: "DB Weekly", Category: "Work", Summary: "From the archives"},
+		{Title: "Go 1.30", URL: "https://go.dev/blog/go1.30", Feed: "Go Blog", Category: "Work"},
+		{Title: "Index bloat", URL: "https://db.example.com/bloat", Feed: "DB Weekly", Category: "Work"},
+	},
+}
+
+func TestGroups(t *testing.T) {
+	groups := testDigest.Groups()
+	var got []string
+	for _, g := range groups {
+		got = append(got, g.Category+"/"+g.Feed)
+	}
+	want := []string{"Work/DB Weekly", "Work/Go Blog", "/Misc"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+	if len(groups[0].Items) != 2 || groups[0].Items[0].Title != "Vacuum tuning" {
+		t.Errorf("DB Weekly items = %+v", groups[0].Items)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	raw, err := testDigest.Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("message does not parse: %v", err)
+	}
+	if got := msg.Header.Get("Subject"); got != "gator digest: 4 new posts" {
+		t.Errorf("Subject = %q", got)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", msg.Header.Get("Content-Type"), err)
+	}
+
+	parts := map[string]string{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[ct] = string(body)
+	}
+
+	text := parts["text/plain"]
+	if !strings.Contains(text, "== Work / DB Weekly ==") || !strings.Contains(text, "  https://go.dev/blog/go1.30") {
+		t.Errorf("unexpected text part:\n%s", text)
+	}
+	if strings.Index(text, "Misc") < strings.Index(text, "Go Blog") {
+		t.Errorf("uncategorized feeds should come last:\n%s", text)
+	}
+
+	html := parts["text/html"]
+	if !strings.Contains(html, `<a href="https://db.example.com/vacuum">Vacuum tuning</a>`) {
+		t.Errorf("unexpected html part:\n%s", html)
+	}
+	if strings.Contains(html, "<script>") {
+		t.Errorf("html part does not escape titles:\n%s", html)
+	}
+}
+
+// smtpSink is a minimal SMTP server that records the last message it got.
+func smtpSink(t *testing.T, extensions ...string) (addr string, received chan string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	received = make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+		reply("220 localhost ESMTP sink")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.Fields(line + " x")[0])
+			switch verb {
+			case "EHLO":
+				reply("250-localhost")
+				for _, ext := range extensions {
+					reply("250-" + ext)
+				}
+				reply("250 8BITMIME")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				received <- data.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return l.Addr().String(), received
+}
+
+func sinkConfig(t *testing.T, addr string) SMTPConfig {
+	host, port, _ := net.SplitHostPort(addr)
+	cfg := SMTPConfig{Host: host}
+	if _, err := fmt.Sscan(port, &cfg.Port); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestSend(t *testing.T) {
+	addr, received := smtpSink(t)
+	msg, err := testDigest.Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Send(sinkConfig(t, addr), testDigest.From, testDigest.To, msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	select {
+	case data := <-received:
+		if !strings.Contains(data, "Subject: gator digest: 4 new posts") {
+			t.Errorf("sink got unexpected message:\n%s", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sink received nothing")
+	}
+}
+
+func TestSendRequiresStartTLS(t *testing.T) {
+	addr, _ := smtpSink(t)
+	cfg := sinkConfig(t, addr)
+	cfg.StartTLS = true
+
+	err := Send(cfg, testDigest.From, testDigest.To, []byte("Subject: x\r\n\r\nx\r\n"))
+	if !errors.Is(err, ErrNoStartTLS) {
+		t.Errorf("Send() error = %v, want %v", err, ErrNoStartTLS)
+	}
+}
+
+func TestWriteMbox(t *testing.T) {
+	var buf bytes.Buffer
+	msg := []byte("Subject: x\r\n\r\nFrom here on\r\n>From quoted\r\nplain\r\n")
+	at := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	if err := WriteMbox(&buf, "gator <gator@example.com>", at, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "From gator@example.com Mon Mar  2 07:00:00 2026\nSubject: x\n\n>From here on\n>>From quoted\nplain\n\n"
+	if buf.String() != want {
+		t.Errorf("mbox = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	if got := Summarize("  a\n\tb  c ", 10); got != "a b c" {
+		t.Errorf("Summarize() = %q", got)
+	}
+	if got := Summarize("héllo wörld", 6); got != "héllo…" {
+		t.Errorf("Summarize() = %q", got)
+	}
+}
@@ -0,0 +1,129 @@
+package digest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+var ErrNoStartTLS = errors.New("server does not support STARTTLS")
+
+// SMTPConfig describes the server digests are sent through. Credentials are
+// only sent after STARTTLS unless the server is on localhost.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	StartTLS bool
+	// TLSConfig overrides the TLS settings used for STARTTLS, mainly for tests.
+	TLSConfig *tls.Config
+}
+
+// Send delivers msg from sender to recipient. Both are plain addresses or
+// "Name <address>" forms.
+func Send(cfg SMTPConfig, from, to string, msg []byte) error {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("digest error: invalid sender: %w", err)
+	}
+	toAddr, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("digest error: invalid recipient: %w", err)
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(port)), 30*time.Second)
+	if err != nil {
+		return fmt.Errorf("digest error connecting to smtp server: %w", err)
+	}
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("digest error: %w", err)
+	}
+	defer c.Close()
+
+	if cfg.StartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("digest error: %w", ErrNoStartTLS)
+		}
+		tlsConfig := cfg.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: cfg.Host}
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("digest error starting tls: %w", err)
+		}
+	}
+
+	if cfg.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted
+		// connection to anything but localhost.
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("digest error authenticating: %w", err)
+		}
+	}
+
+	if err := c.Mail(fromAddr.Address); err != nil {
+		return fmt.Errorf("digest error: %w", err)
+	}
+	if err := c.Rcpt(toAddr.Address); err != nil {
+		return fmt.Errorf("digest error: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("digest error: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("digest error: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("digest error: %w", err)
+	}
+	return c.Quit()
+}
+
+// WriteMbox appends msg to w as a single mboxrd entry.
+func WriteMbox(w io.Writer, from string, at time.Time, msg []byte) error {
+	sender := "gator"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		sender = addr.Address
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From %s %s\n", sender, at.UTC().Format(time.ANSIC))
+	scanner := bufio.NewScanner(bytes.NewReader(msg))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(msg)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))
+		if isFromLine(line) {
+			buf.WriteByte('>')
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("digest error writing mbox: %w", err)
+	}
+	return nil
+}
+
+// isFromLine reports whether line needs quoting in mboxrd, i.e. whether it
+// matches ^>*From .
+func isFromLine(line []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From "))
+}
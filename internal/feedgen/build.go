@@ -0,0 +1,258 @@
+package feedgen
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/htmltext"
+	"github.com/theandyeh/gator/internal/rules"
+	"github.com/theandyeh/gator/internal/sanitize"
+)
+
+// Store is the subset of database.Queries used to build feeds.
+type Store interface {
+	GetUser(ctx context.Context, name string) (database.User, error)
+	GetOutputPostsForUser(ctx context.Context, arg database.GetOutputPostsForUserParams) ([]database.GetOutputPostsForUserRow, error)
+}
+
+// Query selects the posts of a generated feed. Filter uses the syntax of
+// rules.ParseFilter.
+type Query struct {
+	Category string
+	Starred  bool
+	Filter   string
+	Limit    int
+}
+
+// filterScan is how many posts are read per requested post in each page
+// when a filter is set, since filters are evaluated after the query.
+const filterScan = 10
+
+// maxScanned bounds how many posts one build reads, so a filter that rarely
+// matches cannot make every request walk all posts of the user.
+const maxScanned = 5000
+
+// Build collects the posts of user selected by q, newest first.
+func Build(ctx context.Context, db Store, user database.User, q Query) (Feed, error) {
+	if q.Limit <= 0 {
+		return Feed{}, fmt.Errorf("feedgen error: limit must be positive, got %d", q.Limit)
+	}
+	var filter *rules.Matcher
+	scan := q.Limit
+	if q.Filter != "" {
+		var err error
+		if filter, err = rules.ParseFilter(q.Filter); err != nil {
+			return Feed{}, fmt.Errorf("feedgen error: %w", err)
+		}
+		scan *= filterScan
+	}
+
+	feed := Feed{
+		ID:          "urn:uuid:" + uuid.NewSHA1(user.ID, []byte(q.key())).String(),
+		Title:       q.title(user.Name),
+		Description: q.title(user.Name) + ", collected by gator",
+		Updated:     time.Now(),
+	}
+	// Pages are read until the limit is filled, the posts run out or
+	// maxScanned posts were looked at.
+	for offset := 0; len(feed.Items) < q.Limit && offset < maxScanned; offset += scan {
+		postsP := database.GetOutputPostsForUserParams{
+			UserID:      user.ID,
+			Category:    q.Category,
+			StarredOnly: q.Starred,
+			MaxPosts:    int32(min(scan, maxScanned-offset)),
+			SkipPosts:   int32(offset),
+		}
+		posts, err := db.GetOutputPostsForUser(ctx, postsP)
+		if err != nil {
+			return Feed{}, fmt.Errorf("feedgen error: %w", err)
+		}
+		for _, post := range posts {
+			if filter != nil && !filter.Matches(rules.Item{
+				Feed:        post.FeedName,
+				Title:       post.Title,
+				Description: htmltext.Text(post.Description.String),
+				Content:     htmltext.Text(post.Content.String),
+				Author:      post.Author.String,
+				Link:        post.Url,
+				Category:    post.CategoryName.String,
+				Categories:  strings.Split(post.ItemCategories, ","),
+			}) {
+				continue
+			}
+			feed.Items = append(feed.Items, outputItem(post))
+			if len(feed.Items) == q.Limit {
+				break
+			}
+		}
+		if len(posts) < scan {
+			break
+		}
+	}
+	return feed, nil
+}
+
+func outputItem(post database.GetOutputPostsForUserRow) Item {
+	item := Item{
+		ID:        post.ID,
+		Title:     post.Title,
+		Link:      post.Url,
+		Content:   sanitize.HTML(cmp.Or(post.Content.String, post.Description.String), post.Url),
+		Author:    post.Author.String,
+		Source:    post.FeedName,
+		Published: post.PublishedAt.Time,
+		Updated:   post.UpdatedAt,
+	}
+	if post.CategoryName.Valid {
+		item.Categories = append(item.Categories, post.CategoryName.String)
+	}
+	if post.Tags != "" {
+		item.Categories = append(item.Categories, strings.Split(post.Tags, ",")...)
+	}
+	if post.ItemCategories != "" {
+		item.Categories = append(item.Categories, strings.Split(post.ItemCategories, ",")...)
+	}
+	return item
+}
+
+// key identifies the selection, so each one gets its own stable feed id.
+// The limit is left out because it does not change which feed it is.
+func (q Query) key() string {
+	return fmt.Sprintf("category=%s&starred=%t&filter=%s", q.Category, q.Starred, q.Filter)
+}
+
+func (q Query) title(user string) string {
+	title := "Posts of " + user
+	if q.Starred {
+		title = "Starred posts of " + user
+	}
+	if q.Category != "" {
+		title += " in " + q.Category
+	}
+	if q.Filter != "" {
+		title += " matching " + q.Filter
+	}
+	return title
+}
+
+// extensions maps the file extension of a served feed to its format.
+var extensions = map[string]string{
+	".rss":  FormatRSS,
+	".xml":  FormatRSS,
+	".atom": FormatAtom,
+	".json": FormatJSON,
+}
+
+// Server serves the feeds of every user at /feeds/<user>.<rss|atom|json>,
+// taking category, starred, filter and limit as query parameters. Readers
+// have no way to log in, so the token query parameter has to carry the feed
+// token of the user instead. Users without a feed token have no served feeds.
+type Server struct {
+	db  Store
+	mux *http.ServeMux
+}
+
+func NewServer(db Store) *Server {
+	s := &Server{db: db, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /feeds/{file}", s.getFeed)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) getFeed(w http.ResponseWriter, r *http.Request) {
+	file := r.PathValue("file")
+	ext := path.Ext(file)
+	format, ok := extensions[ext]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	params := r.URL.Query()
+	user, err := s.db.GetUser(r.Context(), strings.TrimSuffix(file, ext))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+	// A wrong token looks like an unknown user, so names cannot be probed.
+	if !user.FeedTokenHash.Valid || subtle.ConstantTimeCompare([]byte(HashToken(params.Get("token"))), []byte(user.FeedTokenHash.String)) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	q := Query{
+		Category: params.Get("category"),
+		Starred:  params.Get("starred") == "true" || params.Get("starred") == "1",
+		Filter:   params.Get("filter"),
+		Limit:    50,
+	}
+	if raw := params.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > 500 {
+			http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		q.Limit = limit
+	}
+	if q.Filter != "" {
+		if _, err := rules.ParseFilter(q.Filter); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	feed, err := Build(r.Context(), s.db, user, q)
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	feed.Self = scheme + "://" + r.Host + r.URL.RequestURI()
+
+	w.Header().Set("Content-Type", ContentType(format))
+	if err := Write(w, format, feed); err != nil {
+		fmt.Fprintf(os.Stderr, "feedgen error: %v\n", err)
+	}
+}
+
+// NewToken returns a new random feed token. Only its hash is stored.
+func NewToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "gfd_" + hex.EncodeToString(b)
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Server) error(w http.ResponseWriter, err error) {
+	fmt.Fprintf(os.Stderr, "feedgen error: %v\n", err)
+	http.Error(w, "internal server error", http.StatusInternalServerError)
+}
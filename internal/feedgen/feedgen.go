@@ -0,0 +1,339 @@
+// Package feedgen writes posts back out as RSS 2.0, Atom or JSON Feed so
+// other readers can subscribe to what gator collected.
+package feedgen
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+var ErrUnknownFormat = errors.New("unknown feed format, expected rss, atom or json")
+
+// Feed is a generated feed. ID must stay the same between runs for Atom and
+// JSON Feed readers to recognise it.
+type Feed struct {
+	ID          string
+	Title       string
+	Description string
+	// Link is the page the feed belongs to, Self the URL of the feed itself.
+	Link    string
+	Self    string
+	Updated time.Time
+	Items   []Item
+}
+
+type Item struct {
+	ID         uuid.UUID
+	Title      string
+	Link       string
+	Content    string
+	Author     string
+	Source     string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+// GUID is the identifier of a post in every format. It is derived from the
+// post id so it does not change when the post is edited or moved.
+func GUID(id uuid.UUID) string {
+	return "urn:uuid:" + id.String()
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatRSS:
+		return "application/rss+xml; charset=utf-8"
+	case FormatAtom:
+		return "application/atom+xml; charset=utf-8"
+	case FormatJSON:
+		return "application/feed+json; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+func Write(w io.Writer, format string, f Feed) error {
+	switch format {
+	case FormatRSS:
+		return WriteRSS(w, f)
+	case FormatAtom:
+		return WriteAtom(w, f)
+	case FormatJSON:
+		return WriteJSON(w, f)
+	}
+	return fmt.Errorf("feedgen error: %w: %q", ErrUnknownFormat, format)
+}
+
+// published falls back to the update time for posts without a date.
+func (i Item) published() time.Time {
+	if i.Published.IsZero() {
+		return i.Updated
+	}
+	return i.Published
+}
+
+func (i Item) updated() time.Time {
+	if i.Updated.Before(i.published()) {
+		return i.published()
+	}
+	return i.Updated
+}
+
+// updated is the newest item change, or f.Updated for an empty feed.
+func (f Feed) updated() time.Time {
+	var latest time.Time
+	for _, item := range f.Items {
+		if u := item.updated(); u.After(latest) {
+			latest = u
+		}
+	}
+	if latest.IsZero() {
+		return f.Updated
+	}
+	return latest
+}
+
+func (f Feed) link() string {
+	if f.Link == "" {
+		return f.Self
+	}
+	return f.Link
+}
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          *atomLink `xml:"atom:link,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	Author      string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+// WriteRSS writes f as RSS 2.0. Authors go in dc:creator because the RSS
+// author element must be an email address.
+func WriteRSS(w io.Writer, f Feed) error {
+	doc := rssDoc{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.link(),
+			Description:   f.Description,
+			LastBuildDate: f.updated().UTC().Format(time.RFC1123Z),
+			Generator:     "gator",
+		},
+	}
+	if f.Self != "" {
+		doc.Channel.Self = &atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"}
+	}
+	for _, item := range f.Items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Content,
+			Author:      item.Author,
+			Categories:  item.Categories,
+			GUID:        rssGUID{Value: GUID(item.ID)},
+			PubDate:     item.published().UTC().Format(time.RFC1123Z),
+		})
+	}
+	return writeXML(w, doc)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  *atomPerson `xml:"author,omitempty"`
+	Sub     string      `xml:"subtitle,omitempty"`
+	Gen     string      `xml:"generator"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Links      []atomLink     `xml:"link"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Source     *atomSource    `xml:"source,omitempty"`
+}
+
+type atomSource struct {
+	Title string `xml:"title"`
+}
+
+// WriteAtom writes f as Atom 1.0. Atom requires an author for every entry,
+// so the feed itself is credited to gator for entries without one.
+func WriteAtom(w io.Writer, f Feed) error {
+	feed := atomFeed{
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: f.updated().UTC().Format(time.RFC3339),
+		Author:  &atomPerson{Name: "gator"},
+		Sub:     f.Description,
+		Gen:     "gator",
+	}
+	if link := f.link(); link != "" {
+		feed.Links = append(feed.Links, atomLink{Href: link, Rel: "alternate"})
+	}
+	if f.Self != "" {
+		feed.Links = append(feed.Links, atomLink{Href: f.Self, Rel: "self", Type: "application/atom+xml"})
+	}
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:        GUID(item.ID),
+			Title:     item.Title,
+			Updated:   item.updated().UTC().Format(time.RFC3339),
+			Published: item.published().UTC().Format(time.RFC3339),
+		}
+		if item.Link != "" {
+			entry.Links = []atomLink{{Href: item.Link, Rel: "alternate"}}
+		}
+		if item.Author != "" {
+			entry.Author = &atomPerson{Name: item.Author}
+		}
+		for _, c := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		if item.Source != "" {
+			entry.Source = &atomSource{Title: item.Source}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return writeXML(w, feed)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("feedgen error: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	HomePageURL string     `json:"home_page_url,omitempty"`
+	FeedURL     string     `json:"feed_url,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url,omitempty"`
+	Title         string       `json:"title"`
+	ContentHTML   string       `json:"content_html"`
+	DatePublished string       `json:"date_published"`
+	DateModified  string       `json:"date_modified"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+// WriteJSON writes f as JSON Feed 1.1.
+func WriteJSON(w io.Writer, f Feed) error {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.Self,
+		Description: f.Description,
+		Items:       []jsonItem{},
+	}
+	for _, item := range f.Items {
+		ji := jsonItem{
+			ID:            GUID(item.ID),
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.Content,
+			DatePublished: item.published().UTC().Format(time.RFC3339),
+			DateModified:  item.updated().UTC().Format(time.RFC3339),
+			Tags:          item.Categories,
+		}
+		if item.Author != "" {
+			ji.Authors = []jsonAuthor{{Name: item.Author}}
+		}
+		feed.Items = append(feed.Items, ji)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return fmt.Errorf("feedgen error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package feedgen
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/database"
+)
+
+var (
+	postID    = uuid.MustParse("6f1c1f0a-4c3e-4d2b-9a55-2f5d3b8d9e01")
+	published = time.Date(2026, 2, 3, 10, 30, 0, 0, time.UTC)
+)
+
+func testFeed() Feed {
+	return Feed{
+		ID:    "urn:uuid:00000000-0000-0000-0000-000000000001",
+		Title: "Team reading list",
+		Self:  "https://gator.example.com/feeds/kahya.atom",
+		Items: []Item{{
+			ID:         postID,
+			Title:      "Go 1.26 is released",
+			Link:       "https://go.dev/blog/go1.26",
+			Content:    "<p>Today the Go team is <b>happy</b> to announce</p>",
+			Author:     "The Go Team",
+			Categories: []string{"Work", "golang"},
+			Published:  published,
+			Updated:    published.Add(time.Hour),
+		}},
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteRSS(&buf, testFeed()); err != nil {
+		t.Fatalf("WriteRSS failed: %v", err)
+	}
+
+	var doc struct {
+		Channel struct {
+			Links []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:"link"`
+			Items []struct {
+				GUID struct {
+					IsPermaLink string `xml:"isPermaLink,attr"`
+					Value       string `xml:",chardata"`
+				} `xml:"guid"`
+				PubDate     string   `xml:"pubDate"`
+				Description string   `xml:"description"`
+				Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Categories  []string `xml:"category"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid RSS: %v\n%s", err, buf.String())
+	}
+	if link := doc.Channel.Links[0]; link.XMLName.Space != "" || link.Value != "https://gator.example.com/feeds/kahya.atom" {
+		t.Errorf("Expected channel link to fall back to the self link, got %+v", link)
+	}
+	item := doc.Channel.Items[0]
+	if item.GUID.Value != "urn:uuid:"+postID.String() || item.GUID.IsPermaLink != "false" {
+		t.Errorf("Unexpected guid %+v", item.GUID)
+	}
+	if item.PubDate != "Tue, 03 Feb 2026 10:30:00 +0000" {
+		t.Errorf("Unexpected pubDate %q", item.PubDate)
+	}
+	if item.Description != testFeed().Items[0].Content {
+		t.Errorf("Content was not preserved: %q", item.Description)
+	}
+	if item.Creator != "The Go Team" || len(item.Categories) != 2 {
+		t.Errorf("Unexpected author or categories: %q %v", item.Creator, item.Categories)
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteAtom(&buf, testFeed()); err != nil {
+		t.Fatalf("WriteAtom failed: %v", err)
+	}
+
+	var doc struct {
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Updated   string `xml:"updated"`
+			Published string `xml:"published"`
+			Content   struct {
+				Type string `xml:"type,attr"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid Atom: %v\n%s", err, buf.String())
+	}
+	if doc.ID != testFeed().ID {
+		t.Errorf("Unexpected feed id %q", doc.ID)
+	}
+	if doc.Updated != "2026-02-03T11:30:00Z" {
+		t.Errorf("Expected feed updated to be the newest entry, got %q", doc.Updated)
+	}
+	entry := doc.Entries[0]
+	if entry.ID != "urn:uuid:"+postID.String() || entry.Published != "2026-02-03T10:30:00Z" || entry.Content.Type != "html" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	f := testFeed()
+	f.Items[0].Published = time.Time{}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, f); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	var doc struct {
+		Version string `json:"version"`
+		Items   []struct {
+			ID            string   `json:"id"`
+			DatePublished string   `json:"date_published"`
+			Tags          []string `json:"tags"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid JSON Feed: %v", err)
+	}
+	if doc.Version != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("Unexpected version %q", doc.Version)
+	}
+	// Posts without a date fall back to when they were last updated.
+	if doc.Items[0].DatePublished != "2026-02-03T11:30:00Z" {
+		t.Errorf("Unexpected date_published %q", doc.Items[0].DatePublished)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "opml", testFeed()); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+type fakeStore struct {
+	user    database.User
+	posts   []database.GetOutputPostsForUserRow
+	last    database.GetOutputPostsForUserParams
+	queries int
+}
+
+func (f *fakeStore) GetUser(ctx context.Context, name string) (database.User, error) {
+	if name != f.user.Name {
+		return database.User{}, sql.ErrNoRows
+	}
+	return f.user, nil
+}
+
+func (f *fakeStore) GetOutputPostsForUser(ctx context.Context, arg database.GetOutputPostsForUserParams) ([]database.GetOutputPostsForUserRow, error) {
+	f.last = arg
+	f.queries++
+	start := min(int(arg.SkipPosts), len(f.posts))
+	end := min(start+int(arg.MaxPosts), len(f.posts))
+	return f.posts[start:end], nil
+}
+
+const testToken = "gfd_secret"
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		user: database.User{ID: uuid.New(), Name: "kahya", FeedTokenHash: sql.NullString{String: HashToken(testToken), Valid: true}},
+		posts: []database.GetOutputPostsForUserRow{
+			{ID: postID, Title: "Go 1.26 is released", Url: "https://go.dev/blog/go1.26", FeedName: "Go Blog", Tags: "golang,release", PublishedAt: sql.NullTime{Time: published, Valid: true}},
+			{ID: uuid.New(), Title: "Sponsored: a database", Url: "https://example.com/ad", FeedName: "Weekly"},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	store := newFakeStore()
+
+	feed, err := Build(context.Background(), store, store.user, Query{Filter: "title contains go 1.26", Limit: 5})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(feed.Items) != 1 || feed.Items[0].ID != postID {
+		t.Fatalf("Expected only the matching post, got %+v", feed.Items)
+	}
+	if got := feed.Items[0].Categories; len(got) != 2 || got[1] != "release" {
+		t.Errorf("Expected tags as categories, got %v", got)
+	}
+	if store.last.MaxPosts != 5*filterScan {
+		t.Errorf("Expected filtered builds to scan more posts, got %d", store.last.MaxPosts)
+	}
+
+	again, err := Build(context.Background(), store, store.user, Query{Filter: "title contains go 1.26", Limit: 20})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if again.ID != feed.ID {
+		t.Error("Feed id should not depend on the limit")
+	}
+	starred, _ := Build(context.Background(), store, store.user, Query{Starred: true, Limit: 5})
+	if starred.ID == feed.ID {
+		t.Error("Different selections should have different feed ids")
+	}
+
+	if _, err := Build(context.Background(), store, store.user, Query{Filter: "body contains x", Limit: 5}); err == nil {
+		t.Error("Expected an error for an invalid filter")
+	}
+}
+
+func TestBuildPagesUntilLimit(t *testing.T) {
+	store := newFakeStore()
+	store.posts = nil
+	for i := range 25 {
+		store.posts = append(store.posts, database.GetOutputPostsForUserRow{ID: uuid.New(), Title: fmt.Sprintf("Noise %d", i)})
+	}
+	for i := range 3 {
+		store.posts = append(store.posts, database.GetOutputPostsForUserRow{ID: uuid.New(), Title: fmt.Sprintf("Match %d", i)})
+	}
+
+	feed, err := Build(context.Background(), store, store.user, Query{Filter: "title contains match", Limit: 2})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(feed.Items) != 2 || feed.Items[0].Title != "Match 0" {
+		t.Errorf("Expected the first 2 matching posts, got %+v", feed.Items)
+	}
+	if store.queries != 2 {
+		t.Errorf("Expected 2 pages to be read, got %d", store.queries)
+	}
+}
+
+func TestBuildStopsScanning(t *testing.T) {
+	store := newFakeStore()
+	store.posts = nil
+	for i := range maxScanned + 100 {
+		store.posts = append(store.posts, database.GetOutputPostsForUserRow{ID: uuid.New(), Title: fmt.Sprintf("Noise %d", i)})
+	}
+
+	feed, err := Build(context.Background(), store, store.user, Query{Filter: "title contains match", Limit: 30})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(feed.Items) != 0 {
+		t.Errorf("Expected no items, got %d", len(feed.Items))
+	}
+	if scanned := int(store.last.SkipPosts + store.last.MaxPosts); scanned != maxScanned {
+		t.Errorf("Expected to stop after %d posts, read %d", maxScanned, scanned)
+	}
+}
+
+func TestServerWithoutFeedToken(t *testing.T) {
+	store := newFakeStore()
+	store.user.FeedTokenHash = sql.NullString{}
+	srv := NewServer(store)
+
+	for _, path := range []string{"/feeds/kahya.rss", "/feeds/kahya.rss?token=", "/feeds/kahya.rss?token=" + testToken} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404 without a feed token, got %d", path, rec.Code)
+		}
+	}
+	if store.queries != 0 {
+		t.Errorf("Expected no posts to be read, got %d queries", store.queries)
+	}
+}
+
+func TestServer(t *testing.T) {
+	store := newFakeStore()
+	srv := NewServer(store)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feeds/kahya.atom?category=Work&starred=1&token="+testToken, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	if !store.last.StarredOnly || store.last.Category != "Work" {
+		t.Errorf("Query parameters were not applied: %+v", store.last)
+	}
+	if !strings.Contains(rec.Body.String(), `href="http://example.com/feeds/kahya.atom?category=Work&amp;starred=1&amp;token=`+testToken+`" rel="self"`) {
+		t.Errorf("Missing self link:\n%s", rec.Body)
+	}
+
+	for path, status := range map[string]int{
+		"/feeds/nobody.rss?token=" + testToken:           http.StatusNotFound,
+		"/feeds/kahya.opml?token=" + testToken:           http.StatusNotFound,
+		"/feeds/kahya.rss":                               http.StatusNotFound,
+		"/feeds/kahya.rss?token=gfd_guess":               http.StatusNotFound,
+		"/feeds/kahya.json?limit=0&token=" + testToken:   http.StatusBadRequest,
+		"/feeds/kahya.json?filter=xx&token=" + testToken: http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != status {
+			t.Errorf("%s: expected %d, got %d", path, status, rec.Code)
+		}
+	}
+}
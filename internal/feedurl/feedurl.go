@@ -0,0 +1,104 @@
+package feedurl
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that only identify where a reader came
+// from and never change the feed being served.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalize returns the canonical form of a feed URL so that trivially
+// different spellings of the same feed compare equal. The scheme is kept
+// since some feeds are only served over http, the host is lowercased,
+// default ports, trailing slashes, fragments and tracking parameters are
+// dropped and the remaining query parameters are sorted. Use SameFeed to
+// compare urls regardless of scheme.
+func Normalize(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("feedurl normalize error: %w", err)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("feedurl normalize error: %q is not an absolute url", raw)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port := u.Port()
+	if port == defaultPorts[scheme] {
+		port = ""
+	}
+
+	u.Scheme = scheme
+	u.Host = host
+	if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	}
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+// SameFeed reports whether two normalized urls name the same feed, treating
+// the http and https spellings as one. Stores compare urls the same way.
+func SameFeed(a, b string) bool {
+	return Key(a) == Key(b)
+}
+
+// Key returns u without its scheme, for grouping the http and https
+// spellings of a feed.
+func Key(u string) string {
+	if rest, ok := strings.CutPrefix(u, "https://"); ok {
+		return rest
+	}
+	if rest, ok := strings.CutPrefix(u, "http://"); ok {
+		return rest
+	}
+	return u
+}
+
+// Prefer picks the url to keep of two spellings of the same feed, which is
+// the https one when only one of them uses https.
+func Prefer(a, b string) string {
+	if strings.HasPrefix(b, "https://") && !strings.HasPrefix(a, "https://") {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,58 @@
+package feedurl
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"http://x.com/feed", "http://x.com/feed"},
+		{"https://x.com/feed/", "https://x.com/feed"},
+		{"https://X.com/feed?utm_source=a", "https://x.com/feed"},
+		{"https://x.com:443/feed", "https://x.com/feed"},
+		{"http://x.com:80/feed", "http://x.com/feed"},
+		{"http://x.com:8080/feed", "http://x.com:8080/feed"},
+		{"https://x.com/", "https://x.com"},
+		{"https://x.com/feed#top", "https://x.com/feed"},
+		{"https://x.com/feed?b=2&a=1&fbclid=abc", "https://x.com/feed?a=1&b=2"},
+		{"  HTTPS://Blog.Example.COM./index.xml  ", "https://blog.example.com/index.xml"},
+		{"HTTP://[::1]:80/rss", "http://[::1]/rss"},
+	}
+
+	for _, tt := range tests {
+		got, err := Normalize(tt.input)
+		if err != nil {
+			t.Errorf("Normalize(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Normalize(%q): expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	inputs := []string{"", "/just/a/path", "x.com/feed", "://broken"}
+
+	for _, input := range inputs {
+		if _, err := Normalize(input); err == nil {
+			t.Errorf("Expected error normalizing %q", input)
+		}
+	}
+}
+
+func TestSameFeed(t *testing.T) {
+	if !SameFeed("http://x.com/feed", "https://x.com/feed") {
+		t.Error("http and https spellings should be the same feed")
+	}
+	if SameFeed("https://x.com/feed", "https://x.com/other") {
+		t.Error("different paths should not be the same feed")
+	}
+	if got := Prefer("http://x.com/feed", "https://x.com/feed"); got != "https://x.com/feed" {
+		t.Errorf("Prefer = %q, want the https url", got)
+	}
+	if got := Prefer("https://x.com/feed", "http://x.com/feed"); got != "https://x.com/feed" {
+		t.Errorf("Prefer = %q, want the https url", got)
+	}
+}
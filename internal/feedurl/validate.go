@@ -0,0 +1,93 @@
+package feedurl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrMalformed         = errors.New("malformed url")
+	ErrUnsupportedScheme = errors.New("unsupported scheme, only http and https are allowed")
+	ErrMissingHost       = errors.New("missing host")
+	ErrUnqualifiedHost   = errors.New("host is not a fully qualified domain name")
+	ErrPrivateAddress    = errors.New("host points to a private, loopback or link-local address")
+	ErrLocalHostname     = errors.New("host is a local network name")
+	ErrCredentialsInURL  = errors.New("urls with embedded credentials are not allowed")
+)
+
+// localSuffixes are DNS suffixes reserved for hosts on the local network.
+var localSuffixes = []string{
+	".localhost",
+	".local",
+	".internal",
+	".lan",
+	".home.arpa",
+}
+
+// Validate reports why raw cannot be used as a feed url. Only absolute http
+// and https urls with a host are accepted. Unless allowPrivate is set, hosts
+// that are loopback, private or link-local addresses, local network names or
+// single label names are rejected as well.
+func Validate(raw string, allowPrivate bool) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return fmt.Errorf("feedurl validate error: %w: %v", ErrMalformed, err)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return fmt.Errorf("feedurl validate error: %w: %q", ErrUnsupportedScheme, u.Scheme)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("feedurl validate error: %w in %q", ErrMissingHost, raw)
+	}
+
+	if u.User != nil {
+		return fmt.Errorf("feedurl validate error: %w", ErrCredentialsInURL)
+	}
+
+	if allowPrivate {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if IsPrivateIP(ip) {
+			return fmt.Errorf("feedurl validate error: %w: %s", ErrPrivateAddress, host)
+		}
+		return nil
+	}
+
+	if host == "localhost" {
+		return fmt.Errorf("feedurl validate error: %w: %s", ErrLocalHostname, host)
+	}
+	for _, suffix := range localSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return fmt.Errorf("feedurl validate error: %w: %s", ErrLocalHostname, host)
+		}
+	}
+
+	if !strings.Contains(host, ".") {
+		return fmt.Errorf("feedurl validate error: %w: %s", ErrUnqualifiedHost, host)
+	}
+
+	return nil
+}
+
+// IsPrivateIP reports whether ip is not reachable on the public internet:
+// loopback, RFC 1918 / RFC 4193 private, link-local, carrier-grade NAT,
+// unspecified or multicast addresses.
+func IsPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+
+	_, cgnat, _ := net.ParseCIDR("100.64.0.0/10")
+	return cgnat.Contains(ip)
+}
@@ -0,0 +1,398 @@
+// Package fever implements the Fever API used by mobile RSS clients such as
+// Reeder and NetNewsWire. Clients log in with the gator user name as email
+// and the password set with the fever command.
+package fever
+
+import (
+	"cmp"
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/sanitize"
+)
+
+const apiVersion = 3
+
+// Store is the subset of database.Queries the Fever API uses.
+type Store interface {
+	GetUserByFeverKey(ctx context.Context, feverApiKey sql.NullString) (database.User, error)
+	GetFeverGroups(ctx context.Context, userID uuid.UUID) ([]database.GetFeverGroupsRow, error)
+	GetFeverFeeds(ctx context.Context, userID uuid.UUID) ([]database.GetFeverFeedsRow, error)
+	GetFeverItems(ctx context.Context, arg database.GetFeverItemsParams) ([]database.GetFeverItemsRow, error)
+	CountFeverItems(ctx context.Context, userID uuid.UUID) (int64, error)
+	GetFeverUnreadItemIDs(ctx context.Context, userID uuid.UUID) ([]int64, error)
+	GetFeverSavedItemIDs(ctx context.Context, userID uuid.UUID) ([]int64, error)
+	GetFeverPostID(ctx context.Context, arg database.GetFeverPostIDParams) (uuid.UUID, error)
+	MarkFeverFeedRead(ctx context.Context, arg database.MarkFeverFeedReadParams) (int64, error)
+	MarkFeverGroupRead(ctx context.Context, arg database.MarkFeverGroupReadParams) (int64, error)
+	MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error
+	MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) (int64, error)
+	CreatePostStar(ctx context.Context, arg database.CreatePostStarParams) error
+	DeletePostStar(ctx context.Context, arg database.DeletePostStarParams) (int64, error)
+}
+
+// APIKey returns the key Fever clients send for a user, the hex md5 of
+// "email:password".
+func APIKey(user, password string) string {
+	sum := md5.Sum([]byte(user + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+type Server struct {
+	db Store
+}
+
+func New(db Store) *Server {
+	return &Server{db: db}
+}
+
+type group struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type item struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// ServeHTTP answers a Fever request. Fever reports authentication failures
+// in the body with auth 0 rather than with a status code.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	if _, ok := r.Form["api"]; !ok {
+		http.Error(w, "not a fever api request", http.StatusBadRequest)
+		return
+	}
+
+	resp := map[string]any{"api_version": apiVersion, "auth": 0}
+	key := strings.ToLower(r.PostFormValue("api_key"))
+	if key == "" {
+		key = strings.ToLower(r.Form.Get("api_key"))
+	}
+	user, err := s.db.GetUserByFeverKey(r.Context(), sql.NullString{String: key, Valid: key != ""})
+	if errors.Is(err, sql.ErrNoRows) || key == "" {
+		writeJSON(w, resp)
+		return
+	}
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	resp["auth"] = 1
+
+	if err := s.respond(r, user, resp); err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) respond(r *http.Request, user database.User, resp map[string]any) error {
+	ctx := r.Context()
+	has := func(name string) bool {
+		_, ok := r.Form[name]
+		return ok
+	}
+
+	// Writes come first so the reads below reflect them.
+	if has("mark") {
+		if err := s.mark(ctx, user, r.Form.Get("mark"), r.Form.Get("as"), r.Form.Get("id"), r.Form.Get("before")); err != nil {
+			return err
+		}
+	}
+
+	feeds, err := s.db.GetFeverFeeds(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	var lastRefreshed time.Time
+	for _, f := range feeds {
+		if f.LastFetchedAt.Valid && f.LastFetchedAt.Time.After(lastRefreshed) {
+			lastRefreshed = f.LastFetchedAt.Time
+		}
+	}
+	resp["last_refreshed_on_time"] = unix(lastRefreshed)
+
+	if has("groups") {
+		groups, err := s.db.GetFeverGroups(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		out := make([]group, 0, len(groups))
+		for _, g := range groups {
+			out = append(out, group{ID: g.Seq, Title: g.Name})
+		}
+		resp["groups"] = out
+		resp["feeds_groups"] = feedsGroups(feeds)
+	}
+
+	if has("feeds") {
+		out := make([]feed, 0, len(feeds))
+		for _, f := range feeds {
+			out = append(out, feed{
+				ID:                f.Seq,
+				Title:             f.Title,
+				URL:               f.Url,
+				LastUpdatedOnTime: unix(f.LastFetchedAt.Time),
+			})
+		}
+		resp["feeds"] = out
+		resp["feeds_groups"] = feedsGroups(feeds)
+	}
+
+	if has("favicons") {
+		resp["favicons"] = []any{}
+	}
+	if has("links") {
+		resp["links"] = []any{}
+	}
+
+	if has("items") {
+		if err := s.items(ctx, r, user, resp); err != nil {
+			return err
+		}
+	}
+
+	if has("unread_item_ids") {
+		ids, err := s.db.GetFeverUnreadItemIDs(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		resp["unread_item_ids"] = joinIDs(ids)
+	}
+	if has("saved_item_ids") {
+		ids, err := s.db.GetFeverSavedItemIDs(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		resp["saved_item_ids"] = joinIDs(ids)
+	}
+	return nil
+}
+
+func (s *Server) items(ctx context.Context, r *http.Request, user database.User, resp map[string]any) error {
+	itemsP := database.GetFeverItemsParams{UserID: user.ID}
+	if v := r.Form.Get("with_ids"); v != "" {
+		ids, err := parseIDs(v)
+		if err != nil {
+			return errBadRequest
+		}
+		if len(ids) > 50 {
+			ids = ids[:50]
+		}
+		itemsP.WithIds = joinIDs(ids)
+	}
+	for name, dst := range map[string]*int64{"since_id": &itemsP.SinceID, "max_id": &itemsP.MaxID} {
+		if v := r.Form.Get(name); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || id < 0 {
+				return errBadRequest
+			}
+			*dst = id
+		}
+	}
+
+	rows, err := s.db.GetFeverItems(ctx, itemsP)
+	if err != nil {
+		return err
+	}
+	total, err := s.db.CountFeverItems(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	out := make([]item, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, item{
+			ID:            row.Seq,
+			FeedID:        row.FeedSeq,
+			Title:         row.Title,
+			Author:        row.Author.String,
+			HTML:          sanitize.HTML(cmp.Or(row.Content.String, row.Description.String), row.Url),
+			URL:           row.Url,
+			IsSaved:       boolInt(row.IsSaved),
+			IsRead:        boolInt(row.IsRead),
+			CreatedOnTime: unix(row.CreatedOn),
+		})
+	}
+	resp["items"] = out
+	resp["total_items"] = total
+	return nil
+}
+
+var errBadRequest = errors.New("invalid fever request")
+
+func (s *Server) mark(ctx context.Context, user database.User, kind, as, rawID, rawBefore string) error {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return errBadRequest
+	}
+
+	switch kind {
+	case "item":
+		postID, err := s.db.GetFeverPostID(ctx, database.GetFeverPostIDParams{Seq: id, UserID: user.ID})
+		if errors.Is(err, sql.ErrNoRows) {
+			// Clients retry marks they think failed, so unknown items are
+			// ignored instead of breaking their sync.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch as {
+		case "read":
+			return s.db.MarkPostRead(ctx, database.MarkPostReadParams{UserID: user.ID, PostID: postID, ReadAt: time.Now()})
+		case "unread":
+			_, err = s.db.MarkPostUnread(ctx, database.MarkPostUnreadParams{UserID: user.ID, PostID: postID})
+		case "saved":
+			err = s.db.CreatePostStar(ctx, database.CreatePostStarParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				PostID:    postID,
+				UserID:    user.ID,
+			})
+		case "unsaved":
+			_, err = s.db.DeletePostStar(ctx, database.DeletePostStarParams{PostID: postID, UserID: user.ID})
+		default:
+			return errBadRequest
+		}
+		return err
+
+	case "feed", "group":
+		if as != "read" {
+			return errBadRequest
+		}
+		// before is the time the client last refreshed, so items that
+		// arrived after it stay unread.
+		before := time.Now()
+		if rawBefore != "" {
+			ts, err := strconv.ParseInt(rawBefore, 10, 64)
+			if err != nil {
+				return errBadRequest
+			}
+			before = time.Unix(ts, 0)
+		}
+		if kind == "feed" {
+			_, err = s.db.MarkFeverFeedRead(ctx, database.MarkFeverFeedReadParams{ReadAt: time.Now(), UserID: user.ID, FeedSeq: id, Before: before})
+		} else {
+			_, err = s.db.MarkFeverGroupRead(ctx, database.MarkFeverGroupReadParams{ReadAt: time.Now(), UserID: user.ID, GroupSeq: id, Before: before})
+		}
+		return err
+	}
+	return errBadRequest
+}
+
+func (s *Server) fail(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBadRequest) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "fever error: %v\n", err)
+	http.Error(w, "internal server error", http.StatusInternalServerError)
+}
+
+func feedsGroups(feeds []database.GetFeverFeedsRow) []feedsGroup {
+	var out []feedsGroup
+	index := map[int64]int{}
+	for _, f := range feeds {
+		if !f.GroupSeq.Valid {
+			continue
+		}
+		i, ok := index[f.GroupSeq.Int64]
+		if !ok {
+			i = len(out)
+			index[f.GroupSeq.Int64] = i
+			out = append(out, feedsGroup{GroupID: f.GroupSeq.Int64})
+		}
+		if out[i].FeedIDs != "" {
+			out[i].FeedIDs += ","
+		}
+		out[i].FeedIDs += strconv.FormatInt(f.Seq, 10)
+	}
+	if out == nil {
+		out = []feedsGroup{}
+	}
+	return out
+}
+
+func parseIDs(s string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func joinIDs(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,292 @@
+package fever
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/database"
+)
+
+// fakeStore keeps one user with two feeds, one of them in a group.
+type fakeStore struct {
+	user    database.User
+	feeds   []database.GetFeverFeedsRow
+	items   []database.GetFeverItemsRow
+	postIDs map[int64]uuid.UUID
+	// stored is when each item was fetched, which can be long after it
+	// was published.
+	stored map[int64]time.Time
+}
+
+func newFakeStore() *fakeStore {
+	fetched := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{
+		user: database.User{ID: uuid.New(), Name: "kahya", FeverApiKey: sql.NullString{String: APIKey("kahya", "secret"), Valid: true}},
+		feeds: []database.GetFeverFeedsRow{
+			{Seq: 1, Title: "Go Blog", Url: "https://go.dev/blog/feed.atom", LastFetchedAt: sql.NullTime{Time: fetched, Valid: true}, GroupSeq: sql.NullInt64{Int64: 7, Valid: true}},
+			{Seq: 2, Title: "Hacker News", Url: "https://news.ycombinator.com/rss"},
+		},
+		postIDs: map[int64]uuid.UUID{},
+		stored:  map[int64]time.Time{},
+	}
+	for i := int64(1); i <= 3; i++ {
+		store.items = append(store.items, database.GetFeverItemsRow{
+			Seq:       i,
+			FeedSeq:   1,
+			Title:     "Post",
+			Url:       "https://go.dev/blog/post",
+			CreatedOn: fetched.Add(-time.Duration(i) * time.Hour),
+		})
+		store.postIDs[i] = uuid.New()
+		store.stored[i] = fetched.Add(-time.Duration(i) * 30 * time.Minute)
+	}
+	return store
+}
+
+func (f *fakeStore) item(seq int64) *database.GetFeverItemsRow {
+	for i := range f.items {
+		if f.items[i].Seq == seq {
+			return &f.items[i]
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) seqOf(postID uuid.UUID) int64 {
+	for seq, id := range f.postIDs {
+		if id == postID {
+			return seq
+		}
+	}
+	return 0
+}
+
+func (f *fakeStore) GetUserByFeverKey(ctx context.Context, key sql.NullString) (database.User, error) {
+	if key != f.user.FeverApiKey {
+		return database.User{}, sql.ErrNoRows
+	}
+	return f.user, nil
+}
+
+func (f *fakeStore) GetFeverGroups(ctx context.Context, userID uuid.UUID) ([]database.GetFeverGroupsRow, error) {
+	return []database.GetFeverGroupsRow{{Seq: 7, Name: "Programming"}}, nil
+}
+
+func (f *fakeStore) GetFeverFeeds(ctx context.Context, userID uuid.UUID) ([]database.GetFeverFeedsRow, error) {
+	return f.feeds, nil
+}
+
+func (f *fakeStore) GetFeverItems(ctx context.Context, arg database.GetFeverItemsParams) ([]database.GetFeverItemsRow, error) {
+	var rows []database.GetFeverItemsRow
+	for _, it := range f.items {
+		if arg.WithIds != "" && !strings.Contains(","+arg.WithIds+",", ","+strconv.FormatInt(it.Seq, 10)+",") {
+			continue
+		}
+		if it.Seq <= arg.SinceID || (arg.MaxID > 0 && it.Seq >= arg.MaxID) {
+			continue
+		}
+		rows = append(rows, it)
+	}
+	return rows, nil
+}
+
+func (f *fakeStore) CountFeverItems(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return int64(len(f.items)), nil
+}
+
+func (f *fakeStore) GetFeverUnreadItemIDs(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	var ids []int64
+	for _, it := range f.items {
+		if !it.IsRead {
+			ids = append(ids, it.Seq)
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeStore) GetFeverSavedItemIDs(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	var ids []int64
+	for _, it := range f.items {
+		if it.IsSaved {
+			ids = append(ids, it.Seq)
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeStore) GetFeverPostID(ctx context.Context, arg database.GetFeverPostIDParams) (uuid.UUID, error) {
+	id, ok := f.postIDs[arg.Seq]
+	if !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+	return id, nil
+}
+
+func (f *fakeStore) MarkFeverFeedRead(ctx context.Context, arg database.MarkFeverFeedReadParams) (int64, error) {
+	var n int64
+	for i := range f.items {
+		if f.items[i].FeedSeq == arg.FeedSeq && f.stored[f.items[i].Seq].Before(arg.Before) && !f.items[i].IsRead {
+			f.items[i].IsRead = true
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeStore) MarkFeverGroupRead(ctx context.Context, arg database.MarkFeverGroupReadParams) (int64, error) {
+	var n int64
+	for i := range f.items {
+		if f.stored[f.items[i].Seq].Before(arg.Before) && !f.items[i].IsRead {
+			f.items[i].IsRead = true
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeStore) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	f.item(f.seqOf(arg.PostID)).IsRead = true
+	return nil
+}
+
+func (f *fakeStore) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) (int64, error) {
+	f.item(f.seqOf(arg.PostID)).IsRead = false
+	return 1, nil
+}
+
+func (f *fakeStore) CreatePostStar(ctx context.Context, arg database.CreatePostStarParams) error {
+	f.item(f.seqOf(arg.PostID)).IsSaved = true
+	return nil
+}
+
+func (f *fakeStore) DeletePostStar(ctx context.Context, arg database.DeletePostStarParams) (int64, error) {
+	f.item(f.seqOf(arg.PostID)).IsSaved = false
+	return 1, nil
+}
+
+// call sends a request shaped like the ones Reeder sends: the api key as a
+// form field and the sections as query parameters.
+func call(t *testing.T, srv *Server, query string, form url.Values) map[string]any {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/fever/?api&"+query, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: expected status 200, got %d: %s", query, rec.Code, rec.Body)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s: invalid json: %v", query, err)
+	}
+	return body
+}
+
+func authed(extra ...string) url.Values {
+	form := url.Values{"api_key": {APIKey("kahya", "secret")}}
+	for i := 0; i+1 < len(extra); i += 2 {
+		form.Set(extra[i], extra[i+1])
+	}
+	return form
+}
+
+func TestAuth(t *testing.T) {
+	srv := New(newFakeStore())
+
+	body := call(t, srv, "", url.Values{"api_key": {APIKey("kahya", "wrong")}})
+	if body["auth"] != 0.0 || body["api_version"] != 3.0 {
+		t.Errorf("Expected auth 0 for a wrong key, got %v", body)
+	}
+	if _, ok := body["last_refreshed_on_time"]; ok {
+		t.Error("Unauthenticated response should not include last_refreshed_on_time")
+	}
+
+	body = call(t, srv, "", authed())
+	if body["auth"] != 1.0 {
+		t.Errorf("Expected auth 1, got %v", body)
+	}
+	if body["last_refreshed_on_time"] != float64(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC).Unix()) {
+		t.Errorf("Unexpected last_refreshed_on_time %v", body["last_refreshed_on_time"])
+	}
+}
+
+func TestGroupsAndFeeds(t *testing.T) {
+	srv := New(newFakeStore())
+
+	body := call(t, srv, "groups&feeds", authed())
+	feeds := body["feeds"].([]any)
+	if len(feeds) != 2 {
+		t.Fatalf("Expected 2 feeds, got %d", len(feeds))
+	}
+	first := feeds[0].(map[string]any)
+	if first["id"] != 1.0 || first["title"] != "Go Blog" || first["is_spark"] != 0.0 {
+		t.Errorf("Unexpected feed %v", first)
+	}
+	groups := body["feeds_groups"].([]any)
+	if len(groups) != 1 || groups[0].(map[string]any)["feed_ids"] != "1" {
+		t.Errorf("Unexpected feeds_groups %v", groups)
+	}
+	if g := body["groups"].([]any); len(g) != 1 || g[0].(map[string]any)["title"] != "Programming" {
+		t.Errorf("Unexpected groups %v", g)
+	}
+}
+
+func TestItems(t *testing.T) {
+	srv := New(newFakeStore())
+
+	body := call(t, srv, "items&since_id=1", authed())
+	if items := body["items"].([]any); len(items) != 2 {
+		t.Errorf("Expected 2 items after id 1, got %d", len(items))
+	}
+	if body["total_items"] != 3.0 {
+		t.Errorf("Expected total_items 3, got %v", body["total_items"])
+	}
+
+	body = call(t, srv, "items&with_ids=2", authed())
+	if items := body["items"].([]any); len(items) != 1 || items[0].(map[string]any)["id"] != 2.0 {
+		t.Errorf("Unexpected items for with_ids=2: %v", items)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/fever/?api&items&since_id=x", strings.NewReader(authed().Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid since_id, got %d", rec.Code)
+	}
+}
+
+func TestMark(t *testing.T) {
+	store := newFakeStore()
+	srv := New(store)
+
+	body := call(t, srv, "unread_item_ids", authed("mark", "item", "as", "read", "id", "2"))
+	if body["unread_item_ids"] != "1,3" {
+		t.Errorf("Expected unread ids 1,3, got %v", body["unread_item_ids"])
+	}
+
+	body = call(t, srv, "saved_item_ids", authed("mark", "item", "as", "saved", "id", "3"))
+	if body["saved_item_ids"] != "3" {
+		t.Errorf("Expected saved ids 3, got %v", body["saved_item_ids"])
+	}
+
+	// Marking an unknown item is not an error.
+	call(t, srv, "", authed("mark", "item", "as", "read", "id", "99"))
+
+	// Post 1 was published before the cutoff but only stored after it, so
+	// the client has not seen it yet.
+	before := time.Date(2026, 3, 1, 11, 15, 0, 0, time.UTC).Unix()
+	body = call(t, srv, "unread_item_ids", authed("mark", "feed", "as", "read", "id", "1", "before", strconv.FormatInt(before, 10)))
+	if body["unread_item_ids"] != "1" {
+		t.Errorf("Expected unread ids 1, got %v", body["unread_item_ids"])
+	}
+}
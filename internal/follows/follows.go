@@ -0,0 +1,146 @@
+// Package follows holds the follow and unfollow logic shared by the CLI and
+// the HTTP servers.
+package follows
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedurl"
+	"github.com/theandyeh/gator/internal/rss"
+)
+
+var (
+	ErrInvalidURL       = errors.New("invalid feed url")
+	ErrNotFollowing     = errors.New("feed is not followed")
+	ErrAlreadyFollowing = errors.New("feed is already followed")
+)
+
+// uniqueViolation is the Postgres error code of a duplicate key.
+const uniqueViolation = "23505"
+
+// Store is the subset of database.Queries used to follow feeds.
+type Store interface {
+	GetFeedByURL(ctx context.Context, url string) (database.Feed, error)
+	CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error)
+	CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error)
+	GetFeedFollow(ctx context.Context, arg database.GetFeedFollowParams) (database.FeedFollow, error)
+	DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) error
+}
+
+// Fetcher fetches a feed that is not registered yet to learn its title.
+type Fetcher func(ctx context.Context, feedURL string) (*rss.RSSFeed, error)
+
+// Follow makes user follow the feed at rawURL, registering the feed first if
+// nobody added it yet. created reports whether the feed was new.
+// ErrAlreadyFollowing is returned when user follows the feed already.
+func Follow(ctx context.Context, db Store, fetch Fetcher, user database.User, rawURL string, allowPrivate bool) (follow database.CreateFeedFollowRow, created bool, err error) {
+	feedUrl, err := NormalizeURL(rawURL, allowPrivate)
+	if err != nil {
+		return follow, false, err
+	}
+
+	feed, err := db.GetFeedByURL(ctx, feedUrl)
+	if errors.Is(err, sql.ErrNoRows) {
+		fetched, err := fetch(ctx, feedUrl)
+		if err != nil {
+			return follow, false, fmt.Errorf("fetching feed: %w", err)
+		}
+
+		newFeed := database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      fetched.Channel.Title,
+			Url:       feedUrl,
+			UserID:    user.ID,
+		}
+		feed, err = db.CreateFeed(ctx, newFeed)
+		if err != nil {
+			return follow, false, fmt.Errorf("creating new feed: %w", err)
+		}
+		created = true
+	} else if err != nil {
+		return follow, false, fmt.Errorf("retrieving feed: %w", err)
+	}
+
+	_, err = db.GetFeedFollow(ctx, database.GetFeedFollowParams{UserID: user.ID, FeedID: feed.ID})
+	if err == nil {
+		return follow, false, fmt.Errorf("%w: %s", ErrAlreadyFollowing, feed.Url)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return follow, created, fmt.Errorf("retrieving feed follow: %w", err)
+	}
+
+	followP := database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		FeedID:    feed.ID,
+		UserID:    user.ID,
+	}
+	follow, err = db.CreateFeedFollow(ctx, followP)
+	// Another request may have followed the feed since the check above.
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return follow, created, fmt.Errorf("%w: %s", ErrAlreadyFollowing, feed.Url)
+	}
+	if err != nil {
+		return follow, created, fmt.Errorf("creating feed follow: %w", err)
+	}
+	return follow, created, nil
+}
+
+// Unfollow removes the follow of user on the feed at rawURL. Posts and the
+// feed itself are kept for other followers and for stars.
+func Unfollow(ctx context.Context, db Store, user database.User, rawURL string) (database.Feed, error) {
+	feedUrl, err := feedurl.Normalize(rawURL)
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	feed, err := db.GetFeedByURL(ctx, feedUrl)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, fmt.Errorf("%w: %s", ErrNotFollowing, feedUrl)
+	}
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("retrieving feed: %w", err)
+	}
+	if err := UnfollowID(ctx, db, user, feed.ID); err != nil {
+		return database.Feed{}, err
+	}
+	return feed, nil
+}
+
+// UnfollowID is Unfollow for callers that already know the feed id.
+func UnfollowID(ctx context.Context, db Store, user database.User, feedID uuid.UUID) error {
+	_, err := db.GetFeedFollow(ctx, database.GetFeedFollowParams{UserID: user.ID, FeedID: feedID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrNotFollowing, feedID)
+	}
+	if err != nil {
+		return fmt.Errorf("retrieving feed follow: %w", err)
+	}
+	if err := db.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{FeedID: feedID, UserID: user.ID}); err != nil {
+		return fmt.Errorf("deleting feed follow: %w", err)
+	}
+	return nil
+}
+
+// NormalizeURL validates rawURL against the fetch policy and returns its
+// normalized form.
+func NormalizeURL(rawURL string, allowPrivate bool) (string, error) {
+	if err := feedurl.Validate(rawURL, allowPrivate); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	feedUrl, err := feedurl.Normalize(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	return feedUrl, nil
+}
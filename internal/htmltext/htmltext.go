@@ -0,0 +1,548 @@
+// Package htmltext turns the HTML of feed items into text for the terminal:
+// wrapped paragraphs, lists, quotes and code blocks, with links collected as
+// numbered footnotes.
+package htmltext
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type Options struct {
+	// Width wraps lines at this many columns, 0 does not wrap.
+	Width int
+	// ANSI styles headings, emphasis, links and code with escape codes.
+	ANSI bool
+	// Base resolves relative links, usually the item link.
+	Base string
+	// NoFootnotes leaves out link references and the footnote list.
+	NoFootnotes bool
+}
+
+const (
+	ansiBold      = "\x1b[1m"
+	ansiBoldOff   = "\x1b[22m"
+	ansiItalic    = "\x1b[3m"
+	ansiItalicOff = "\x1b[23m"
+	ansiUnder     = "\x1b[4m"
+	ansiUnderOff  = "\x1b[24m"
+	ansiCode      = "\x1b[36m"
+	ansiCodeOff   = "\x1b[39m"
+	ansiReset     = "\x1b[0m"
+)
+
+// Line returns the plain text s, such as a feed or item title, on one line
+// without control characters, so it cannot move the cursor or start escape
+// sequences when printed to a terminal.
+func Line(s string) string {
+	return strings.Join(strings.Fields(clean(s)), " ")
+}
+
+// Text returns the text of src on one line, for summaries and table cells.
+func Text(src string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(src), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return Line(src)
+	}
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(clean(n.Data))
+		case n.Type == html.ElementNode && skipped[n.DataAtom]:
+		default:
+			// Blocks separate words that only markup kept apart.
+			block := n.Type == html.ElementNode && n.DataAtom != atom.A && !inline[n.DataAtom]
+			if block {
+				b.WriteString(" ")
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+			if block {
+				b.WriteString(" ")
+			}
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+var inline = map[atom.Atom]bool{
+	atom.B: true, atom.Strong: true, atom.I: true, atom.Em: true, atom.Cite: true,
+	atom.Code: true, atom.Kbd: true, atom.Samp: true, atom.Tt: true, atom.Span: true,
+	atom.Small: true, atom.Sub: true, atom.Sup: true, atom.Mark: true, atom.U: true,
+	atom.S: true, atom.Abbr: true, atom.Q: true, atom.Font: true, atom.Del: true, atom.Ins: true,
+}
+
+// Render formats src for a terminal. Control characters in the input are
+// dropped, so feeds cannot send their own escape sequences.
+func Render(src string, opts Options) string {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(src), body)
+	if err != nil {
+		return clean(src)
+	}
+
+	r := &renderer{opts: opts}
+	if opts.Base != "" {
+		r.base, _ = url.Parse(opts.Base)
+	}
+	root := &frame{}
+	for _, n := range nodes {
+		r.node(n, root)
+	}
+	r.flush(root)
+
+	if len(r.links) > 0 {
+		r.blank(root)
+		for i, link := range r.links {
+			r.lines = append(r.lines, fmt.Sprintf("[%d] %s", i+1, link))
+		}
+	}
+	return strings.Join(r.lines, "\n")
+}
+
+// frame is a block that prefixes its lines, such as a list item or a
+// quote. The first line of a list item gets the bullet, later lines are
+// indented to match.
+type frame struct {
+	parent *frame
+	first  string
+	rest   string
+	used   bool
+	// item marks list items, whose nested lists follow without a gap.
+	item bool
+}
+
+func (f *frame) prefix() string {
+	if f == nil {
+		return ""
+	}
+	p := f.parent.prefix()
+	if f.used {
+		return p + f.rest
+	}
+	f.used = true
+	return p + f.first
+}
+
+func (f *frame) blankPrefix() string {
+	if f == nil {
+		return ""
+	}
+	return strings.TrimRight(f.parent.blankPrefix()+f.rest, " ")
+}
+
+func (f *frame) width() int {
+	if f == nil {
+		return 0
+	}
+	return f.parent.width() + utf8.RuneCountInString(f.rest)
+}
+
+type renderer struct {
+	opts  Options
+	base  *url.URL
+	lines []string
+	links []string
+	// inline collects the text of the current paragraph.
+	inline strings.Builder
+	// pending is set once a block ended, so the next one starts after a
+	// blank line.
+	pending bool
+	pre     bool
+}
+
+var skipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Head: true, atom.Noscript: true,
+	atom.Template: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Svg: true, atom.Math: true, atom.Button: true, atom.Input: true,
+	atom.Select: true, atom.Textarea: true, atom.Form: true,
+}
+
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true,
+	atom.Header: true, atom.Footer: true, atom.Main: true, atom.Aside: true,
+	atom.Nav: true, atom.Figure: true, atom.Figcaption: true, atom.Details: true,
+	atom.Summary: true, atom.Address: true, atom.Center: true, atom.Body: true,
+	atom.Html: true, atom.Dl: true,
+}
+
+func (r *renderer) node(n *html.Node, f *frame) {
+	switch n.Type {
+	case html.TextNode:
+		r.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		r.children(n, f)
+		return
+	}
+	if skipped[n.DataAtom] {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.flush(f)
+		level := int(n.Data[1] - '0')
+		if r.opts.ANSI {
+			r.inline.WriteString(ansiBold)
+		} else {
+			r.inline.WriteString(strings.Repeat("#", level) + " ")
+		}
+		r.children(n, f)
+		if r.opts.ANSI {
+			r.inline.WriteString(ansiBoldOff)
+		}
+		r.flush(f)
+
+	case atom.Ul, atom.Ol:
+		r.flush(f)
+		if f.item {
+			r.pending = false
+		}
+		num := 1
+		if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+			num = start
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom != atom.Li {
+				r.node(c, f)
+				continue
+			}
+			bullet := "• "
+			if n.DataAtom == atom.Ol {
+				bullet = fmt.Sprintf("%d. ", num)
+				num++
+			}
+			item := &frame{parent: f, first: bullet, rest: strings.Repeat(" ", utf8.RuneCountInString(bullet)), item: true}
+			r.children(c, item)
+			r.flush(item)
+			if !item.used {
+				r.emit(item, "")
+			}
+			// Items of one list are not separated by blank lines.
+			r.pending = false
+		}
+		r.pending = true
+
+	case atom.Li:
+		item := &frame{parent: f, first: "• ", rest: "  ", item: true}
+		r.flush(f)
+		r.children(n, item)
+		r.flush(item)
+
+	case atom.Blockquote:
+		r.flush(f)
+		r.space(f)
+		quote := "> "
+		if r.opts.ANSI {
+			quote = "│ "
+		}
+		inner := &frame{parent: f, first: quote, rest: quote}
+		r.children(n, inner)
+		r.flush(inner)
+		r.pending = true
+
+	case atom.Pre:
+		r.flush(f)
+		code := &frame{parent: f, first: "    ", rest: "    "}
+		var b strings.Builder
+		r.pre = true
+		r.collect(n, &b)
+		r.pre = false
+		text := strings.Trim(strings.ReplaceAll(b.String(), "\t", "    "), "\n")
+		r.space(f)
+		for _, line := range strings.Split(text, "\n") {
+			if r.opts.ANSI {
+				line = ansiCode + line + ansiCodeOff
+			}
+			r.emit(code, strings.TrimRight(line, " "))
+		}
+		r.pending = true
+
+	case atom.Hr:
+		r.flush(f)
+		r.space(f)
+		width := 40
+		if r.opts.Width > 0 {
+			width = max(3, min(width, r.opts.Width-f.width()))
+		}
+		r.emit(f, strings.Repeat("─", width))
+		r.pending = true
+
+	case atom.Table:
+		r.flush(f)
+		r.table(n, f)
+
+	case atom.Dt:
+		r.flush(f)
+		r.styled(n, f, ansiBold, ansiBoldOff)
+		r.lineBreak(f)
+	case atom.Dd:
+		dd := &frame{parent: f, first: "    ", rest: "    "}
+		r.children(n, dd)
+		r.lineBreak(dd)
+
+	case atom.Br:
+		r.inline.WriteString("\n")
+
+	case atom.A:
+		r.link(n, f)
+
+	case atom.Img:
+		if alt := strings.TrimSpace(clean(attr(n, "alt"))); alt != "" {
+			r.text(" [image: " + alt + "] ")
+		} else if attr(n, "width") != "1" && attr(n, "height") != "1" {
+			// One pixel images are tracking pixels, the rest are worth a mention.
+			r.text(" [image] ")
+		}
+
+	case atom.B, atom.Strong:
+		r.styled(n, f, ansiBold, ansiBoldOff)
+	case atom.I, atom.Em, atom.Cite:
+		r.styled(n, f, ansiItalic, ansiItalicOff)
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		if r.opts.ANSI {
+			r.styled(n, f, ansiCode, ansiCodeOff)
+		} else {
+			r.inline.WriteString("`")
+			r.children(n, f)
+			r.inline.WriteString("`")
+		}
+
+	default:
+		if blocks[n.DataAtom] {
+			r.flush(f)
+			r.children(n, f)
+			r.flush(f)
+			r.pending = true
+			return
+		}
+		r.children(n, f)
+	}
+}
+
+func (r *renderer) children(n *html.Node, f *frame) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.node(c, f)
+	}
+}
+
+func (r *renderer) styled(n *html.Node, f *frame, on, off string) {
+	if r.opts.ANSI {
+		r.inline.WriteString(on)
+	}
+	r.children(n, f)
+	if r.opts.ANSI {
+		r.inline.WriteString(off)
+	}
+}
+
+// link renders the link text followed by a footnote reference, unless the
+// text already is the address.
+func (r *renderer) link(n *html.Node, f *frame) {
+	href := r.resolve(attr(n, "href"))
+	start := r.inline.Len()
+	r.styled(n, f, ansiUnder, ansiUnderOff)
+	if href == "" || r.opts.NoFootnotes {
+		return
+	}
+	text := strings.TrimSpace(stripANSI(r.inline.String()[start:]))
+	if bareURL(text) == bareURL(href) {
+		return
+	}
+
+	num := 0
+	for i, link := range r.links {
+		if link == href {
+			num = i + 1
+		}
+	}
+	if num == 0 {
+		r.links = append(r.links, href)
+		num = len(r.links)
+	}
+	r.inline.WriteString(fmt.Sprintf("[%d]", num))
+}
+
+// resolve returns href as an absolute http, https or mailto address, or ""
+// for anything else such as javascript: links and in-page anchors.
+func (r *renderer) resolve(href string) string {
+	href = strings.TrimSpace(clean(href))
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if r.base != nil {
+		u = r.base.ResolveReference(u)
+	}
+	switch u.Scheme {
+	case "http", "https", "mailto":
+		return u.String()
+	}
+	return ""
+}
+
+func (r *renderer) table(n *html.Node, f *frame) {
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if c.DataAtom != atom.Tr {
+				walk(c)
+				continue
+			}
+			first := true
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.DataAtom != atom.Td && cell.DataAtom != atom.Th {
+					continue
+				}
+				if !first {
+					r.inline.WriteString(" | ")
+				}
+				first = false
+				if cell.DataAtom == atom.Th {
+					r.styled(cell, f, ansiBold, ansiBoldOff)
+				} else {
+					r.children(cell, f)
+				}
+			}
+			r.lineBreak(f)
+		}
+	}
+	walk(n)
+	r.flush(f)
+	r.pending = true
+}
+
+// collect appends the raw text below n, used for preformatted blocks.
+func (r *renderer) collect(n *html.Node, b *strings.Builder) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode:
+			b.WriteString(clean(c.Data))
+		case c.DataAtom == atom.Br:
+			b.WriteString("\n")
+		default:
+			r.collect(c, b)
+		}
+	}
+}
+
+func (r *renderer) text(s string) {
+	s = clean(s)
+	if r.pre {
+		r.inline.WriteString(s)
+		return
+	}
+	// Collapse whitespace like a browser would, keeping one space at the
+	// edges so words of neighbouring elements do not run together.
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" {
+			r.inline.WriteString(" ")
+		}
+		return
+	}
+	if unicode.IsSpace(rune(s[0])) {
+		r.inline.WriteString(" ")
+	}
+	r.inline.WriteString(strings.Join(fields, " "))
+	if unicode.IsSpace(rune(s[len(s)-1])) {
+		r.inline.WriteString(" ")
+	}
+}
+
+// lineBreak ends the current line without a blank line before the next.
+func (r *renderer) lineBreak(f *frame) {
+	r.flush(f)
+	r.pending = false
+}
+
+// flush wraps the collected inline text into lines.
+func (r *renderer) flush(f *frame) {
+	text := r.inline.String()
+	r.inline.Reset()
+	if strings.TrimSpace(stripANSI(text)) == "" {
+		return
+	}
+
+	r.space(f)
+	width := 0
+	if r.opts.Width > 0 {
+		width = max(10, r.opts.Width-f.width())
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.TrimSpace(stripANSI(line)) == "" {
+			continue
+		}
+		for _, l := range wrap(line, width, r.opts.ANSI) {
+			r.emit(f, l)
+		}
+	}
+	r.pending = true
+}
+
+// space starts a new block after a blank line when one is due.
+func (r *renderer) space(f *frame) {
+	if r.pending && len(r.lines) > 0 {
+		r.blank(f)
+	}
+	r.pending = false
+}
+
+func (r *renderer) blank(f *frame) {
+	r.lines = append(r.lines, f.blankPrefix())
+}
+
+func (r *renderer) emit(f *frame, line string) {
+	r.lines = append(r.lines, strings.TrimRight(f.prefix()+line, " "))
+}
+
+func bareURL(s string) string {
+	for _, scheme := range []string{"https://", "http://", "mailto:"} {
+		s = strings.TrimPrefix(s, scheme)
+	}
+	return strings.TrimSuffix(s, "/")
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// clean drops control characters other than tabs and newlines.
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,114 @@
+package htmltext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "paragraphs and wrapping",
+			src:      "<p>The quick brown fox jumps over the lazy dog</p><p>Second &amp; last</p>",
+			opts:     Options{Width: 20},
+			expected: "The quick brown fox\njumps over the lazy\ndog\n\nSecond & last",
+		},
+		{
+			name:     "links as footnotes",
+			src:      `<p>Read <a href="/blog/go1.26">the post</a>, <a href="https://go.dev/">go.dev</a> and <a href="/blog/go1.26">again</a>.</p><p><a href="javascript:alert(1)">no</a></p>`,
+			opts:     Options{Base: "https://go.dev/blog/"},
+			expected: "Read the post[1], go.dev and again[1].\n\nno\n\n[1] https://go.dev/blog/go1.26",
+		},
+		{
+			name:     "lists",
+			src:      `<ul><li>One</li><li>Two<ul><li>Nested</li></ul></li></ul><ol start="3"><li>Three</li></ol>`,
+			expected: "• One\n• Two\n  • Nested\n\n3. Three",
+		},
+		{
+			name:     "blockquote",
+			src:      "<p>Said:</p><blockquote><p>Be brief</p><p>Be done</p></blockquote>",
+			expected: "Said:\n\n> Be brief\n>\n> Be done",
+		},
+		{
+			name:     "code",
+			src:      "<p>Use <code>go vet</code>:</p><pre><code>go vet ./...\n\tgo test</code></pre>",
+			opts:     Options{Width: 10},
+			expected: "Use `go\nvet`:\n\n    go vet ./...\n        go test",
+		},
+		{
+			name:     "headings, breaks and rules",
+			src:      "<h2>Notes</h2>line one<br>line two<hr>",
+			expected: "## Notes\n\nline one\nline two\n\n" + strings.Repeat("─", 40),
+		},
+		{
+			name:     "images and skipped elements",
+			src:      `<p>Chart <img src="c.png" alt="growth"><img src="t.gif" width="1" height="1"><script>alert(1)</script><style>p{}</style></p>`,
+			expected: "Chart [image: growth]",
+		},
+		{
+			name:     "tables",
+			src:      "<table><tr><th>Name</th><th>Value</th></tr><tr><td>a</td><td>1</td></tr></table>",
+			expected: "Name | Value\na | 1",
+		},
+		{
+			name:     "control characters",
+			src:      "<p>red \x1b[31mtext\x07</p>",
+			expected: "red [31mtext",
+		},
+		{
+			name:     "long words",
+			src:      "see https://example.com/a/very/long/path",
+			opts:     Options{Width: 16},
+			expected: "see\nhttps://example.\ncom/a/very/long/\npath",
+		},
+		{
+			name:     "plain text",
+			src:      "just a summary",
+			expected: "just a summary",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := Render(tt.src, tt.opts); got != tt.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestRenderANSI(t *testing.T) {
+	got := Render(`<p><b>bold words that wrap</b> <a href="https://go.dev/x">link</a></p>`, Options{Width: 12, ANSI: true})
+	expected := "\x1b[1mbold words\x1b[0m\n\x1b[1mthat wrap\x1b[22m\n\x1b[4mlink\x1b[24m[1]\n\n[1] https://go.dev/x"
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	quoted := Render("<blockquote>quoted</blockquote>", Options{ANSI: true})
+	if quoted != "│ quoted" {
+		t.Errorf("Expected a bar before quotes, got %q", quoted)
+	}
+}
+
+func TestText(t *testing.T) {
+	got := Text("<p>First <b>para</b></p><ul><li>One</li><li>Two</li></ul><script>x()</script><a href=\"/\">end</a>")
+	if got != "First para One Two end" {
+		t.Errorf("Unexpected text %q", got)
+	}
+}
+
+func TestLine(t *testing.T) {
+	got := Line("\x1b[2JBreaking:\x9b31m a < b\n &amp; more\x07")
+	if got != "[2JBreaking:31m a < b &amp; more" {
+		t.Errorf("Unexpected line %q", got)
+	}
+}
+
+func TestWidth(t *testing.T) {
+	if got := Width("\x1b[1mgö\x1b[22m"); got != 2 {
+		t.Errorf("Expected width 2, got %d", got)
+	}
+}
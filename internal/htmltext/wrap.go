@@ -0,0 +1,111 @@
+package htmltext
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var ansiSeq = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func stripANSI(s string) string {
+	return ansiSeq.ReplaceAllString(s, "")
+}
+
+// Width is the number of columns s takes up, ignoring escape codes.
+func Width(s string) int {
+	return utf8.RuneCountInString(stripANSI(s))
+}
+
+// wrap breaks line at spaces into lines of at most width columns, 0 for no
+// limit. Words longer than a line are cut. With ansi set, styles open at a
+// break are closed at the end of the line and reopened on the next one, so
+// line prefixes stay unstyled.
+func wrap(line string, width int, ansi bool) []string {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return nil
+	}
+	if width <= 0 {
+		return []string{strings.Join(words, " ")}
+	}
+
+	var lines []string
+	var cur strings.Builder
+	curWidth := 0
+	var active []string
+	for _, word := range split(words, width) {
+		w := Width(word)
+		if curWidth > 0 && curWidth+1+w > width {
+			if ansi && len(active) > 0 {
+				cur.WriteString(ansiReset)
+			}
+			lines = append(lines, cur.String())
+			cur.Reset()
+			curWidth = 0
+			if ansi {
+				cur.WriteString(strings.Join(active, ""))
+			}
+		}
+		if curWidth > 0 {
+			cur.WriteString(" ")
+			curWidth++
+		}
+		cur.WriteString(word)
+		curWidth += w
+		if ansi {
+			active = track(active, word)
+		}
+	}
+	lines = append(lines, cur.String())
+	return lines
+}
+
+var closes = map[string]string{
+	ansiBold:   ansiBoldOff,
+	ansiItalic: ansiItalicOff,
+	ansiUnder:  ansiUnderOff,
+	ansiCode:   ansiCodeOff,
+}
+
+// track updates the styles left open after s.
+func track(active []string, s string) []string {
+	for _, seq := range ansiSeq.FindAllString(s, -1) {
+		if _, ok := closes[seq]; ok {
+			active = append(active, seq)
+			continue
+		}
+		for i := len(active) - 1; i >= 0; i-- {
+			if closes[active[i]] == seq {
+				active = append(active[:i], active[i+1:]...)
+				break
+			}
+		}
+	}
+	return active
+}
+
+// split cuts words wider than width into pieces that fit.
+func split(words []string, width int) []string {
+	var out []string
+	for _, word := range words {
+		for Width(word) > width {
+			var head strings.Builder
+			n := 0
+			for n < width {
+				if loc := ansiSeq.FindStringIndex(word); loc != nil && loc[0] == 0 {
+					head.WriteString(word[:loc[1]])
+					word = word[loc[1]:]
+					continue
+				}
+				_, size := utf8.DecodeRuneInString(word)
+				head.WriteString(word[:size])
+				word = word[size:]
+				n++
+			}
+			out = append(out, head.String())
+		}
+		out = append(out, word)
+	}
+	return out
+}
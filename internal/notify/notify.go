@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	KindDesktop = "desktop"
+	KindCommand = "command"
+	KindFile    = "file"
+)
+
+// Timeout bounds how long a single notification may take so a hanging
+// command cannot stall the aggregator.
+const Timeout = 10 * time.Second
+
+var (
+	ErrUnknownKind   = errors.New("unknown notifier, expected desktop, command or file")
+	ErrMissingTarget = errors.New("notifier needs a target")
+	ErrNoReader      = errors.New("no process is reading from the fifo")
+	ErrNotAllowed    = errors.New("target is not in the operator allowlist")
+)
+
+// Allowlist holds the command lines and file paths the operator of the
+// aggregator lets watches use. Commands run and files are written as the
+// aggregator's user, so watches created by users may only pick targets
+// listed here.
+type Allowlist struct {
+	Commands []string
+	Files    []string
+}
+
+// Notification describes a stored item that matched a watch. It is what
+// command notifiers receive as JSON on stdin and file notifiers append as a
+// JSON line.
+type Notification struct {
+	Watch       string    `json:"watch"`
+	User        string    `json:"user"`
+	Feed        string    `json:"feed"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	PublishedAt time.Time `json:"published_at,omitzero"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// New returns the notifier of the given kind. The target is the command line
+// for command notifiers and the path for file notifiers; desktop notifiers
+// ignore it. Command and file targets must appear in allowed.
+func New(kind, target string, allowed Allowlist) (Notifier, error) {
+	switch kind {
+	case KindDesktop:
+		return Desktop{}, nil
+	case KindCommand:
+		if strings.TrimSpace(target) == "" {
+			return nil, fmt.Errorf("notify error: %w (command to run)", ErrMissingTarget)
+		}
+		if !slices.Contains(allowed.Commands, target) {
+			return nil, fmt.Errorf("notify error: command %q: %w", target, ErrNotAllowed)
+		}
+		return Command{Shell: target}, nil
+	case KindFile:
+		if strings.TrimSpace(target) == "" {
+			return nil, fmt.Errorf("notify error: %w (file path)", ErrMissingTarget)
+		}
+		if !slices.Contains(allowed.Files, target) {
+			return nil, fmt.Errorf("notify error: file %q: %w", target, ErrNotAllowed)
+		}
+		return File{Path: target}, nil
+	}
+	return nil, fmt.Errorf("notify error: %w: %q", ErrUnknownKind, kind)
+}
+
+// Desktop shows a notification through notify-send, or Program if set.
+type Desktop struct {
+	Program string
+}
+
+func (d Desktop) Notify(ctx context.Context, n Notification) error {
+	program := d.Program
+	if program == "" {
+		program = "notify-send"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	summary := n.Title
+	if n.Feed != "" {
+		summary = n.Feed + ": " + n.Title
+	}
+	// The summary and address come from the feed, -- keeps them from being
+	// read as options.
+	out, err := exec.CommandContext(ctx, program, "--app-name=gator", "--", summary, n.URL).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("notify error running %s: %w: %s", program, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// Command runs Shell through sh with the notification as JSON on stdin.
+type Command struct {
+	Shell string
+}
+
+func (c Command) Notify(ctx context.Context, n Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("notify error: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Shell)
+	cmd.Stdin = bytes.NewReader(payload)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("notify error running %q: %w: %s", c.Shell, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// File appends the notification as a JSON line to Path, which may be a
+// regular file or a FIFO. Writing to a FIFO nobody reads fails right away
+// instead of blocking.
+type File struct {
+	Path string
+}
+
+func (f File) Notify(ctx context.Context, n Notification) error {
+	file, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|syscall.O_NONBLOCK, 0o600)
+	if errors.Is(err, syscall.ENXIO) {
+		return fmt.Errorf("notify error: %w: %s", ErrNoReader, f.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("notify error: %w", err)
+	}
+	defer file.Close()
+
+	return writeJSONLine(file, n)
+}
+
+// Writer prints a one line summary of each notification to W.
+type Writer struct {
+	W io.Writer
+}
+
+func (w Writer) Notify(ctx context.Context, n Notification) error {
+	_, err := fmt.Fprintf(w.W, "%s: %s (%s)\n", n.Feed, n.Title, n.URL)
+	return err
+}
+
+func writeJSONLine(w io.Writer, n Notification) error {
+	line, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("notify error: %w", err)
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("notify error: %w", err)
+	}
+	return nil
+}
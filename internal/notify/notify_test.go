@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+var testNotification = Notification{
+	Watch: "advisories",
+	User:  "kahya",
+	Feed:  "Go Blog",
+	Title: "Security fix for net/http",
+	URL:   "https://go.dev/blog/security",
+}
+
+func TestNew(t *testing.T) {
+	allowed := Allowlist{Commands: []string{"logger -t gator"}, Files: []string{"/var/lib/gator/alerts.jsonl"}}
+
+	if _, err := New("pager", "", allowed); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("New(pager) error = %v, want %v", err, ErrUnknownKind)
+	}
+	if _, err := New(KindCommand, " ", allowed); !errors.Is(err, ErrMissingTarget) {
+		t.Errorf("New(command, blank) error = %v, want %v", err, ErrMissingTarget)
+	}
+	if _, err := New(KindFile, "", allowed); !errors.Is(err, ErrMissingTarget) {
+		t.Errorf("New(file, blank) error = %v, want %v", err, ErrMissingTarget)
+	}
+	if n, err := New(KindDesktop, "", Allowlist{}); err != nil || n == nil {
+		t.Errorf("New(desktop) = %v, %v", n, err)
+	}
+	if _, err := New(KindCommand, "rm -rf ~", allowed); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("New(command, not listed) error = %v, want %v", err, ErrNotAllowed)
+	}
+	if _, err := New(KindFile, "/home/other/.bashrc", allowed); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("New(file, not listed) error = %v, want %v", err, ErrNotAllowed)
+	}
+	if n, err := New(KindCommand, "logger -t gator", allowed); err != nil || n != (Command{Shell: "logger -t gator"}) {
+		t.Errorf("New(command, listed) = %v, %v", n, err)
+	}
+	if n, err := New(KindFile, "/var/lib/gator/alerts.jsonl", allowed); err != nil || n != (File{Path: "/var/lib/gator/alerts.jsonl"}) {
+		t.Errorf("New(file, listed) = %v, %v", n, err)
+	}
+}
+
+func TestCommandReceivesJSON(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "stdin.json")
+	c := Command{Shell: "cat > " + out}
+	if err := c.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Notification
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("stdin is not JSON: %v", err)
+	}
+	if got != testNotification {
+		t.Errorf("stdin = %+v, want %+v", got, testNotification)
+	}
+}
+
+func TestCommandFailure(t *testing.T) {
+	c := Command{Shell: "echo broken >&2; exit 3"}
+	err := c.Notify(context.Background(), testNotification)
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Notify() error = %v, want output of failed command", err)
+	}
+}
+
+func TestFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	f := File{Path: path}
+	for range 2 {
+		if err := f.Notify(context.Background(), testNotification); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), data)
+	}
+}
+
+func TestFileFIFOWithoutReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.fifo")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+
+	err := File{Path: path}.Notify(context.Background(), testNotification)
+	if !errors.Is(err, ErrNoReader) {
+		t.Errorf("Notify() error = %v, want %v", err, ErrNoReader)
+	}
+}
+
+func TestDesktopProgram(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "args")
+	program := filepath.Join(dir, "notify-send")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + out + "\n"
+	if err := os.WriteFile(program, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	n := testNotification
+	n.Feed = "-u critical"
+	if err := (Desktop{Program: program}).Notify(context.Background(), n); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--app-name=gator\n--\n-u critical: Security fix for net/http\nhttps://go.dev/blog/security\n"
+	if string(data) != want {
+		t.Errorf("args = %q, want %q", data, want)
+	}
+}
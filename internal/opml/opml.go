@@ -0,0 +1,131 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Subscription is a single feed together with the category it is filed
+// under, which is empty for uncategorized feeds.
+type Subscription struct {
+	Title    string
+	URL      string
+	Category string
+}
+
+func Parse(r io.Reader) (*OPML, error) {
+	var doc OPML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("opml parse error: %w", err)
+	}
+	return &doc, nil
+}
+
+// Subscriptions flattens the outline tree. Feeds nested in folders take the
+// name of their top level folder as category.
+func (o *OPML) Subscriptions() []Subscription {
+	var subs []Subscription
+	var walk func(outlines []Outline, category string)
+	walk = func(outlines []Outline, category string) {
+		for _, out := range outlines {
+			if out.XMLURL != "" {
+				title := out.Title
+				if title == "" {
+					title = out.Text
+				}
+				subs = append(subs, Subscription{Title: title, URL: out.XMLURL, Category: category})
+			}
+			if len(out.Outlines) > 0 {
+				folder := category
+				if folder == "" {
+					folder = out.Text
+					if folder == "" {
+						folder = out.Title
+					}
+				}
+				walk(out.Outlines, folder)
+			}
+		}
+	}
+	walk(o.Body.Outlines, "")
+	return subs
+}
+
+// Build creates a document with one folder per category. Uncategorized feeds
+// are placed at the top level.
+func Build(title string, subs []Subscription) *OPML {
+	doc := &OPML{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	folders := make(map[string]*Outline)
+	var names []string
+	for _, sub := range subs {
+		feed := Outline{Text: sub.Title, Title: sub.Title, Type: "rss", XMLURL: sub.URL}
+		if sub.Category == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, feed)
+			continue
+		}
+		folder, ok := folders[sub.Category]
+		if !ok {
+			folder = &Outline{Text: sub.Category, Title: sub.Category}
+			folders[sub.Category] = folder
+			names = append(names, sub.Category)
+		}
+		folder.Outlines = append(folder.Outlines, feed)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		doc.Body.Outlines = append(doc.Body.Outlines, *folders[name])
+	}
+
+	return doc
+}
+
+func (o *OPML) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("opml write error: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(o); err != nil {
+		return fmt.Errorf("opml write error: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
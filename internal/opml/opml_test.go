@@ -0,0 +1,81 @@
+package opml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="1.0">
+  <head><title>Subscriptions</title></head>
+  <body>
+    <outline text="Unfiled" type="rss" xmlUrl="https://example.com/feed"/>
+    <outline text="Work">
+      <outline text="Go Blog" title="The Go Blog" type="rss" xmlUrl="https://go.dev/blog/feed.atom"/>
+      <outline text="Databases">
+        <outline text="Postgres" type="rss" xmlUrl="https://postgres.example.com/rss"/>
+      </outline>
+    </outline>
+  </body>
+</opml>`
+
+func TestSubscriptions(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testDoc))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := []Subscription{
+		{Title: "Unfiled", URL: "https://example.com/feed"},
+		{Title: "The Go Blog", URL: "https://go.dev/blog/feed.atom", Category: "Work"},
+		{Title: "Postgres", URL: "https://postgres.example.com/rss", Category: "Work"},
+	}
+
+	subs := doc.Subscriptions()
+	if len(subs) != len(expected) {
+		t.Fatalf("Expected %d subscriptions, got %d: %v", len(expected), len(subs), subs)
+	}
+	for i, sub := range expected {
+		if subs[i] != sub {
+			t.Errorf("Subscription %d: expected %+v, got %+v", i, sub, subs[i])
+		}
+	}
+}
+
+func TestBuildRoundTrip(t *testing.T) {
+	subs := []Subscription{
+		{Title: "Personal", URL: "https://me.example.com/feed", Category: "Reading"},
+		{Title: "Loose", URL: "https://loose.example.com/feed"},
+		{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", Category: "Work"},
+		{Title: "Another", URL: "https://another.example.com/rss", Category: "Reading"},
+	}
+
+	var buf bytes.Buffer
+	if err := Build("gator", subs).Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	doc, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(doc.Body.Outlines) != 3 {
+		t.Fatalf("Expected 1 loose feed and 2 folders, got %d outlines", len(doc.Body.Outlines))
+	}
+	if doc.Body.Outlines[1].Text != "Reading" || len(doc.Body.Outlines[1].Outlines) != 2 {
+		t.Errorf("Expected Reading folder with 2 feeds, got %+v", doc.Body.Outlines[1])
+	}
+
+	got := doc.Subscriptions()
+	if len(got) != len(subs) {
+		t.Fatalf("Expected %d subscriptions after round trip, got %d", len(subs), len(got))
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse(strings.NewReader("<opml><body>")); err == nil {
+		t.Error("Expected error parsing truncated document")
+	}
+}
@@ -0,0 +1,268 @@
+// Package planet renders posts from many feeds into a static HTML site in
+// the style of Planet aggregators: paginated index pages, one page per feed
+// and an Atom feed of the latest posts.
+package planet
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/feedgen"
+)
+
+//go:embed templates/page.html templates/style.css
+var templateFS embed.FS
+
+var pageTemplate = template.Must(template.ParseFS(templateFS, "templates/page.html"))
+
+// Feed is one source blog. Slug names its page and is filled in by Build
+// when empty.
+type Feed struct {
+	ID   uuid.UUID
+	Name string
+	URL  string
+	Slug string
+}
+
+type Item struct {
+	ID        uuid.UUID
+	Title     string
+	Link      string
+	Summary   string
+	Author    string
+	Published time.Time
+	Updated   time.Time
+	Feed      *Feed
+}
+
+// Site is everything rendered by one build. Items are expected newest
+// first.
+type Site struct {
+	Title     string
+	URL       string
+	Generated time.Time
+	PerPage   int
+	Feeds     []*Feed
+	Items     []Item
+}
+
+type day struct {
+	Date  string
+	Items []Item
+}
+
+type pageData struct {
+	Site  *Site
+	Title string
+	Feed  *Feed
+	// Root is the relative path back to the site root.
+	Root  string
+	Days  []day
+	Page  int
+	Pages int
+	Prev  string
+	Next  string
+}
+
+// Build writes the site into dir and removes pages left over from earlier
+// builds, such as index pages beyond the current last page.
+func Build(dir string, site *Site) error {
+	if site.PerPage <= 0 {
+		return fmt.Errorf("planet error: posts per page must be positive, got %d", site.PerPage)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "feeds"), 0755); err != nil {
+		return fmt.Errorf("planet error: %w", err)
+	}
+	assignSlugs(site.Feeds)
+
+	written := map[string]bool{}
+	write := func(name string, data []byte) error {
+		written[name] = true
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return fmt.Errorf("planet error: %w", err)
+		}
+		return nil
+	}
+
+	if err := writePages(site, site.Items, nil, write); err != nil {
+		return err
+	}
+	for _, feed := range site.Feeds {
+		var items []Item
+		for _, item := range site.Items {
+			if item.Feed == feed {
+				items = append(items, item)
+			}
+		}
+		if err := writePages(site, items, feed, write); err != nil {
+			return err
+		}
+	}
+
+	css, err := templateFS.ReadFile("templates/style.css")
+	if err != nil {
+		return fmt.Errorf("planet error: %w", err)
+	}
+	if err := write("style.css", css); err != nil {
+		return err
+	}
+
+	var atom bytes.Buffer
+	if err := feedgen.WriteAtom(&atom, site.atom()); err != nil {
+		return fmt.Errorf("planet error: %w", err)
+	}
+	if err := write("atom.xml", atom.Bytes()); err != nil {
+		return err
+	}
+
+	return removeStale(dir, written)
+}
+
+// writePages renders items as numbered pages: index.html, page-2.html and
+// so on for the whole site, feeds/<slug>.html, feeds/<slug>.2.html for a
+// single feed.
+func writePages(site *Site, items []Item, feed *Feed, write func(string, []byte) error) error {
+	name := func(page int) string {
+		switch {
+		case feed == nil && page == 1:
+			return "index.html"
+		case feed == nil:
+			return fmt.Sprintf("page-%d.html", page)
+		case page == 1:
+			return "feeds/" + feed.Slug + ".html"
+		}
+		return fmt.Sprintf("feeds/%s.%d.html", feed.Slug, page)
+	}
+
+	pages := max(1, (len(items)+site.PerPage-1)/site.PerPage)
+	for page := 1; page <= pages; page++ {
+		data := pageData{
+			Site:  site,
+			Title: site.Title,
+			Feed:  feed,
+			Page:  page,
+			Pages: pages,
+		}
+		if feed != nil {
+			data.Title = feed.Name + " - " + site.Title
+			data.Root = "../"
+		}
+		if page > 1 {
+			data.Prev = filepath.Base(name(page - 1))
+		}
+		if page < pages {
+			data.Next = filepath.Base(name(page + 1))
+		}
+		start := (page - 1) * site.PerPage
+		data.Days = byDay(items[start:min(start+site.PerPage, len(items))])
+
+		var buf bytes.Buffer
+		if err := pageTemplate.Execute(&buf, data); err != nil {
+			return fmt.Errorf("planet error rendering %s: %w", name(page), err)
+		}
+		if err := write(name(page), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func byDay(items []Item) []day {
+	var days []day
+	for _, item := range items {
+		date := item.Published.Format("Monday, 2 January 2006")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, day{Date: date})
+		}
+		days[len(days)-1].Items = append(days[len(days)-1].Items, item)
+	}
+	return days
+}
+
+func (site *Site) atom() feedgen.Feed {
+	feed := feedgen.Feed{
+		// The id only has to be stable for the same site address or title.
+		ID:      "urn:uuid:" + uuid.NewSHA1(uuid.NameSpaceURL, []byte(site.URL+"#"+site.Title)).String(),
+		Title:   site.Title,
+		Updated: site.Generated,
+	}
+	if site.URL != "" {
+		base := strings.TrimSuffix(site.URL, "/") + "/"
+		feed.Link = base + "index.html"
+		feed.Self = base + "atom.xml"
+	}
+	for _, item := range site.Items[:min(len(site.Items), site.PerPage)] {
+		feed.Items = append(feed.Items, feedgen.Item{
+			ID:        item.ID,
+			Title:     item.Title,
+			Link:      item.Link,
+			Content:   item.Summary,
+			Author:    item.Author,
+			Source:    item.Feed.Name,
+			Published: item.Published,
+			Updated:   item.Updated,
+		})
+	}
+	return feed
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// assignSlugs derives file names from feed names, numbering duplicates.
+func assignSlugs(feeds []*Feed) {
+	used := map[string]bool{}
+	for _, feed := range feeds {
+		if feed.Slug != "" {
+			used[feed.Slug] = true
+		}
+	}
+	for _, feed := range feeds {
+		if feed.Slug != "" {
+			continue
+		}
+		base := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(feed.Name), "-"), "-")
+		if base == "" {
+			base = "feed"
+		}
+		slug := base
+		for i := 2; used[slug]; i++ {
+			slug = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[slug] = true
+		feed.Slug = slug
+	}
+}
+
+var generatedPage = regexp.MustCompile(`^(index|page-\d+)\.html$`)
+
+// removeStale deletes pages this package generated before but not in this
+// build. Other files in dir are left alone.
+func removeStale(dir string, written map[string]bool) error {
+	for _, sub := range []string{"", "feeds"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			return fmt.Errorf("planet error: %w", err)
+		}
+		for _, entry := range entries {
+			name := filepath.ToSlash(filepath.Join(sub, entry.Name()))
+			if entry.IsDir() || written[name] || !strings.HasSuffix(name, ".html") {
+				continue
+			}
+			if sub == "" && !generatedPage.MatchString(name) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return fmt.Errorf("planet error: %w", err)
+			}
+		}
+	}
+	return nil
+}
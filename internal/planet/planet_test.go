@@ -0,0 +1,133 @@
+package planet
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testSite() *Site {
+	goBlog := &Feed{ID: uuid.New(), Name: "Go Blog", URL: "https://go.dev/blog/feed.atom"}
+	other := &Feed{ID: uuid.New(), Name: "Go  blog!", URL: "https://example.com/feed"}
+	site := &Site{
+		Title:     "Planet Eng",
+		URL:       "https://planet.example.com/",
+		Generated: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		PerPage:   2,
+		Feeds:     []*Feed{goBlog, other},
+	}
+	start := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		feed := goBlog
+		if i == 4 {
+			feed = other
+		}
+		site.Items = append(site.Items, Item{
+			ID:        uuid.New(),
+			Title:     fmt.Sprintf("Post %d <b>", i),
+			Link:      fmt.Sprintf("https://go.dev/blog/%d", i),
+			Summary:   "summary",
+			Published: start.Add(-time.Duration(i) * 10 * time.Hour),
+			Feed:      feed,
+		})
+	}
+	return site
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected %s to exist: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	site := testSite()
+	if err := Build(dir, site); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if site.Feeds[0].Slug != "go-blog" || site.Feeds[1].Slug != "go-blog-2" {
+		t.Errorf("Unexpected slugs %q and %q", site.Feeds[0].Slug, site.Feeds[1].Slug)
+	}
+
+	index := readFile(t, filepath.Join(dir, "index.html"))
+	if !strings.Contains(index, "Post 0 &lt;b&gt;") {
+		t.Error("Titles should be escaped")
+	}
+	if !strings.Contains(index, `href="page-2.html"`) || strings.Contains(index, "Post 2") {
+		t.Error("Index should hold the first page and link to the next one")
+	}
+	if !strings.Contains(index, "Sunday, 1 March 2026") {
+		t.Error("Posts should be grouped by day")
+	}
+	last := readFile(t, filepath.Join(dir, "page-3.html"))
+	if !strings.Contains(last, "Post 4") || !strings.Contains(last, `href="page-2.html"`) {
+		t.Error("Last page should hold the oldest post and link back")
+	}
+
+	feedPage := readFile(t, filepath.Join(dir, "feeds", "go-blog.html"))
+	if !strings.Contains(feedPage, `href="../style.css"`) || !strings.Contains(feedPage, `href="go-blog.2.html"`) {
+		t.Error("Feed pages should link relative to the site root and to their next page")
+	}
+	if strings.Contains(readFile(t, filepath.Join(dir, "feeds", "go-blog-2.html")), "Post 0") {
+		t.Error("Feed pages should only hold posts of their feed")
+	}
+	readFile(t, filepath.Join(dir, "style.css"))
+
+	var atom struct {
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Entries []struct{} `xml:"entry"`
+	}
+	if err := xml.Unmarshal([]byte(readFile(t, filepath.Join(dir, "atom.xml"))), &atom); err != nil {
+		t.Fatalf("Invalid atom.xml: %v", err)
+	}
+	if len(atom.Entries) != 2 || atom.Links[1].Href != "https://planet.example.com/atom.xml" {
+		t.Errorf("Unexpected atom feed: %+v", atom)
+	}
+}
+
+func TestBuildRemovesStalePages(t *testing.T) {
+	dir := t.TempDir()
+	if err := Build(dir, testSite()); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "about.html"), []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	site := testSite()
+	site.PerPage = 10
+	site.Feeds = site.Feeds[:1]
+	site.Items = site.Items[:4]
+	if err := Build(dir, site); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	for _, name := range []string{"page-2.html", "page-3.html", "feeds/go-blog.2.html", "feeds/go-blog-2.html"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected stale %s to be removed", name)
+		}
+	}
+	readFile(t, filepath.Join(dir, "about.html"))
+}
+
+func TestBuildInvalidPerPage(t *testing.T) {
+	site := testSite()
+	site.PerPage = 0
+	if err := Build(t.TempDir(), site); err == nil {
+		t.Error("Expected an error for zero posts per page")
+	}
+}
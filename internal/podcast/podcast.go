@@ -0,0 +1,218 @@
+// Package podcast downloads the media files attached to feed items. Partial
+// downloads are kept next to the target with a .part suffix and resumed with
+// a range request on the next attempt. The ETag or Last-Modified value of the
+// first response is kept beside the partial file and sent as If-Range, so a
+// file that changed on the server is downloaded again instead of being
+// appended to the old bytes.
+package podcast
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// partSuffix marks files that are still being downloaded.
+const partSuffix = ".part"
+
+// validatorSuffix marks the file holding the validator of a partial download.
+const validatorSuffix = ".part.validator"
+
+// maxNameLength keeps generated file names well below file system limits.
+const maxNameLength = 100
+
+// Download fetches mediaURL into dest, resuming a partial download left by
+// an earlier attempt. It returns the number of bytes written by this call.
+// dest only appears once the whole file has arrived.
+func Download(ctx context.Context, client *http.Client, mediaURL, dest string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return 0, fmt.Errorf("podcast download error: %w", err)
+	}
+	part := dest + partSuffix
+
+	// A partial file can only be resumed when the server can tell whether
+	// it still serves the same file.
+	var offset int64
+	validator, _ := os.ReadFile(dest + validatorSuffix)
+	if info, err := os.Stat(part); err == nil && len(validator) > 0 {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", mediaURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("podcast download error: %w", err)
+	}
+	req.Header.Set("User-Agent", "gator")
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		req.Header.Set("If-Range", string(validator))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("podcast download error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		// Nothing was there to resume, the server ignored the range or the
+		// file changed since the partial download.
+		flags |= os.O_TRUNC
+		if err := saveValidator(dest, resp.Header); err != nil {
+			return 0, fmt.Errorf("podcast download error: %w", err)
+		}
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if start, ok := rangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			return 0, fmt.Errorf("podcast download error: %s resumed at the wrong offset", mediaURL)
+		}
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		if total, ok := rangeTotal(resp.Header.Get("Content-Range")); ok && total == offset {
+			// The partial file already holds everything.
+			return 0, finish(part, dest)
+		}
+		// The file changed on the server, start over.
+		if err := os.Remove(part); err != nil {
+			return 0, fmt.Errorf("podcast download error: %w", err)
+		}
+		os.Remove(dest + validatorSuffix)
+		resp.Body.Close()
+		return Download(ctx, client, mediaURL, dest)
+	default:
+		return 0, fmt.Errorf("podcast download error: %s returned %s", mediaURL, resp.Status)
+	}
+
+	f, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("podcast download error: %w", err)
+	}
+	n, err := io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, fmt.Errorf("podcast download error, rerun to resume: %w", err)
+	}
+
+	return n, finish(part, dest)
+}
+
+func finish(part, dest string) error {
+	if err := os.Rename(part, dest); err != nil {
+		return fmt.Errorf("podcast download error: %w", err)
+	}
+	os.Remove(dest + validatorSuffix)
+	return nil
+}
+
+// saveValidator keeps the value If-Range is checked against when the
+// download is resumed. Weak ETags do not work with If-Range, Last-Modified
+// is used for them and for servers without an ETag. Without either the
+// partial file is not resumed.
+func saveValidator(dest string, header http.Header) error {
+	validator := header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = header.Get("Last-Modified")
+	}
+	if validator == "" {
+		if err := os.Remove(dest + validatorSuffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(dest+validatorSuffix, []byte(validator), 0o644)
+}
+
+// rangeStart returns the first byte of a "bytes start-end/total" header.
+func rangeStart(header string) (int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
+
+// rangeTotal returns the complete length from a Content-Range header.
+func rangeTotal(header string) (int64, bool) {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(total, 10, 64)
+	return n, err == nil
+}
+
+// Path returns where an episode of feed titled title is stored below dir.
+// Names are reduced to characters that are safe on common file systems and
+// the extension is taken from the media address or its MIME type. The
+// publication date and the start of id keep episodes that share a title,
+// like trailers and bonus episodes, from overwriting each other.
+func Path(dir, feed, title string, published time.Time, id, mediaURL, mimeType string) string {
+	name := safeName(title, "episode")
+	if !published.IsZero() {
+		name = published.UTC().Format(time.DateOnly) + " " + name
+	}
+	if short := safeName(id, ""); short != "" {
+		name += " (" + short[:min(len(short), 8)] + ")"
+	}
+	return filepath.Join(dir, safeName(feed, "feed"), name+extension(mediaURL, mimeType))
+}
+
+func safeName(s, fallback string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.,()'&", r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+
+	name := strings.Trim(b.String(), ". ")
+	for utf8.RuneCountInString(name) > maxNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+func extension(mediaURL, mimeType string) string {
+	if u, err := url.Parse(mediaURL); err == nil {
+		ext := strings.ToLower(path.Ext(u.Path))
+		if len(ext) > 1 && len(ext) <= 5 && safeName(ext[1:], "") == ext[1:] {
+			return ext
+		}
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+package podcast
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const etag = `"v2"`
+
+func newMediaServer(t *testing.T, data []byte, ranges bool) (*httptest.Server, *[]string) {
+	t.Helper()
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Range"))
+		if r.URL.Path != "/episode.mp3" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		if !ranges {
+			r.Header.Del("Range")
+		}
+		http.ServeContent(w, r, "episode.mp3", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &seen
+}
+
+func TestDownload(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+
+	stale := bytes.Repeat([]byte("x"), 4000)
+
+	tests := []struct {
+		name      string
+		partial   []byte
+		validator string
+		ranges    bool
+		written   int64
+		rangeHd   string
+	}{
+		{name: "fresh download", ranges: true, written: int64(len(data))},
+		{name: "resume", partial: data[:4000], validator: etag, ranges: true, written: int64(len(data) - 4000), rangeHd: "bytes=4000-"},
+		{name: "file changed on the server", partial: stale, validator: `"v1"`, ranges: true, written: int64(len(data)), rangeHd: "bytes=4000-"},
+		{name: "partial file without validator", partial: stale, ranges: true, written: int64(len(data))},
+		{name: "server without ranges", partial: data[:4000], validator: etag, ranges: false, written: int64(len(data)), rangeHd: "bytes=4000-"},
+		{name: "partial file complete", partial: data, validator: etag, ranges: true, written: 0, rangeHd: "bytes=10000-"},
+		{name: "partial file too long", partial: append(append([]byte{}, data...), "junk"...), validator: etag, ranges: true, written: int64(len(data)), rangeHd: "bytes=10004-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, seen := newMediaServer(t, data, tt.ranges)
+			dest := filepath.Join(t.TempDir(), "show", "episode.mp3")
+			if tt.partial != nil {
+				if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(dest+partSuffix, tt.partial, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.validator != "" {
+				if err := os.WriteFile(dest+validatorSuffix, []byte(tt.validator), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			n, err := Download(context.Background(), srv.Client(), srv.URL+"/episode.mp3", dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.written {
+				t.Errorf("wrote %d bytes, want %d", n, tt.written)
+			}
+			if (*seen)[0] != tt.rangeHd {
+				t.Errorf("first request had range %q, want %q", (*seen)[0], tt.rangeHd)
+			}
+
+			got, err := os.ReadFile(dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("downloaded %d bytes that do not match the original", len(got))
+			}
+			for _, suffix := range []string{partSuffix, validatorSuffix} {
+				if _, err := os.Stat(dest + suffix); !os.IsNotExist(err) {
+					t.Errorf("%s file left behind: %v", suffix, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadKeepsValidatorOfPartialFile(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Length", "10000")
+		w.Write(data[:4000])
+		// Drop the connection half way through the body.
+		panic(http.ErrAbortHandler)
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "episode.mp3")
+	if _, err := Download(context.Background(), srv.Client(), srv.URL+"/episode.mp3", dest); err == nil {
+		t.Fatal("expected an error for an interrupted download")
+	}
+	if validator, err := os.ReadFile(dest + validatorSuffix); err != nil || string(validator) != etag {
+		t.Errorf("validator = %q, %v, want %q", validator, err, etag)
+	}
+}
+
+func TestDownloadFailureKeepsNothing(t *testing.T) {
+	srv, _ := newMediaServer(t, nil, true)
+	dest := filepath.Join(t.TempDir(), "missing.mp3")
+	if _, err := Download(context.Background(), srv.Client(), srv.URL+"/missing.mp3", dest); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("failed download created %s", dest)
+	}
+}
+
+func TestPath(t *testing.T) {
+	published := time.Date(2026, 3, 1, 23, 30, 0, 0, time.FixedZone("", -5*60*60))
+	tests := []struct {
+		feed, title string
+		published   time.Time
+		id          string
+		url         string
+		mimeType    string
+		expected    string
+	}{
+		{"Ship It!", "Episode 12: Go / Rust?", published, "3f2a9c1e-7b4d-4e8a-9f00-1c2d3e4f5a6b", "https://cdn.example.com/12.MP3?token=x", "audio/mpeg", "Ship It/2026-03-02 Episode 12 Go Rust (3f2a9c1e).mp3"},
+		{"Ship It!", "Trailer", time.Time{}, "8d0b7e52-1111-4e8a-9f00-1c2d3e4f5a6b", "https://cdn.example.com/trailer.mp3", "audio/mpeg", "Ship It/Trailer (8d0b7e52).mp3"},
+		{"../../etc", "..", time.Time{}, "", "https://cdn.example.com/download", "", "etc/episode"},
+		{"Show", strings.Repeat("a", 300), time.Time{}, "", "https://cdn.example.com/a.m4a", "audio/mp4", "Show/" + strings.Repeat("a", maxNameLength) + ".m4a"},
+	}
+
+	for _, tt := range tests {
+		got := Path("/media", tt.feed, tt.title, tt.published, tt.id, tt.url, tt.mimeType)
+		if expected := filepath.Join("/media", tt.expected); got != expected {
+			t.Errorf("Path(%q, %q) = %q, want %q", tt.feed, tt.title, got, expected)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package readability
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// maxPageSize limits how much of a page is read. Articles are far smaller,
+// anything bigger is not worth parsing.
+const maxPageSize = 5 << 20
+
+// Fetch downloads pageURL with client and extracts its article. Addresses
+// are resolved against the final url after redirects.
+func Fetch(ctx context.Context, client *http.Client, pageURL string) (Article, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return Article{}, fmt.Errorf("readability fetch error: %w", err)
+	}
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Article{}, fmt.Errorf("readability fetch error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Article{}, fmt.Errorf("readability fetch error: %s returned %s", pageURL, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, _ := mime.ParseMediaType(ct)
+		if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+			return Article{}, fmt.Errorf("readability fetch error: %s is %s, not html", pageURL, mediaType)
+		}
+	}
+
+	article, err := Extract(io.LimitReader(resp.Body, maxPageSize), resp.Request.URL.String())
+	if err != nil {
+		return Article{}, fmt.Errorf("readability error for %s: %w", pageURL, err)
+	}
+	return article, nil
+}
@@ -0,0 +1,360 @@
+// Package readability extracts the main article from a web page, so items
+// of feeds that only publish a summary can be read in full and searched
+// offline. Scoring follows the approach of the original readability
+// bookmarklet: every paragraph votes for its parent and grandparent, votes
+// are weighed by link density and class names, and the best container wins
+// together with related siblings.
+package readability
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/theandyeh/gator/internal/sanitize"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var ErrNoArticle = errors.New("no article found")
+
+// minLength is the shortest text accepted as an article. Anything shorter
+// is most likely a landing page or a paywall notice.
+const minLength = 250
+
+// Article is the extracted main content of a page.
+type Article struct {
+	Title string
+	// Content is sanitized HTML with addresses resolved against the page.
+	Content string
+	// Length is the number of characters of text in Content.
+	Length int
+}
+
+var (
+	unlikely = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|header|menu|modal|nav|newsletter|pager|pagination|popup|promo|related|remark|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|advert|agegate|tweet|widget`)
+	maybe    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positive = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negative = regexp.MustCompile(`(?i)hidden|banner|combx|comment|com-|contact|foot|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget|advert`)
+)
+
+// removed elements never hold article text.
+var removed = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true,
+	atom.Form: true, atom.Nav: true, atom.Footer: true, atom.Aside: true,
+	atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+	atom.Svg: true, atom.Link: true, atom.Meta: true, atom.Object: true,
+	atom.Embed: true, atom.Dialog: true,
+}
+
+// blocks are the elements that stop a div from being scored as a paragraph.
+var blocks = map[atom.Atom]bool{
+	atom.Article: true, atom.Blockquote: true, atom.Div: true, atom.Dl: true,
+	atom.Figure: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Ul: true,
+}
+
+// Extract reads an HTML page and returns its main article. pageURL is used
+// to resolve relative links and images. ErrNoArticle is returned when the
+// page has nothing that looks like an article.
+func Extract(r io.Reader, pageURL string) (Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return Article{}, err
+	}
+
+	article := Article{Title: title(doc)}
+	prune(doc)
+
+	top, scores := candidates(doc)
+	if top == nil {
+		return Article{}, ErrNoArticle
+	}
+
+	var buf bytes.Buffer
+	for _, n := range withSiblings(top, scores) {
+		cleanConditionally(n)
+		if err := html.Render(&buf, n); err != nil {
+			return Article{}, err
+		}
+		article.Length += utf8.RuneCountInString(innerText(n))
+	}
+	if article.Length < minLength {
+		return Article{}, ErrNoArticle
+	}
+
+	article.Content = strings.TrimSpace(sanitize.HTML(buf.String(), pageURL))
+	return article, nil
+}
+
+// title prefers the Open Graph title, which rarely carries the site name,
+// over the title element.
+func title(doc *html.Node) string {
+	var og, plain string
+	walk(doc, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Meta:
+			if attr(n, "property") == "og:title" && og == "" {
+				og = strings.TrimSpace(attr(n, "content"))
+			}
+		case atom.Title:
+			if plain == "" {
+				plain = innerText(n)
+			}
+		}
+		return true
+	})
+	if og != "" {
+		return og
+	}
+	return plain
+}
+
+// prune removes elements that never hold article text, hidden elements and
+// elements whose class or id mark them as page furniture.
+func prune(doc *html.Node) {
+	var drop []*html.Node
+	walk(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if removed[n.DataAtom] || hidden(n) {
+			drop = append(drop, n)
+			return false
+		}
+		switch n.DataAtom {
+		case atom.Html, atom.Body, atom.Article, atom.A:
+			return true
+		}
+		names := attr(n, "class") + " " + attr(n, "id")
+		if unlikely.MatchString(names) && !maybe.MatchString(names) {
+			drop = append(drop, n)
+			return false
+		}
+		return true
+	})
+	for _, n := range drop {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+func hidden(n *html.Node) bool {
+	if _, ok := lookup(n, "hidden"); ok || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// candidates scores the parents and grandparents of every paragraph and
+// returns the best one.
+func candidates(doc *html.Node) (*html.Node, map[*html.Node]float64) {
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+	vote := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode || n.DataAtom == atom.Html {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			order = append(order, n)
+		}
+		scores[n] += score
+	}
+
+	walk(doc, func(n *html.Node) bool {
+		if !paragraph(n) {
+			return true
+		}
+		text := innerText(n)
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			return false
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(length/100), 3)
+		vote(n.Parent, score)
+		if n.Parent != nil {
+			vote(n.Parent.Parent, score/2)
+		}
+		return false
+	})
+
+	var top *html.Node
+	for _, n := range order {
+		scores[n] *= 1 - linkDensity(n)
+		if top == nil || scores[n] > scores[top] {
+			top = n
+		}
+	}
+	return top, scores
+}
+
+// paragraph reports whether n is scored as a paragraph: a p, pre or td, or a
+// div holding only inline content.
+func paragraph(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.P, atom.Pre, atom.Td:
+		return true
+	case atom.Div:
+		block := false
+		walk(n, func(c *html.Node) bool {
+			if c != n && blocks[c.DataAtom] {
+				block = true
+			}
+			return !block
+		})
+		return !block
+	}
+	return false
+}
+
+func initialScore(n *html.Node) float64 {
+	score := classWeight(n)
+	switch n.DataAtom {
+	case atom.Div, atom.Article:
+		score += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score += 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		score -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score -= 5
+	}
+	return score
+}
+
+func classWeight(n *html.Node) float64 {
+	var weight float64
+	for _, name := range []string{attr(n, "class"), attr(n, "id")} {
+		if name == "" {
+			continue
+		}
+		if negative.MatchString(name) {
+			weight -= 25
+		}
+		if positive.MatchString(name) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// withSiblings returns top together with the siblings that look like they
+// belong to the same article, such as a lead paragraph kept outside the
+// main container.
+func withSiblings(top *html.Node, scores map[*html.Node]float64) []*html.Node {
+	if top.Parent == nil {
+		return []*html.Node{top}
+	}
+	threshold := max(10, scores[top]*0.2)
+
+	var nodes []*html.Node
+	for s := top.Parent.FirstChild; s != nil; s = s.NextSibling {
+		if s.Type != html.ElementNode {
+			continue
+		}
+		keep := s == top
+		if score, ok := scores[s]; ok && score >= threshold {
+			keep = true
+		}
+		if s.DataAtom == atom.P {
+			text := innerText(s)
+			length := utf8.RuneCountInString(text)
+			density := linkDensity(s)
+			if length > 80 && density < 0.25 || length > 0 && density == 0 && strings.Contains(text, ". ") {
+				keep = true
+			}
+		}
+		if keep {
+			nodes = append(nodes, s)
+		}
+	}
+	return nodes
+}
+
+// cleanConditionally removes lists, tables and containers inside the
+// article that are mostly links or are marked as furniture, like share
+// boxes and related article lists.
+func cleanConditionally(root *html.Node) {
+	var drop []*html.Node
+	walk(root, func(n *html.Node) bool {
+		if n == root || n.Type != html.ElementNode {
+			return true
+		}
+		switch n.DataAtom {
+		case atom.Div, atom.Section, atom.Ul, atom.Ol, atom.Table:
+		default:
+			return true
+		}
+		if classWeight(n) < 0 || linkDensity(n) > 0.5 {
+			drop = append(drop, n)
+			return false
+		}
+		return true
+	})
+	for _, n := range drop {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+// linkDensity is the share of the text of n found inside links.
+func linkDensity(n *html.Node) float64 {
+	length := utf8.RuneCountInString(innerText(n))
+	if length == 0 {
+		return 0
+	}
+	var links int
+	walk(n, func(c *html.Node) bool {
+		if c.DataAtom == atom.A {
+			links += utf8.RuneCountInString(innerText(c))
+			return false
+		}
+		return true
+	})
+	return float64(links) / float64(length)
+}
+
+// innerText returns the text of n with whitespace collapsed.
+func innerText(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+		return true
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// walk calls fn for n and its descendants in document order. Children are
+// skipped when fn returns false.
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func lookup(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := lookup(n, key)
+	return v
+}
@@ -0,0 +1,119 @@
+package readability
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func extractFixture(t *testing.T, name, pageURL string) (Article, error) {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return Extract(f, pageURL)
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		title    string
+		contains []string
+		missing  []string
+	}{
+		{
+			fixture: "blog.html",
+			title:   "Writing a feed reader in Go",
+			contains: []string{
+				"For years I have read the web through feeds",
+				"Every post is keyed by its url",
+				`<img src="https://shed.example.com/images/terminal.png"`,
+			},
+			missing: []string{"Archive", "Share on", "Great post", "Recent posts", "Subscribe", "Copyright", "analytics"},
+		},
+		{
+			fixture: "news.html",
+			title:   "City council approves new bike lanes - The Daily Example",
+			contains: []string{
+				"The council voted on Tuesday",
+				"Opponents argued",
+				"Work on the first section",
+			},
+			missing: []string{"mattresses", "Bus fares", "Sport", "1 euro", "Tracking consent"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			article, err := extractFixture(t, tt.fixture, "https://shed.example.com/2026/03/feed-reader")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if article.Title != tt.title {
+				t.Errorf("title = %q, want %q", article.Title, tt.title)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(article.Content, s) {
+					t.Errorf("content is missing %q:\n%s", s, article.Content)
+				}
+			}
+			for _, s := range tt.missing {
+				if strings.Contains(article.Content, s) {
+					t.Errorf("content should not contain %q:\n%s", s, article.Content)
+				}
+			}
+			if article.Length < minLength {
+				t.Errorf("length = %d, want at least %d", article.Length, minLength)
+			}
+		})
+	}
+}
+
+func TestExtractNoArticle(t *testing.T) {
+	if _, err := extractFixture(t, "index.html", "https://shed.example.com/archive"); !errors.Is(err, ErrNoArticle) {
+		t.Errorf("err = %v, want ErrNoArticle", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "blog.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/post":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(page)
+		case "/moved":
+			http.Redirect(w, r, "/post", http.StatusMovedPermanently)
+		case "/file.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.7"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	article, err := Fetch(context.Background(), srv.Client(), srv.URL+"/moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(article.Content, `src="`+srv.URL+`/images/terminal.png"`) {
+		t.Errorf("images are not resolved against the final url:\n%s", article.Content)
+	}
+
+	for _, path := range []string{"/file.pdf", "/missing"} {
+		if _, err := Fetch(context.Background(), srv.Client(), srv.URL+path); err == nil {
+			t.Errorf("fetching %s should fail", path)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package rss
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var ErrDeniedAddress = errors.New("connection to denied address")
+
+// DefaultDenyCIDRs are the ranges feeds may never be fetched from unless
+// private urls are explicitly allowed: loopback, private, link-local (which
+// includes cloud metadata services), carrier-grade NAT, multicast and
+// reserved ranges.
+var DefaultDenyCIDRs = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+}
+
+// DialPolicy decides which resolved addresses the fetcher may connect to.
+// Addresses inside an Allow range are always permitted, otherwise addresses
+// inside a Deny range are refused.
+type DialPolicy struct {
+	Allow []*net.IPNet
+	Deny  []*net.IPNet
+}
+
+var (
+	clientMu sync.RWMutex
+	client   = NewClient(mustDefaultPolicy())
+)
+
+func NewDialPolicy(allow, deny []string, allowPrivate bool) (*DialPolicy, error) {
+	p := &DialPolicy{}
+
+	if !allowPrivate {
+		deny = append(append([]string{}, DefaultDenyCIDRs...), deny...)
+	}
+
+	for _, cidr := range allow {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("rss dial policy error parsing allow cidr: %w", err)
+		}
+		p.Allow = append(p.Allow, n)
+	}
+
+	for _, cidr := range deny {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("rss dial policy error parsing deny cidr: %w", err)
+		}
+		p.Deny = append(p.Deny, n)
+	}
+
+	return p, nil
+}
+
+func mustDefaultPolicy() *DialPolicy {
+	p, err := NewDialPolicy(nil, nil, false)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *DialPolicy) Check(ip net.IP) error {
+	for _, n := range p.Allow {
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+	for _, n := range p.Deny {
+		if n.Contains(ip) {
+			return fmt.Errorf("%w: %s is in %s", ErrDeniedAddress, ip, n)
+		}
+	}
+	return nil
+}
+
+// control runs after name resolution and right before each connection is
+// made, so it also covers every hop of a redirect chain.
+func (p *DialPolicy) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDeniedAddress, address)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %s is not an ip address", ErrDeniedAddress, host)
+	}
+
+	return p.Check(ip)
+}
+
+// NewClient returns an http client whose connections are checked against
+// the policy. Proxies are disabled since they would hide the real target.
+func NewClient(p *DialPolicy) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   p.control,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("refusing redirect to %s url", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// SetDialPolicy replaces the policy used by FetchFeed.
+func SetDialPolicy(p *DialPolicy) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	client = NewClient(p)
+}
+
+// HTTPClient returns the policy checked client used for all outgoing fetches.
+func HTTPClient() *http.Client {
+	clientMu.RLock()
+	defer clientMu.RUnlock()
+	return client
+}
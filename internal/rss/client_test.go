@@ -0,0 +1,117 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title><link>https://example.com</link>
+<item><title>Hello</title><link>https://example.com/hello</link></item>
+</channel></rss>`
+
+func TestDialPolicyCheck(t *testing.T) {
+	p, err := NewDialPolicy([]string{"10.1.2.0/24"}, []string{"203.0.113.0/24"}, false)
+	if err != nil {
+		t.Fatalf("NewDialPolicy failed: %v", err)
+	}
+
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"169.254.169.254", false},
+		{"10.0.0.1", false},
+		{"10.1.2.3", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"203.0.113.7", false},
+	}
+
+	for _, tt := range tests {
+		err := p.Check(net.ParseIP(tt.ip))
+		if tt.allowed && err != nil {
+			t.Errorf("Expected %s to be allowed, got %v", tt.ip, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrDeniedAddress) {
+			t.Errorf("Expected %s to be denied, got %v", tt.ip, err)
+		}
+	}
+}
+
+func TestNewDialPolicyInvalidCIDR(t *testing.T) {
+	if _, err := NewDialPolicy([]string{"not-a-cidr"}, nil, false); err == nil {
+		t.Error("Expected error for invalid cidr")
+	}
+}
+
+func TestFetchFeedDeniedAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testFeed))
+	}))
+	defer srv.Close()
+
+	SetDialPolicy(mustDefaultPolicy())
+	_, err := FetchFeed(context.Background(), srv.URL)
+	if !errors.Is(err, ErrDeniedAddress) {
+		t.Fatalf("Expected denied address error, got %v", err)
+	}
+}
+
+func TestFetchFeedRedirectToDeniedAddress(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Skipf("cannot listen on 127.0.0.2: %v", err)
+	}
+	target := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testFeed))
+	}))
+	target.Listener.Close()
+	target.Listener = l
+	target.Start()
+	defer target.Close()
+
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer redirector.Close()
+
+	p, err := NewDialPolicy([]string{"127.0.0.1/32"}, nil, false)
+	if err != nil {
+		t.Fatalf("NewDialPolicy failed: %v", err)
+	}
+	SetDialPolicy(p)
+	defer SetDialPolicy(mustDefaultPolicy())
+
+	_, err = FetchFeed(context.Background(), redirector.URL)
+	if !errors.Is(err, ErrDeniedAddress) {
+		t.Fatalf("Expected redirect to denied address to fail, got %v", err)
+	}
+}
+
+func TestFetchFeedAllowedAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testFeed))
+	}))
+	defer srv.Close()
+
+	p, err := NewDialPolicy([]string{"127.0.0.0/8"}, nil, false)
+	if err != nil {
+		t.Fatalf("NewDialPolicy failed: %v", err)
+	}
+	SetDialPolicy(p)
+	defer SetDialPolicy(mustDefaultPolicy())
+
+	feed, err := FetchFeed(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("FetchFeed failed: %v", err)
+	}
+	if feed.Channel.Title != "Test" {
+		t.Errorf("Expected title 'Test', got '%s'", feed.Channel.Title)
+	}
+}
@@ -0,0 +1,85 @@
+package rss
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media is a file attached to an item, from an enclosure or a Media RSS
+// content element.
+type Media struct {
+	URL    string
+	Type   string
+	Length int64
+	// Duration is only known for Media RSS content that states it.
+	Duration time.Duration
+}
+
+// Media returns the files attached to the item, enclosures first, without
+// duplicates or entries lacking an address.
+func (item RSSItem) Media() []Media {
+	var media []Media
+	seen := make(map[string]bool)
+	add := func(m Media) {
+		m.URL = strings.TrimSpace(m.URL)
+		if m.URL == "" || seen[m.URL] {
+			return
+		}
+		seen[m.URL] = true
+		media = append(media, m)
+	}
+
+	for _, e := range item.Enclosure {
+		add(Media{URL: e.URL, Type: mediaType(e.Type, ""), Length: parseLength(e.Length)})
+	}
+	for _, c := range append(append([]RSSMediaContent{}, item.MediaContent...), item.MediaGroup.Content...) {
+		m := Media{URL: c.URL, Type: mediaType(c.Type, c.Medium), Length: parseLength(c.FileSize)}
+		if seconds, err := strconv.ParseFloat(strings.TrimSpace(c.Duration), 64); err == nil && seconds > 0 {
+			m.Duration = time.Duration(seconds * float64(time.Second))
+		}
+		add(m)
+	}
+	return media
+}
+
+// mediaType normalises a MIME type, falling back to the Media RSS medium
+// when the type is missing.
+func mediaType(t, medium string) string {
+	if mt, _, err := mime.ParseMediaType(t); err == nil {
+		return mt
+	}
+	switch medium {
+	case "audio", "video", "image":
+		return medium + "/*"
+	}
+	return "application/octet-stream"
+}
+
+func parseLength(s string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// ParseDuration parses an iTunes episode duration, given either in seconds
+// or as MM:SS or HH:MM:SS.
+func ParseDuration(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Count(s, ":") > 2 {
+		return 0, false
+	}
+
+	var seconds float64
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
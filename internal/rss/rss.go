@@ -7,22 +7,95 @@ import (
 	"html"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+		Language    string `xml:"language"`
+		Generator   string `xml:"generator"`
+		// ItunesImage comes before Image since an element name without a
+		// namespace matches itunes:image too.
+		ItunesImage ItunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+		Image       RSSImage    `xml:"image"`
+		Item        []RSSItem   `xml:"item"`
 	} `xml:"channel"`
 }
 
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	PubDate     string    `xml:"pubDate"`
+	Author      string    `xml:"author"`
+	Creator     string    `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	GUID        RSSGUID   `xml:"guid"`
+	Categories  []string  `xml:"category"`
+	Comments    string    `xml:"comments"`
+	Content     string    `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Source      RSSSource `xml:"source"`
+
+	Enclosure    []RSSEnclosure    `xml:"enclosure"`
+	MediaContent []RSSMediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroup   struct {
+		Content []RSSMediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+	Duration    string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Season      string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+	Episode     string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ItunesImage ItunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+}
+
+type RSSGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink string `xml:"isPermaLink,attr"`
+}
+
+// PermaLink returns the guid when it is the address of the item, which the
+// RSS spec assumes unless isPermaLink is false.
+func (item RSSItem) PermaLink() string {
+	guid := strings.TrimSpace(item.GUID.Value)
+	if strings.EqualFold(strings.TrimSpace(item.GUID.IsPermaLink), "false") {
+		return ""
+	}
+	if !strings.HasPrefix(guid, "http://") && !strings.HasPrefix(guid, "https://") {
+		return ""
+	}
+	return guid
+}
+
+// RSSSource names the feed an item was republished from.
+type RSSSource struct {
+	Title string `xml:",chardata"`
+	URL   string `xml:"url,attr"`
+}
+
+type RSSImage struct {
+	URL   string `xml:"url"`
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+}
+
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type RSSMediaContent struct {
+	URL      string `xml:"url,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Type     string `xml:"type,attr"`
+	Medium   string `xml:"medium,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+type ItunesImage struct {
+	Href string `xml:"href,attr"`
 }
 
 func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
@@ -34,7 +107,7 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 
 	req.Header.Set("User-Agent", "gator")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := HTTPClient().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("rss fetch error: %w", err)
 	}
@@ -53,10 +126,39 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 
 	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
 	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
-	for _, item := range feed.Channel.Item {
-		item.Title = html.UnescapeString(item.Title)
-		item.Description = html.UnescapeString(item.Description)
+	for i := range feed.Channel.Item {
+		feed.Channel.Item[i].Title = html.UnescapeString(feed.Channel.Item[i].Title)
+		feed.Channel.Item[i].Description = html.UnescapeString(feed.Channel.Item[i].Description)
+		feed.Channel.Item[i].Source.Title = html.UnescapeString(feed.Channel.Item[i].Source.Title)
+		for j, category := range feed.Channel.Item[i].Categories {
+			feed.Channel.Item[i].Categories[j] = html.UnescapeString(category)
+		}
 	}
 
 	return &feed, nil
 }
+
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ParseDate parses the publication dates found in the wild, which rarely
+// stick to a single layout.
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,160 @@
+package rss
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	expected := time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC)
+
+	inputs := []string{
+		"Tue, 05 Mar 2024 14:30:00 +0000",
+		"Tue, 5 Mar 2024 14:30:00 +0000",
+		"2024-03-05T14:30:00Z",
+		" 05 Mar 24 14:30 +0000 ",
+	}
+
+	for _, input := range inputs {
+		got, ok := ParseDate(input)
+		if !ok {
+			t.Errorf("ParseDate(%q) failed", input)
+			continue
+		}
+		if !got.Equal(expected) {
+			t.Errorf("ParseDate(%q): expected %s, got %s", input, expected, got)
+		}
+	}
+
+	if _, ok := ParseDate("yesterday"); ok {
+		t.Error("Expected ParseDate to fail for an unparseable date")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"3725":     3725 * time.Second,
+		"62:05":    62*time.Minute + 5*time.Second,
+		"01:02:05": time.Hour + 2*time.Minute + 5*time.Second,
+	}
+	for input, expected := range tests {
+		got, ok := ParseDuration(input)
+		if !ok || got != expected {
+			t.Errorf("ParseDuration(%q) = %s, %v, expected %s", input, got, ok, expected)
+		}
+	}
+
+	for _, input := range []string{"", "an hour", "1:2:3:4", "-5"} {
+		if _, ok := ParseDuration(input); ok {
+			t.Errorf("Expected ParseDuration to fail for %q", input)
+		}
+	}
+}
+
+func TestItemMedia(t *testing.T) {
+	data := `<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:media="http://search.yahoo.com/mrss/">
+<channel>
+<title>Ship It</title>
+<itunes:image href="https://example.com/show.jpg"/>
+<item>
+<title>Episode 12</title>
+<enclosure url="https://cdn.example.com/12.mp3" length="31337" type="audio/mpeg; charset=binary"/>
+<media:content url="https://cdn.example.com/12.mp3" fileSize="31337" type="audio/mpeg"/>
+<media:group>
+<media:content url="https://cdn.example.com/12.mp4" medium="video" duration="1805.5"/>
+</media:group>
+<itunes:duration>30:05</itunes:duration>
+<itunes:season>2</itunes:season>
+<itunes:episode>12</itunes:episode>
+<itunes:image href="https://example.com/12.jpg"/>
+</item>
+</channel>
+</rss>`
+
+	var feed RSSFeed
+	if err := xml.Unmarshal([]byte(data), &feed); err != nil {
+		t.Fatal(err)
+	}
+	if feed.Channel.ItunesImage.Href != "https://example.com/show.jpg" {
+		t.Errorf("channel artwork = %q", feed.Channel.ItunesImage.Href)
+	}
+
+	item := feed.Channel.Item[0]
+	if item.Duration != "30:05" || item.Season != "2" || item.Episode != "12" || item.ItunesImage.Href != "https://example.com/12.jpg" {
+		t.Errorf("itunes tags not parsed: %+v", item)
+	}
+
+	expected := []Media{
+		{URL: "https://cdn.example.com/12.mp3", Type: "audio/mpeg", Length: 31337},
+		{URL: "https://cdn.example.com/12.mp4", Type: "video/*", Duration: 1805500 * time.Millisecond},
+	}
+	got := item.Media()
+	if len(got) != len(expected) {
+		t.Fatalf("Media() = %+v, expected %+v", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Media()[%d] = %+v, expected %+v", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestItemMetadata(t *testing.T) {
+	data := `<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel>
+<title>Engineering</title>
+<language>en-gb</language>
+<generator>Hugo 0.140</generator>
+<itunes:image href="https://example.com/itunes.jpg"/>
+<image><url>https://example.com/logo.png</url><title>Engineering</title><link>https://example.com/</link></image>
+<item>
+<title>Postmortem</title>
+<guid isPermaLink="false">tag:example.com,2026:42</guid>
+<dc:creator>Robin</dc:creator>
+<category>Incidents</category>
+<category>Databases</category>
+<comments>https://example.com/42#comments</comments>
+<content:encoded><![CDATA[<p>The <b>full</b> story</p>]]></content:encoded>
+<source url="https://ops.example.com/feed.xml">Ops Weekly</source>
+</item>
+<item>
+<title>Permalink only</title>
+<guid>https://example.com/43</guid>
+</item>
+</channel>
+</rss>`
+
+	var feed RSSFeed
+	if err := xml.Unmarshal([]byte(data), &feed); err != nil {
+		t.Fatal(err)
+	}
+	channel := feed.Channel
+	if channel.Language != "en-gb" || channel.Generator != "Hugo 0.140" {
+		t.Errorf("language %q, generator %q", channel.Language, channel.Generator)
+	}
+	if channel.Image.URL != "https://example.com/logo.png" || channel.ItunesImage.Href != "https://example.com/itunes.jpg" {
+		t.Errorf("image %+v, itunes image %+v", channel.Image, channel.ItunesImage)
+	}
+
+	item := channel.Item[0]
+	if item.GUID.Value != "tag:example.com,2026:42" || item.PermaLink() != "" {
+		t.Errorf("guid %+v, permalink %q", item.GUID, item.PermaLink())
+	}
+	if item.Creator != "Robin" || item.Comments != "https://example.com/42#comments" {
+		t.Errorf("creator %q, comments %q", item.Creator, item.Comments)
+	}
+	if len(item.Categories) != 2 || item.Categories[0] != "Incidents" || item.Categories[1] != "Databases" {
+		t.Errorf("categories %q", item.Categories)
+	}
+	if item.Content != "<p>The <b>full</b> story</p>" {
+		t.Errorf("content %q", item.Content)
+	}
+	if item.Source.Title != "Ops Weekly" || item.Source.URL != "https://ops.example.com/feed.xml" {
+		t.Errorf("source %+v", item.Source)
+	}
+
+	if link := channel.Item[1].PermaLink(); link != "https://example.com/43" {
+		t.Errorf("permalink = %q, expected the guid", link)
+	}
+}
@@ -0,0 +1,204 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	FieldFeed        = "feed"
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldAuthor      = "author"
+	FieldDomain      = "domain"
+	FieldCategory    = "category"
+	// FieldText matches the title, description and content together.
+	FieldText = "text"
+)
+
+const (
+	MatchSubstring = "substring"
+	MatchRegex     = "regex"
+	MatchKeywords  = "keywords"
+)
+
+const (
+	ActionHide     = "hide"
+	ActionMarkRead = "read"
+	ActionStar     = "star"
+	ActionTag      = "tag"
+	ActionNotify   = "notify"
+)
+
+var (
+	ErrUnknownField  = errors.New("unknown field, expected feed, title, description, text, author, domain or category")
+	ErrUnknownMatch  = errors.New("unknown match type, expected substring, regex or keywords")
+	ErrUnknownAction = errors.New("unknown action, expected hide, read, star, tag or notify")
+	ErrEmptyPattern  = errors.New("pattern must not be empty")
+	ErrMissingTag    = errors.New("tag action needs a tag name")
+)
+
+// Rule is a single condition on an incoming item and the action to take
+// when it matches.
+type Rule struct {
+	Field     string
+	Match     string
+	Pattern   string
+	Action    string
+	ActionArg string
+}
+
+// Item is the view of a post that rules are evaluated against.
+type Item struct {
+	Feed  string
+	Title string
+	// Description and Content are plain text, not the HTML the feed sent.
+	Description string
+	Content     string
+	Author      string
+	Link        string
+	// Category is the user's category of the feed and Categories are the
+	// categories the feed gave the item. The category field matches both.
+	Category   string
+	Categories []string
+}
+
+// Matcher is a validated rule ready to be evaluated against many items.
+type Matcher struct {
+	Rule
+	re       *regexp.Regexp
+	keywords []string
+}
+
+func Compile(r Rule) (*Matcher, error) {
+	switch r.Field {
+	case FieldFeed, FieldTitle, FieldDescription, FieldText, FieldAuthor, FieldDomain, FieldCategory:
+	default:
+		return nil, fmt.Errorf("rules error: %w: %q", ErrUnknownField, r.Field)
+	}
+
+	switch r.Action {
+	case ActionHide, ActionMarkRead, ActionStar, ActionNotify:
+	case ActionTag:
+		if strings.TrimSpace(r.ActionArg) == "" {
+			return nil, fmt.Errorf("rules error: %w", ErrMissingTag)
+		}
+	default:
+		return nil, fmt.Errorf("rules error: %w: %q", ErrUnknownAction, r.Action)
+	}
+
+	if strings.TrimSpace(r.Pattern) == "" {
+		return nil, fmt.Errorf("rules error: %w", ErrEmptyPattern)
+	}
+
+	m := &Matcher{Rule: r}
+	switch r.Match {
+	case MatchSubstring:
+	case MatchRegex:
+		re, err := regexp.Compile("(?i)" + r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rules error: invalid regex: %w", err)
+		}
+		m.re = re
+	case MatchKeywords:
+		for _, kw := range strings.Split(r.Pattern, ",") {
+			if kw = strings.ToLower(strings.TrimSpace(kw)); kw != "" {
+				m.keywords = append(m.keywords, kw)
+			}
+		}
+		if len(m.keywords) == 0 {
+			return nil, fmt.Errorf("rules error: %w", ErrEmptyPattern)
+		}
+	default:
+		return nil, fmt.Errorf("rules error: %w: %q", ErrUnknownMatch, r.Match)
+	}
+
+	return m, nil
+}
+
+// Matches reports whether the rule's condition holds for item. Substring and
+// keyword matches ignore case; keywords match if any of them is present.
+func (m *Matcher) Matches(item Item) bool {
+	if m.Field == FieldCategory {
+		for _, value := range append([]string{item.Category}, item.Categories...) {
+			if m.matchValue(value) {
+				return true
+			}
+		}
+		return false
+	}
+	return m.matchValue(m.value(item))
+}
+
+func (m *Matcher) matchValue(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	switch m.Match {
+	case MatchRegex:
+		return m.re.MatchString(value)
+	case MatchKeywords:
+		lower := strings.ToLower(value)
+		for _, kw := range m.keywords {
+			if strings.Contains(lower, kw) {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(strings.ToLower(value), strings.ToLower(m.Pattern))
+}
+
+func (m *Matcher) value(item Item) string {
+	switch m.Field {
+	case FieldFeed:
+		return item.Feed
+	case FieldTitle:
+		return item.Title
+	case FieldDescription:
+		return item.Description
+	case FieldText:
+		return strings.TrimSpace(item.Title + "\n" + item.Description + "\n" + item.Content)
+	case FieldAuthor:
+		return item.Author
+	case FieldDomain:
+		u, err := url.Parse(item.Link)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	}
+	return ""
+}
+
+var filterOps = map[string]string{
+	"contains": MatchSubstring,
+	"matches":  MatchRegex,
+	"any":      MatchKeywords,
+}
+
+// ParseFilter turns a filter expression of the form "<field> <op> <pattern>"
+// into a compiled notify rule, where op is contains (substring), matches (regex) or
+// any (comma separated keywords), e.g. "title matches CVE-\d+".
+func ParseFilter(expr string) (*Matcher, error) {
+	fields := strings.Fields(expr)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("rules error: filter %q is not of the form <field> <contains|matches|any> <pattern>", expr)
+	}
+	match, ok := filterOps[fields[1]]
+	if !ok {
+		return nil, fmt.Errorf("rules error: unknown filter operator %q, expected contains, matches or any", fields[1])
+	}
+
+	// The pattern is everything after the operator, keeping inner spacing.
+	rest := strings.TrimSpace(expr)
+	for _, f := range fields[:2] {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, f))
+	}
+
+	return Compile(Rule{Field: fields[0], Match: match, Pattern: rest, Action: ActionNotify})
+}
@@ -0,0 +1,105 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+)
+
+var testItem = Item{
+	Feed:        "Engineering Weekly",
+	Title:       "Sponsored: Try our new database",
+	Description: "A deep dive into Postgres vacuum internals",
+	Content:     "Autovacuum settings worth tuning",
+	Author:      "Jane Doe",
+	Link:        "https://www.example.com/posts/vacuum",
+	Category:    "Work",
+	Categories:  []string{"Databases", "PostgreSQL"},
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		rule    Rule
+		matches bool
+	}{
+		{Rule{Field: FieldTitle, Match: MatchSubstring, Pattern: "sponsored", Action: ActionHide}, true},
+		{Rule{Field: FieldTitle, Match: MatchSubstring, Pattern: "gator", Action: ActionHide}, false},
+		{Rule{Field: FieldDescription, Match: MatchRegex, Pattern: `postgre(s|sql)\s+vacuum`, Action: ActionStar}, true},
+		{Rule{Field: FieldDescription, Match: MatchRegex, Pattern: `^vacuum`, Action: ActionStar}, false},
+		{Rule{Field: FieldDescription, Match: MatchKeywords, Pattern: "mysql, vacuum", Action: ActionNotify}, true},
+		{Rule{Field: FieldText, Match: MatchKeywords, Pattern: "sponsored, vacuum", Action: ActionNotify}, true},
+		{Rule{Field: FieldText, Match: MatchRegex, Pattern: `(?s)sponsored.*vacuum`, Action: ActionNotify}, true},
+		{Rule{Field: FieldText, Match: MatchSubstring, Pattern: "worth tuning", Action: ActionNotify}, true},
+		{Rule{Field: FieldDescription, Match: MatchSubstring, Pattern: "worth tuning", Action: ActionNotify}, false},
+		{Rule{Field: FieldAuthor, Match: MatchSubstring, Pattern: "jane", Action: ActionMarkRead}, true},
+		{Rule{Field: FieldDomain, Match: MatchSubstring, Pattern: "example.com", Action: ActionHide}, true},
+		{Rule{Field: FieldDomain, Match: MatchRegex, Pattern: `^www\.`, Action: ActionHide}, false},
+		{Rule{Field: FieldCategory, Match: MatchSubstring, Pattern: "work", Action: ActionTag, ActionArg: "job"}, true},
+		{Rule{Field: FieldCategory, Match: MatchRegex, Pattern: `^postgres`, Action: ActionStar}, true},
+		{Rule{Field: FieldCategory, Match: MatchKeywords, Pattern: "mysql, sqlite", Action: ActionStar}, false},
+		{Rule{Field: FieldFeed, Match: MatchKeywords, Pattern: "weekly", Action: ActionHide}, true},
+	}
+
+	for _, tt := range tests {
+		m, err := Compile(tt.rule)
+		if err != nil {
+			t.Errorf("Compile(%+v) failed: %v", tt.rule, err)
+			continue
+		}
+		if got := m.Matches(testItem); got != tt.matches {
+			t.Errorf("Rule %+v: expected match %v, got %v", tt.rule, tt.matches, got)
+		}
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	tests := []struct {
+		rule     Rule
+		expected error
+	}{
+		{Rule{Field: "body", Match: MatchSubstring, Pattern: "x", Action: ActionHide}, ErrUnknownField},
+		{Rule{Field: FieldTitle, Match: "glob", Pattern: "x", Action: ActionHide}, ErrUnknownMatch},
+		{Rule{Field: FieldTitle, Match: MatchSubstring, Pattern: "x", Action: "delete"}, ErrUnknownAction},
+		{Rule{Field: FieldTitle, Match: MatchSubstring, Pattern: " ", Action: ActionHide}, ErrEmptyPattern},
+		{Rule{Field: FieldTitle, Match: MatchKeywords, Pattern: ", ,", Action: ActionHide}, ErrEmptyPattern},
+		{Rule{Field: FieldTitle, Match: MatchSubstring, Pattern: "x", Action: ActionTag}, ErrMissingTag},
+	}
+
+	for _, tt := range tests {
+		if _, err := Compile(tt.rule); !errors.Is(err, tt.expected) {
+			t.Errorf("Compile(%+v): expected %v, got %v", tt.rule, tt.expected, err)
+		}
+	}
+
+	if _, err := Compile(Rule{Field: FieldTitle, Match: MatchRegex, Pattern: "(", Action: ActionHide}); err == nil {
+		t.Error("Expected error for invalid regex")
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		expr    string
+		matches bool
+	}{
+		{"title contains try our", true},
+		{"description matches postgres\\s+vacuum", true},
+		{"text any mysql, sqlite", false},
+		{"domain contains example.org", false},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseFilter(tt.expr)
+		if err != nil {
+			t.Errorf("ParseFilter(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := m.Matches(testItem); got != tt.matches {
+			t.Errorf("Filter %q: expected match %v, got %v", tt.expr, tt.matches, got)
+		}
+	}
+
+	for _, expr := range []string{"", "title contains", "title like x", "body contains x"} {
+		if _, err := ParseFilter(expr); err == nil {
+			t.Errorf("ParseFilter(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Package sanitize cleans feed HTML before gator stores or serves it. It
+// keeps an allowlist of formatting elements and attributes and drops
+// everything else: scripts, event handlers, frames, forms, styles, tracking
+// pixels and links with schemes other than http, https and mailto.
+package sanitize
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowed maps each kept element to the attributes it may keep.
+var allowed = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Abbr:       {"title"},
+	atom.B:          nil,
+	atom.Blockquote: {"cite"},
+	atom.Br:         nil,
+	atom.Caption:    nil,
+	atom.Cite:       nil,
+	atom.Code:       nil,
+	atom.Dd:         nil,
+	atom.Del:        nil,
+	atom.Details:    nil,
+	atom.Dfn:        nil,
+	atom.Div:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Ins:        nil,
+	atom.Kbd:        nil,
+	atom.Li:         nil,
+	atom.Mark:       nil,
+	atom.Ol:         {"start"},
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Q:          {"cite"},
+	atom.S:          nil,
+	atom.Samp:       nil,
+	atom.Small:      nil,
+	atom.Span:       nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Summary:    nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Thead:      nil,
+	atom.Time:       {"datetime"},
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+}
+
+// dropped elements are removed with their content. Other unknown elements
+// are unwrapped, keeping their text.
+var dropped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Frame: true,
+	atom.Frameset: true, atom.Object: true, atom.Embed: true, atom.Applet: true,
+	atom.Form: true, atom.Input: true, atom.Button: true, atom.Select: true,
+	atom.Textarea: true, atom.Noscript: true, atom.Template: true, atom.Svg: true,
+	atom.Math: true, atom.Link: true, atom.Meta: true, atom.Base: true,
+	atom.Head: true, atom.Title: true, atom.Audio: true, atom.Video: true,
+	atom.Canvas: true, atom.Dialog: true,
+}
+
+// urlAttrs hold addresses and are only kept with a safe scheme.
+var urlAttrs = map[string]bool{"href": true, "src": true, "cite": true}
+
+// HTML returns the safe part of src. Relative addresses are resolved
+// against base, usually the item link, since the result is shown away from
+// the site it came from.
+func HTML(src, base string) string {
+	if strings.TrimSpace(src) == "" {
+		return ""
+	}
+	var baseURL *url.URL
+	if base != "" {
+		baseURL, _ = url.Parse(base)
+	}
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(src), body)
+	if err != nil {
+		return html.EscapeString(src)
+	}
+
+	out := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	for _, n := range nodes {
+		clean(n, out, baseURL)
+	}
+
+	var b strings.Builder
+	for c := out.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&b, c); err != nil {
+			return html.EscapeString(src)
+		}
+	}
+	return b.String()
+}
+
+// clean appends the safe copy of n to parent.
+func clean(n *html.Node, parent *html.Node, base *url.URL) {
+	switch n.Type {
+	case html.TextNode:
+		parent.AppendChild(&html.Node{Type: html.TextNode, Data: n.Data})
+		return
+	case html.ElementNode:
+	default:
+		// Comments, doctypes and the like are dropped, documents unwrapped.
+		if n.Type == html.DocumentNode {
+			cleanChildren(n, parent, base)
+		}
+		return
+	}
+
+	if dropped[n.DataAtom] {
+		return
+	}
+	attrs, ok := allowed[n.DataAtom]
+	if !ok {
+		cleanChildren(n, parent, base)
+		return
+	}
+
+	el := &html.Node{Type: html.ElementNode, Data: n.Data, DataAtom: n.DataAtom}
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !slices.Contains(attrs, a.Key) {
+			continue
+		}
+		if urlAttrs[a.Key] {
+			safe, ok := safeURL(a.Val, base, n.DataAtom == atom.Img)
+			if !ok {
+				continue
+			}
+			a.Val = safe
+		}
+		el.Attr = append(el.Attr, html.Attribute{Key: a.Key, Val: a.Val})
+	}
+
+	switch n.DataAtom {
+	case atom.Img:
+		if isTrackingPixel(el) || attr(el, "src") == "" {
+			return
+		}
+		el.Attr = append(el.Attr, html.Attribute{Key: "referrerpolicy", Val: "no-referrer"})
+	case atom.A:
+		if attr(el, "href") != "" {
+			el.Attr = append(el.Attr, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+		}
+	}
+
+	parent.AppendChild(el)
+	cleanChildren(n, el, base)
+}
+
+func cleanChildren(n *html.Node, parent *html.Node, base *url.URL) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		clean(c, parent, base)
+	}
+}
+
+// safeURL resolves raw against base and accepts http and https, and mailto
+// for links. Images may not use mailto.
+func safeURL(raw string, base *url.URL, image bool) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || raw == "" {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return u.String(), true
+	case "mailto":
+		return u.String(), !image
+	}
+	return "", false
+}
+
+// isTrackingPixel reports images sized 1x1 or smaller, which only exist to
+// tell the publisher the item was opened.
+func isTrackingPixel(img *html.Node) bool {
+	w, werr := strconv.Atoi(strings.TrimSuffix(attr(img, "width"), "px"))
+	h, herr := strconv.Atoi(strings.TrimSuffix(attr(img, "height"), "px"))
+	return (werr == nil && w <= 1) || (herr == nil && h <= 1)
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
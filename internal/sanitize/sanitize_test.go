@@ -0,0 +1,74 @@
+package sanitize
+
+import "testing"
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name:     "formatting is kept",
+			src:      `<p>Hello <b>bold</b> <em>world</em></p><ul><li>one</li></ul>`,
+			expected: `<p>Hello <b>bold</b> <em>world</em></p><ul><li>one</li></ul>`,
+		},
+		{
+			name:     "scripts and styles are removed with their content",
+			src:      `<p>a<script>alert(1)</script><style>p{color:red}</style>b</p>`,
+			expected: `<p>ab</p>`,
+		},
+		{
+			name:     "event handlers and styles are dropped",
+			src:      `<p onclick="steal()" style="display:none" class="x">hi</p>`,
+			expected: `<p>hi</p>`,
+		},
+		{
+			name:     "dangerous schemes are dropped",
+			src:      `<a href="javascript:alert(1)">x</a><a href=" JAVASCRIPT:alert(1)">y</a><img src="data:image/png;base64,AAAA" alt="z">`,
+			expected: `<a>x</a><a>y</a>`,
+		},
+		{
+			name:     "links are resolved and marked",
+			src:      `<a href="/post" target="_blank">post</a> <a href="mailto:a@example.com">mail</a>`,
+			expected: `<a href="https://example.com/post" rel="nofollow noopener noreferrer">post</a> <a href="mailto:a@example.com" rel="nofollow noopener noreferrer">mail</a>`,
+		},
+		{
+			name:     "frames and forms are removed",
+			src:      `<iframe src="https://evil.example.com"></iframe><form action="/x"><input name="q"></form>ok`,
+			expected: `ok`,
+		},
+		{
+			name:     "tracking pixels are removed",
+			src:      `<img src="https://t.example.com/p.gif" width="1" height="1"><img src="https://example.com/a.png" alt="chart" width="600">`,
+			expected: `<img src="https://example.com/a.png" alt="chart" width="600" referrerpolicy="no-referrer"/>`,
+		},
+		{
+			name:     "unknown elements are unwrapped",
+			src:      `<custom-el data-x="1"><font color="red">text</font></custom-el><!-- comment -->`,
+			expected: `text`,
+		},
+		{
+			name:     "text is escaped",
+			src:      `5 &lt; 6 &amp; "quotes"`,
+			expected: `5 &lt; 6 &amp; &#34;quotes&#34;`,
+		},
+		{
+			name:     "broken markup is closed",
+			src:      `<p><b>unclosed`,
+			expected: `<p><b>unclosed</b></p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := HTML(tt.src, "https://example.com/feed/item"); got != tt.expected {
+			t.Errorf("%s:\nexpected %s\ngot      %s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestHTMLEmpty(t *testing.T) {
+	if got := HTML("  ", ""); got != "" {
+		t.Errorf("Expected empty output, got %q", got)
+	}
+}
@@ -0,0 +1,402 @@
+// Package tui is a full screen terminal reader: a pane of feeds and
+// categories, the items of the selected one and a preview of the selected
+// item.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/database"
+)
+
+// Store is the subset of database.Queries the reader uses.
+type Store interface {
+	GetFeedFollowsByUserID(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsByUserIDRow, error)
+	GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error)
+	MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error
+	MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) (int64, error)
+	CreatePostStar(ctx context.Context, arg database.CreatePostStarParams) error
+	DeletePostStar(ctx context.Context, arg database.DeletePostStarParams) (int64, error)
+}
+
+type Options struct {
+	// Refresh fetches new posts, for example by scraping the followed feeds.
+	// It runs in its own goroutine while the reader stays usable, so it must
+	// not write to the terminal. Without it the r key only reloads what is
+	// stored.
+	Refresh func(ctx context.Context) error
+	// Open shows a link to the user, OpenBrowser by default.
+	Open func(url string) error
+	// Limit is the most items listed for one feed or category.
+	Limit int
+}
+
+type pane int
+
+const (
+	paneFeeds pane = iota
+	paneItems
+	panePreview
+)
+
+// entry is a line of the feeds pane. The first entry lists every feed,
+// category entries list the feeds below them.
+type entry struct {
+	label    string
+	category string
+	feedID   uuid.NullUUID
+	unread   int64
+	indent   bool
+}
+
+// Model holds the reader state. It does no terminal IO, Run drives it.
+type Model struct {
+	db   Store
+	user database.User
+	opts Options
+
+	entries    []entry
+	feedSel    int
+	items      []database.GetPostsForUserRow
+	itemSel    int
+	scroll     int
+	focus      pane
+	unreadOnly bool
+	status     string
+	help       bool
+	done       bool
+	refreshing bool
+	refreshed  chan error
+}
+
+func NewModel(db Store, user database.User, opts Options) *Model {
+	if opts.Open == nil {
+		opts.Open = OpenBrowser
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 200
+	}
+	return &Model{db: db, user: user, opts: opts, refreshed: make(chan error, 1)}
+}
+
+// Done reports whether the user asked to quit.
+func (m *Model) Done() bool {
+	return m.done
+}
+
+// Load reads the feeds and the items of the selected entry, keeping the
+// current selections where they still exist.
+func (m *Model) Load(ctx context.Context) error {
+	if err := m.loadEntries(ctx); err != nil {
+		return err
+	}
+	return m.loadItems(ctx)
+}
+
+func (m *Model) loadEntries(ctx context.Context) error {
+	follows, err := m.db.GetFeedFollowsByUserID(ctx, m.user.ID)
+	if err != nil {
+		return fmt.Errorf("tui error loading feeds: %w", err)
+	}
+	sort.SliceStable(follows, func(i, j int) bool {
+		a, b := follows[i].CategoryName, follows[j].CategoryName
+		if a.Valid != b.Valid {
+			return a.Valid
+		}
+		if a.String != b.String {
+			return a.String < b.String
+		}
+		return strings.ToLower(follows[i].FeedName) < strings.ToLower(follows[j].FeedName)
+	})
+
+	var selected entry
+	if m.feedSel < len(m.entries) {
+		selected = m.entries[m.feedSel]
+	}
+
+	entries := []entry{{label: "All items"}}
+	category := -1
+	for _, follow := range follows {
+		if follow.Muted {
+			continue
+		}
+		entries[0].unread += follow.UnreadCount
+		if follow.CategoryName.Valid {
+			if category < 0 || entries[category].category != follow.CategoryName.String {
+				category = len(entries)
+				entries = append(entries, entry{label: follow.CategoryName.String, category: follow.CategoryName.String})
+			}
+			entries[category].unread += follow.UnreadCount
+		}
+		entries = append(entries, entry{
+			label:    follow.FeedName,
+			category: follow.CategoryName.String,
+			feedID:   uuid.NullUUID{UUID: follow.FeedID, Valid: true},
+			unread:   follow.UnreadCount,
+			indent:   follow.CategoryName.Valid,
+		})
+	}
+	m.entries = entries
+
+	m.feedSel = 0
+	for i, e := range entries {
+		if e.feedID == selected.feedID && e.category == selected.category {
+			m.feedSel = i
+			break
+		}
+	}
+	return nil
+}
+
+func (m *Model) loadItems(ctx context.Context) error {
+	var selected uuid.UUID
+	if item := m.item(); item != nil {
+		selected = item.ID
+	}
+
+	e := m.entries[m.feedSel]
+	postsP := database.GetPostsForUserParams{
+		UserID:     m.user.ID,
+		UnreadOnly: m.unreadOnly,
+		MaxPosts:   int32(m.opts.Limit),
+	}
+	if e.feedID.Valid {
+		postsP.FeedID = e.feedID
+	} else {
+		postsP.Category = e.category
+	}
+	items, err := m.db.GetPostsForUser(ctx, postsP)
+	if err != nil {
+		return fmt.Errorf("tui error loading items: %w", err)
+	}
+	m.items = items
+
+	m.itemSel = 0
+	for i, item := range items {
+		if item.ID == selected {
+			m.itemSel = i
+			break
+		}
+	}
+	m.scroll = 0
+	return nil
+}
+
+func (m *Model) item() *database.GetPostsForUserRow {
+	if m.itemSel < 0 || m.itemSel >= len(m.items) {
+		return nil
+	}
+	return &m.items[m.itemSel]
+}
+
+// Handle applies one key press. Errors end up in the status line so a
+// failing database call does not end the session.
+func (m *Model) Handle(ctx context.Context, k Key) {
+	m.status = ""
+	if err := m.handle(ctx, k); err != nil {
+		m.status = err.Error()
+	}
+}
+
+func (m *Model) handle(ctx context.Context, k Key) error {
+	switch k {
+	case "q", KeyCtrlC:
+		m.done = true
+	case "?":
+		m.help = !m.help
+	case KeyTab:
+		if m.focus == panePreview {
+			m.focus = paneFeeds
+			return nil
+		}
+		return m.focusPane(ctx, m.focus+1)
+	case "h", KeyLeft, KeyEsc:
+		if m.focus > paneFeeds {
+			m.focus--
+		}
+	case "l", KeyRight:
+		return m.focusPane(ctx, min(m.focus+1, panePreview))
+	case KeyEnter:
+		if m.focus < panePreview {
+			return m.focusPane(ctx, m.focus+1)
+		}
+	case "j", KeyDown:
+		return m.move(ctx, 1)
+	case "k", KeyUp:
+		return m.move(ctx, -1)
+	case " ", KeyPgDn:
+		if m.focus == panePreview {
+			m.scroll += 10
+			return nil
+		}
+		return m.move(ctx, 10)
+	case "b", KeyPgUp:
+		if m.focus == panePreview {
+			m.scroll = max(0, m.scroll-10)
+			return nil
+		}
+		return m.move(ctx, -10)
+	case "n":
+		return m.step(ctx, 1)
+	case "p":
+		return m.step(ctx, -1)
+	case "m":
+		return m.toggleRead(ctx)
+	case "s":
+		return m.toggleStar(ctx)
+	case "o":
+		item := m.item()
+		if item == nil {
+			return nil
+		}
+		if err := m.opts.Open(item.Url); err != nil {
+			return err
+		}
+		m.status = "Opened " + item.Url
+	case "u":
+		m.unreadOnly = !m.unreadOnly
+		return m.loadItems(ctx)
+	case "r":
+		if m.opts.Refresh == nil {
+			if err := m.Load(ctx); err != nil {
+				return err
+			}
+			m.status = "Reloaded at " + time.Now().Format("15:04")
+			return nil
+		}
+		if m.refreshing {
+			m.status = "Refresh already running"
+			return nil
+		}
+		m.refreshing = true
+		go func() {
+			m.refreshed <- m.opts.Refresh(ctx)
+		}()
+	}
+	return nil
+}
+
+// Refreshed delivers the outcome of a refresh started with the r key. Run
+// passes it on to FinishRefresh.
+func (m *Model) Refreshed() <-chan error {
+	return m.refreshed
+}
+
+// FinishRefresh reloads the stored posts after a refresh and reports how it
+// went in the status line.
+func (m *Model) FinishRefresh(ctx context.Context, err error) {
+	m.refreshing = false
+	if loadErr := m.Load(ctx); loadErr != nil {
+		err = loadErr
+	}
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.status = "Refreshed at " + time.Now().Format("15:04")
+}
+
+func (m *Model) focusPane(ctx context.Context, p pane) error {
+	if p == panePreview {
+		if m.item() == nil {
+			return nil
+		}
+		m.focus = p
+		return m.markRead(ctx)
+	}
+	m.focus = p
+	return nil
+}
+
+func (m *Model) move(ctx context.Context, delta int) error {
+	switch m.focus {
+	case paneFeeds:
+		sel := clamp(m.feedSel+delta, 0, len(m.entries)-1)
+		if sel == m.feedSel {
+			return nil
+		}
+		m.feedSel = sel
+		m.itemSel = 0
+		return m.loadItems(ctx)
+	case paneItems:
+		m.itemSel = clamp(m.itemSel+delta, 0, len(m.items)-1)
+		m.scroll = 0
+	case panePreview:
+		m.scroll = max(0, m.scroll+delta)
+	}
+	return nil
+}
+
+// step shows the next or previous item in the preview.
+func (m *Model) step(ctx context.Context, delta int) error {
+	sel := m.itemSel + delta
+	if sel < 0 || sel >= len(m.items) {
+		m.status = "No more items"
+		return nil
+	}
+	m.itemSel = sel
+	m.scroll = 0
+	return m.focusPane(ctx, panePreview)
+}
+
+func (m *Model) markRead(ctx context.Context) error {
+	item := m.item()
+	if item == nil || item.ReadAt.Valid {
+		return nil
+	}
+	readP := database.MarkPostReadParams{UserID: m.user.ID, PostID: item.ID, ReadAt: time.Now()}
+	if err := m.db.MarkPostRead(ctx, readP); err != nil {
+		return fmt.Errorf("tui error marking read: %w", err)
+	}
+	item.ReadAt.Time, item.ReadAt.Valid = readP.ReadAt, true
+	return m.loadEntries(ctx)
+}
+
+func (m *Model) toggleRead(ctx context.Context) error {
+	item := m.item()
+	if item == nil {
+		return nil
+	}
+	if !item.ReadAt.Valid {
+		return m.markRead(ctx)
+	}
+	if _, err := m.db.MarkPostUnread(ctx, database.MarkPostUnreadParams{UserID: m.user.ID, PostID: item.ID}); err != nil {
+		return fmt.Errorf("tui error marking unread: %w", err)
+	}
+	item.ReadAt.Valid = false
+	return m.loadEntries(ctx)
+}
+
+func (m *Model) toggleStar(ctx context.Context) error {
+	item := m.item()
+	if item == nil {
+		return nil
+	}
+	if item.Starred {
+		if _, err := m.db.DeletePostStar(ctx, database.DeletePostStarParams{PostID: item.ID, UserID: m.user.ID}); err != nil {
+			return fmt.Errorf("tui error unstarring: %w", err)
+		}
+	} else {
+		starP := database.CreatePostStarParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			PostID:    item.ID,
+			UserID:    m.user.ID,
+		}
+		if err := m.db.CreatePostStar(ctx, starP); err != nil {
+			return fmt.Errorf("tui error starring: %w", err)
+		}
+	}
+	item.Starred = !item.Starred
+	return nil
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
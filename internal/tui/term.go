@@ -0,0 +1,199 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Key is a key press: the character typed, or one of the named keys below.
+type Key string
+
+const (
+	KeyUp    Key = "up"
+	KeyDown  Key = "down"
+	KeyLeft  Key = "left"
+	KeyRight Key = "right"
+	KeyPgUp  Key = "pgup"
+	KeyPgDn  Key = "pgdn"
+	KeyEnter Key = "enter"
+	KeyTab   Key = "tab"
+	KeyEsc   Key = "esc"
+	KeyCtrlC Key = "ctrl-c"
+)
+
+var ErrNotTerminal = errors.New("tui needs an interactive terminal")
+
+var escapes = map[string]Key{
+	"\x1b[A":  KeyUp,
+	"\x1b[B":  KeyDown,
+	"\x1b[C":  KeyRight,
+	"\x1b[D":  KeyLeft,
+	"\x1bOA":  KeyUp,
+	"\x1bOB":  KeyDown,
+	"\x1bOC":  KeyRight,
+	"\x1bOD":  KeyLeft,
+	"\x1b[5~": KeyPgUp,
+	"\x1b[6~": KeyPgDn,
+}
+
+// parseKeys splits what one read from the terminal returned into keys.
+// Unknown escape sequences are dropped.
+func parseKeys(b []byte) []Key {
+	var keys []Key
+	s := string(b)
+	for s != "" {
+		if s[0] == '\x1b' {
+			matched := false
+			for seq, k := range escapes {
+				if strings.HasPrefix(s, seq) {
+					keys, s, matched = append(keys, k), s[len(seq):], true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+			if len(s) > 1 && (s[1] == '[' || s[1] == 'O') {
+				// Skip to the final byte of an unsupported sequence.
+				end := 2
+				for end < len(s) && (s[end] < 0x40 || s[end] > 0x7e) {
+					end++
+				}
+				s = s[min(end+1, len(s)):]
+				continue
+			}
+			keys, s = append(keys, KeyEsc), s[1:]
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(s)
+		switch s[0] {
+		case '\r', '\n':
+			keys = append(keys, KeyEnter)
+		case '\t':
+			keys = append(keys, KeyTab)
+		case 0x03:
+			keys = append(keys, KeyCtrlC)
+		default:
+			if s[0] >= 0x20 && s[0] != 0x7f {
+				keys = append(keys, Key(s[:size]))
+			}
+		}
+		s = s[size:]
+	}
+	return keys
+}
+
+// Run takes over the terminal on in and out until the user quits or ctx is
+// cancelled, restoring it afterwards.
+func Run(ctx context.Context, m *Model, in, out *os.File) error {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(out.Fd())) {
+		return ErrNotTerminal
+	}
+	if err := m.Load(ctx); err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("tui error: %w", err)
+	}
+	defer term.Restore(fd, state)
+	io.WriteString(out, "\x1b[?1049h\x1b[?25l")
+	defer io.WriteString(out, "\x1b[?25h\x1b[?1049l")
+
+	// The reader is left blocked on in when Run returns; the process is
+	// about to exit anyway.
+	keys := make(chan []Key)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := in.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- parseKeys(buf[:n])
+		}
+	}()
+
+	// Terminal size is polled rather than watched with SIGWINCH, which does
+	// not exist on every platform.
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	var width, height int
+	draw := func() {
+		width, height, _ = term.GetSize(int(out.Fd()))
+		var b strings.Builder
+		b.WriteString("\x1b[H")
+		for i, line := range m.View(width, height) {
+			if i > 0 {
+				b.WriteString("\r\n")
+			}
+			b.WriteString(line + styleReset + "\x1b[K")
+		}
+		b.WriteString("\x1b[J")
+		io.WriteString(out, b.String())
+	}
+
+	draw()
+	for !m.Done() {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ks, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, k := range ks {
+				m.Handle(ctx, k)
+			}
+			draw()
+		case err := <-m.Refreshed():
+			m.FinishRefresh(ctx, err)
+			draw()
+		case <-ticker.C:
+			if w, h, err := term.GetSize(int(out.Fd())); err == nil && (w != width || h != height) {
+				draw()
+			}
+		}
+	}
+	return nil
+}
+
+// OpenBrowser opens an http or https link with the desktop's default
+// browser. Other schemes are refused since links come from feeds.
+func OpenBrowser(link string) error {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("tui error: refusing to open %q", link)
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u.String())
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u.String())
+	default:
+		cmd = exec.Command("xdg-open", u.String())
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("tui error opening browser: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
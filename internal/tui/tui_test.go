@@ -0,0 +1,301 @@
+package tui
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/database"
+)
+
+// fakeStore holds two feeds, one in the Work category, and their posts.
+type fakeStore struct {
+	follows []database.GetFeedFollowsByUserIDRow
+	posts   []database.GetPostsForUserRow
+	last    database.GetPostsForUserParams
+}
+
+func newFakeStore() *fakeStore {
+	goBlog, hn := uuid.New(), uuid.New()
+	store := &fakeStore{
+		follows: []database.GetFeedFollowsByUserIDRow{
+			{FeedID: hn, FeedName: "Hacker News"},
+			{FeedID: goBlog, FeedName: "Go Blog", CategoryName: sql.NullString{String: "Work", Valid: true}},
+		},
+	}
+	for i, feed := range []uuid.UUID{goBlog, goBlog, hn} {
+		store.posts = append(store.posts, database.GetPostsForUserRow{
+			ID:          uuid.New(),
+			Title:       []string{"Go 1.26", "Range functions", "Show HN"}[i],
+			Url:         "https://example.com/" + string(rune('a'+i)),
+			Description: sql.NullString{String: "<p>First <b>para</b></p><p>Second &amp; last</p>", Valid: true},
+			FeedID:      feed,
+			FeedName:    map[uuid.UUID]string{goBlog: "Go Blog", hn: "Hacker News"}[feed],
+		})
+	}
+	return store
+}
+
+func (f *fakeStore) GetFeedFollowsByUserID(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsByUserIDRow, error) {
+	rows := append([]database.GetFeedFollowsByUserIDRow(nil), f.follows...)
+	for i := range rows {
+		for _, p := range f.posts {
+			if p.FeedID == rows[i].FeedID && !p.ReadAt.Valid {
+				rows[i].UnreadCount++
+			}
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeStore) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	f.last = arg
+	var rows []database.GetPostsForUserRow
+	for _, p := range f.posts {
+		if arg.FeedID.Valid && p.FeedID != arg.FeedID.UUID {
+			continue
+		}
+		if arg.Category == "Work" && p.FeedName != "Go Blog" {
+			continue
+		}
+		if arg.UnreadOnly && p.ReadAt.Valid {
+			continue
+		}
+		rows = append(rows, p)
+	}
+	return rows, nil
+}
+
+func (f *fakeStore) post(id uuid.UUID) *database.GetPostsForUserRow {
+	for i := range f.posts {
+		if f.posts[i].ID == id {
+			return &f.posts[i]
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	f.post(arg.PostID).ReadAt = sql.NullTime{Time: arg.ReadAt, Valid: true}
+	return nil
+}
+
+func (f *fakeStore) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) (int64, error) {
+	f.post(arg.PostID).ReadAt = sql.NullTime{}
+	return 1, nil
+}
+
+func (f *fakeStore) CreatePostStar(ctx context.Context, arg database.CreatePostStarParams) error {
+	f.post(arg.PostID).Starred = true
+	return nil
+}
+
+func (f *fakeStore) DeletePostStar(ctx context.Context, arg database.DeletePostStarParams) (int64, error) {
+	f.post(arg.PostID).Starred = false
+	return 1, nil
+}
+
+func newTestModel(t *testing.T) (*Model, *fakeStore, *[]string) {
+	t.Helper()
+	store := newFakeStore()
+	var opened []string
+	m := NewModel(store, database.User{ID: uuid.New(), Name: "kahya"}, Options{
+		Open: func(url string) error {
+			opened = append(opened, url)
+			return nil
+		},
+	})
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return m, store, &opened
+}
+
+func press(m *Model, keys ...Key) {
+	for _, k := range keys {
+		m.Handle(context.Background(), k)
+	}
+}
+
+func TestEntries(t *testing.T) {
+	m, _, _ := newTestModel(t)
+
+	var got []string
+	for _, e := range m.entries {
+		got = append(got, e.label)
+	}
+	expected := []string{"All items", "Work", "Go Blog", "Hacker News"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected entries %v, got %v", expected, got)
+	}
+	if m.entries[0].unread != 3 || m.entries[1].unread != 2 {
+		t.Errorf("Unexpected unread counts %d and %d", m.entries[0].unread, m.entries[1].unread)
+	}
+	if len(m.items) != 3 {
+		t.Errorf("Expected all 3 items, got %d", len(m.items))
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	m, store, opened := newTestModel(t)
+
+	press(m, "j")
+	if store.last.Category != "Work" || len(m.items) != 2 {
+		t.Errorf("Moving to a category should list its items, got %+v", store.last)
+	}
+	press(m, "j")
+	if !store.last.FeedID.Valid || len(m.items) != 2 {
+		t.Errorf("Moving to a feed should list its items, got %+v", store.last)
+	}
+
+	press(m, KeyEnter, "j", KeyEnter)
+	if m.focus != panePreview || m.item().Title != "Range functions" {
+		t.Fatalf("Expected the second item in the preview, got focus %d on %q", m.focus, m.item().Title)
+	}
+	if !store.posts[1].ReadAt.Valid || store.posts[0].ReadAt.Valid {
+		t.Error("Only the previewed item should be marked read")
+	}
+	if m.entries[2].unread != 1 {
+		t.Errorf("Expected unread count to drop to 1, got %d", m.entries[2].unread)
+	}
+
+	press(m, "p")
+	if m.item().Title != "Go 1.26" || !store.posts[0].ReadAt.Valid {
+		t.Error("p should preview and mark the previous item")
+	}
+	press(m, "p")
+	if m.status != "No more items" {
+		t.Errorf("Expected a status at the first item, got %q", m.status)
+	}
+
+	press(m, "m", "s", "o")
+	if store.posts[0].ReadAt.Valid || !store.posts[0].Starred {
+		t.Error("m and s should toggle read and star")
+	}
+	if len(*opened) != 1 || (*opened)[0] != store.posts[0].Url {
+		t.Errorf("Expected the item link to be opened, got %v", *opened)
+	}
+
+	press(m, KeyEsc, KeyEsc, "u")
+	if !store.last.UnreadOnly || len(m.items) != 1 {
+		t.Errorf("u should list only unread items, got %d", len(m.items))
+	}
+
+	press(m, "q")
+	if !m.Done() {
+		t.Error("q should quit")
+	}
+}
+
+func TestRefreshInBackground(t *testing.T) {
+	store := newFakeStore()
+	release := make(chan struct{})
+	m := NewModel(store, database.User{ID: uuid.New(), Name: "kahya"}, Options{
+		Refresh: func(ctx context.Context) error {
+			<-release
+			return errors.New("could not refresh 1 of 2 feeds")
+		},
+	})
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	press(m, "r", "j")
+	if !m.refreshing || store.last.Category != "Work" {
+		t.Fatal("The reader should stay usable while refreshing")
+	}
+	press(m, "r")
+	if m.status != "Refresh already running" {
+		t.Errorf("Expected a second refresh to be refused, got %q", m.status)
+	}
+
+	close(release)
+	m.FinishRefresh(context.Background(), <-m.Refreshed())
+	if m.refreshing || m.status != "could not refresh 1 of 2 feeds" {
+		t.Errorf("Expected the refresh error in the status line, got %q", m.status)
+	}
+}
+
+var ansi = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func TestView(t *testing.T) {
+	m, _, _ := newTestModel(t)
+	press(m, KeyTab, KeyEnter)
+
+	lines := m.View(80, 20)
+	if len(lines) != 20 {
+		t.Fatalf("Expected 20 lines, got %d", len(lines))
+	}
+	screen := ansi.ReplaceAllString(strings.Join(lines, "\n"), "")
+	for _, want := range []string{"gator - kahya - All items", "Work 1", "Go Blog: Go 1.26", "First para", "Second & last"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Expected screen to contain %q:\n%s", want, screen)
+		}
+	}
+
+	for i, line := range lines {
+		if n := utf8.RuneCountInString(ansi.ReplaceAllString(line, "")); n != 80 {
+			t.Errorf("Line %d is %d columns wide: %q", i, n, line)
+		}
+	}
+}
+
+func TestViewDropsControlCharacters(t *testing.T) {
+	m, store, _ := newTestModel(t)
+	store.posts[0].Title = "\x1b[2JCleared\x9b31m"
+	store.posts[0].FeedName = "Go\x1b]0;owned\x07 Blog"
+	store.posts[0].Author = sql.NullString{String: "\x1b[5mgopher", Valid: true}
+	store.posts[0].Tags = "go,\rnews"
+	store.posts[0].Url = "https://example.com/\x1b[8m"
+	store.follows[1].FeedName = "Go\x1b[31m Blog"
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	press(m, KeyTab, KeyEnter)
+
+	raw := strings.Join(m.View(80, 20), "\n")
+	for _, injected := range []string{"\x1b[2J", "\x9b", "\x1b]0;", "\x07", "\x1b[5m", "\r", "\x1b[8m", "\x1b[31m"} {
+		if strings.Contains(raw, injected) {
+			t.Errorf("Screen contains %q from the feed:\n%q", injected, raw)
+		}
+	}
+	screen := ansi.ReplaceAllString(raw, "")
+	for _, want := range []string{"[2JCleared", "Go]0;owned Blog"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Expected screen to contain %q:\n%s", want, screen)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("jk\x1b[A\x1b[6~\r\t\x1bq\x1b[1;5Cé\x03"))
+	expected := []Key{"j", "k", KeyUp, KeyPgDn, KeyEnter, KeyTab, KeyEsc, "q", "é", KeyCtrlC}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	got := wrap("the quick brown fox\njumps", 10)
+	expected := []string{"the quick", "brown fox", "jumps"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if got := wrap("abcdefghij", 4); !reflect.DeepEqual(got, []string{"abcd", "efgh", "ij"}) {
+		t.Errorf("Long words should be cut, got %v", got)
+	}
+}
+
+func TestOpenBrowserRefusesOtherSchemes(t *testing.T) {
+	for _, link := range []string{"file:///etc/passwd", "javascript:alert(1)", "-x"} {
+		if err := OpenBrowser(link); err == nil {
+			t.Errorf("Expected %q to be refused", link)
+		}
+	}
+}
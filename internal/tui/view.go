@@ -0,0 +1,229 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/theandyeh/gator/internal/htmltext"
+)
+
+const (
+	styleReset    = "\x1b[0m"
+	styleBold     = "\x1b[1m"
+	styleDim      = "\x1b[2m"
+	styleReverse  = "\x1b[7m"
+	styleInactive = "\x1b[2;7m"
+)
+
+const helpLine = "j/k move  tab/h/l pane  enter open  n/p next/prev  m read  s star  o browser  u unread only  r refresh  q quit"
+
+// View draws the screen as height lines of at most width columns.
+func (m *Model) View(width, height int) []string {
+	if width < 20 || height < 5 {
+		return []string{fit("window too small", width)}
+	}
+
+	title := fmt.Sprintf(" gator - %s", m.user.Name)
+	if m.feedSel < len(m.entries) {
+		title += " - " + htmltext.Line(m.entries[m.feedSel].label)
+	}
+	if m.unreadOnly {
+		title += " (unread)"
+	}
+	lines := []string{styleReverse + pad(title, width) + styleReset}
+
+	body := height - 2
+	left := min(30, width/3)
+	right := width - left - 1
+	feeds := m.viewFeeds(left, body)
+
+	listHeight := max(3, body*2/5)
+	items := m.viewItems(right, listHeight)
+	items = append(items, styleDim+strings.Repeat("─", right)+styleReset)
+	items = append(items, m.viewPreview(right, body-listHeight-1)...)
+
+	for i := range body {
+		lines = append(lines, feeds[i]+styleDim+"│"+styleReset+items[i])
+	}
+
+	status := m.status
+	if status == "" && m.help {
+		status = helpLine
+	}
+	if status == "" && m.refreshing {
+		status = "Refreshing..."
+	}
+	if status == "" {
+		status = "? help"
+	}
+	lines = append(lines, fit(htmltext.Line(status), width))
+	return lines
+}
+
+func (m *Model) viewFeeds(width, height int) []string {
+	rows := make([]string, len(m.entries))
+	for i, e := range m.entries {
+		label := htmltext.Line(e.label)
+		if e.indent {
+			label = "  " + label
+		}
+		count := ""
+		if e.unread > 0 {
+			count = fmt.Sprintf(" %d", e.unread)
+		}
+		row := pad(truncate(" "+label, width-utf8.RuneCountInString(count))+count, width)
+		if e.unread > 0 && !e.feedID.Valid {
+			row = styleBold + row + styleReset
+		}
+		rows[i] = row
+	}
+	return window(rows, m.feedSel, m.focus == paneFeeds, width, height)
+}
+
+func (m *Model) viewItems(width, height int) []string {
+	rows := make([]string, len(m.items))
+	showFeed := !m.entries[m.feedSel].feedID.Valid
+	for i, item := range m.items {
+		marker := " "
+		if !item.ReadAt.Valid {
+			marker = "●"
+		}
+		star := " "
+		if item.Starred {
+			star = "★"
+		}
+		date := "      "
+		if item.PublishedAt.Valid {
+			date = item.PublishedAt.Time.Local().Format("Jan 02")
+		}
+		text := htmltext.Line(item.Title)
+		if showFeed {
+			text = htmltext.Line(item.FeedName) + ": " + text
+		}
+		row := pad(fmt.Sprintf("%s%s %s %s", marker, star, date, truncate(text, width-10)), width)
+		if !item.ReadAt.Valid {
+			row = styleBold + row + styleReset
+		}
+		rows[i] = row
+	}
+	if len(rows) == 0 {
+		rows = []string{pad(" No items", width)}
+	}
+	return window(rows, m.itemSel, m.focus == paneItems, width, height)
+}
+
+func (m *Model) viewPreview(width, height int) []string {
+	var text []string
+	if item := m.item(); item != nil {
+		// Everything but the body is printed as is, so control characters
+		// from the feed are dropped first. The body is cleaned by htmltext.
+		text = append(text, wrap(htmltext.Line(item.Title), width-1)...)
+		meta := item.FeedName
+		if item.PublishedAt.Valid {
+			meta += " - " + item.PublishedAt.Time.Local().Format("2006-01-02 15:04")
+		}
+		if item.Author.Valid {
+			meta += " - " + item.Author.String
+		}
+		if item.Tags != "" {
+			meta += " - " + item.Tags
+		}
+		text = append(text, wrap(htmltext.Line(meta), width-1)...)
+		text = append(text, truncate(htmltext.Line(item.Url), width-1), "")
+		body := htmltext.Render(cmp.Or(item.Content.String, item.Description.String), htmltext.Options{Width: width - 1, ANSI: true, Base: item.Url})
+		text = append(text, strings.Split(body, "\n")...)
+	}
+
+	m.scroll = clamp(m.scroll, 0, max(0, len(text)-height))
+	rows := make([]string, height)
+	for i := range rows {
+		line := ""
+		if j := m.scroll + i; j < len(text) {
+			line = text[j]
+		}
+		rows[i] = pad(" "+line, width)
+		if i < 2 && m.scroll == 0 && line != "" {
+			rows[i] = styleBold + rows[i] + styleReset
+		}
+	}
+	return rows
+}
+
+// window cuts rows to height lines around the selected row and highlights
+// it, dimmed when the pane does not have focus.
+func window(rows []string, sel int, focused bool, width, height int) []string {
+	start := clamp(sel-height/2, 0, max(0, len(rows)-height))
+	out := make([]string, height)
+	for i := range out {
+		j := start + i
+		switch {
+		case j >= len(rows):
+			out[i] = strings.Repeat(" ", width)
+		case j == sel && focused:
+			out[i] = styleReverse + rows[j] + styleReset
+		case j == sel:
+			out[i] = styleInactive + rows[j] + styleReset
+		default:
+			out[i] = rows[j]
+		}
+	}
+	return out
+}
+
+// wrap breaks s into lines of at most width runes at spaces, keeping
+// existing line breaks. Words longer than width are cut.
+func wrap(s string, width int) []string {
+	if width < 1 {
+		return nil
+	}
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				r := []rune(word)
+				lines = append(lines, string(r[:width]))
+				word = string(r[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func truncate(s string, width int) string {
+	if width < 1 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "…"
+}
+
+func pad(s string, width int) string {
+	if n := htmltext.Width(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func fit(s string, width int) string {
+	return pad(truncate(s, width), width)
+}
@@ -0,0 +1,406 @@
+// Package web serves a server-rendered reading UI. It has no authentication
+// beyond choosing a user, just like the login command of the CLI, so only
+// expose it on trusted networks. The chosen user is kept in a cookie signed
+// with a server secret, so it cannot be swapped for another user.
+package web
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/follows"
+	"github.com/theandyeh/gator/internal/sanitize"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+const (
+	userCookie = "gator_user"
+	pageSize   = 50
+)
+
+// Store is the subset of database.Queries the web UI uses.
+type Store interface {
+	follows.Store
+	GetUser(ctx context.Context, name string) (database.User, error)
+	GetUsers(ctx context.Context) ([]database.User, error)
+	GetFeedFollowsByUserID(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsByUserIDRow, error)
+	GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error)
+	GetPostForUser(ctx context.Context, arg database.GetPostForUserParams) (database.GetPostForUserRow, error)
+	MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error
+	MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) (int64, error)
+	CreatePostStar(ctx context.Context, arg database.CreatePostStarParams) error
+	DeletePostStar(ctx context.Context, arg database.DeletePostStarParams) (int64, error)
+}
+
+type Options struct {
+	AllowPrivateURLs bool
+	Fetch            follows.Fetcher
+	// Secret signs the user cookie. A random secret is used when it is
+	// empty, which logs everyone out when the server restarts.
+	Secret []byte
+}
+
+type Server struct {
+	db    Store
+	opts  Options
+	mux   *http.ServeMux
+	pages map[string]*template.Template
+}
+
+func New(db Store, opts Options) (*Server, error) {
+	if len(opts.Secret) == 0 {
+		opts.Secret = make([]byte, 32)
+		rand.Read(opts.Secret)
+	}
+	s := &Server{db: db, opts: opts, mux: http.NewServeMux(), pages: map[string]*template.Template{}}
+
+	funcs := template.FuncMap{
+		"date": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format("2006-01-02 15:04")
+		},
+		// sanitize is the only way item HTML reaches a page unescaped.
+		"sanitize": func(s, base string) template.HTML {
+			return template.HTML(sanitize.HTML(s, base))
+		},
+	}
+	for _, page := range []string{"login", "subscriptions", "items", "item"} {
+		t, err := template.New("layout.html").Funcs(funcs).ParseFS(templateFS, "templates/layout.html", "templates/"+page+".html")
+		if err != nil {
+			return nil, fmt.Errorf("web error parsing %s template: %w", page, err)
+		}
+		s.pages[page] = t
+	}
+
+	s.mux.HandleFunc("GET /login", s.getLogin)
+	s.mux.HandleFunc("POST /login", s.postLogin)
+	s.mux.HandleFunc("POST /logout", s.postLogout)
+	s.handle("GET /{$}", func(w http.ResponseWriter, r *http.Request, user database.User) error {
+		http.Redirect(w, r, "/items", http.StatusSeeOther)
+		return nil
+	})
+	s.handle("GET /subscriptions", s.getSubscriptions)
+	s.handle("POST /follow", s.postFollow)
+	s.handle("POST /unfollow", s.postUnfollow)
+	s.handle("GET /items", s.getItems)
+	s.handle("GET /items/{id}", s.getItem)
+	s.handle("POST /items/{id}/{action}", s.postItemAction)
+
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; img-src 'self' https: data:; style-src 'self' 'unsafe-inline'; frame-ancestors 'none'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	s.mux.ServeHTTP(w, r)
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, user database.User) error
+
+// handle registers a page that needs a logged in user, redirecting to the
+// login page otherwise.
+func (s *Server) handle(pattern string, h handlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(userCookie)
+		if err != nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		name, ok := s.verifyUser(cookie.Value)
+		if !ok {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		user, err := s.db.GetUser(r.Context(), name)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		if err == nil {
+			err = h(w, r, user)
+		}
+		if err != nil {
+			s.error(w, err)
+		}
+	})
+}
+
+// signUser returns the cookie value for name: the name and its HMAC, both
+// base64 encoded and joined by a dot.
+func (s *Server) signUser(name string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(name)) + "." + base64.RawURLEncoding.EncodeToString(s.mac(name))
+}
+
+// verifyUser returns the user name held by a cookie value made by signUser.
+func (s *Server) verifyUser(value string) (string, bool) {
+	encName, encMAC, ok := strings.Cut(value, ".")
+	if !ok {
+		return "", false
+	}
+	name, err := base64.RawURLEncoding.DecodeString(encName)
+	if err != nil {
+		return "", false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encMAC)
+	if err != nil || !hmac.Equal(mac, s.mac(string(name))) {
+		return "", false
+	}
+	return string(name), true
+}
+
+func (s *Server) mac(name string) []byte {
+	h := hmac.New(sha256.New, s.opts.Secret)
+	h.Write([]byte(userCookie + "\x00" + name))
+	return h.Sum(nil)
+}
+
+func (s *Server) error(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	msg := "Something went wrong."
+	switch {
+	case errors.Is(err, follows.ErrInvalidURL):
+		status, msg = http.StatusBadRequest, err.Error()
+	case errors.Is(err, follows.ErrNotFollowing), errors.Is(err, sql.ErrNoRows):
+		status, msg = http.StatusNotFound, "Not found."
+	default:
+		fmt.Fprintf(os.Stderr, "web error: %v\n", err)
+	}
+	http.Error(w, msg, status)
+}
+
+func (s *Server) render(w http.ResponseWriter, page string, data any) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return s.pages[page].Execute(w, data)
+}
+
+type pageData struct {
+	Title string
+	User  string
+}
+
+func (s *Server) getLogin(w http.ResponseWriter, r *http.Request) {
+	users, err := s.db.GetUsers(r.Context())
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+	data := struct {
+		pageData
+		Users []database.User
+	}{pageData{Title: "Log in"}, users}
+	if err := s.render(w, "login", data); err != nil {
+		s.error(w, err)
+	}
+}
+
+func (s *Server) postLogin(w http.ResponseWriter, r *http.Request) {
+	user, err := s.db.GetUser(r.Context(), r.PostFormValue("user"))
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     userCookie,
+		Value:    s.signUser(user.Name),
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/items", http.StatusSeeOther)
+}
+
+func (s *Server) postLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: userCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (s *Server) getSubscriptions(w http.ResponseWriter, r *http.Request, user database.User) error {
+	rows, err := s.db.GetFeedFollowsByUserID(r.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+	data := struct {
+		pageData
+		Follows []database.GetFeedFollowsByUserIDRow
+		Error   string
+	}{pageData{Title: "Subscriptions", User: user.Name}, rows, r.URL.Query().Get("error")}
+	return s.render(w, "subscriptions", data)
+}
+
+func (s *Server) postFollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	_, _, err := follows.Follow(r.Context(), s.db, s.opts.Fetch, user, r.PostFormValue("url"), s.opts.AllowPrivateURLs)
+	if errors.Is(err, follows.ErrInvalidURL) || errors.Is(err, follows.ErrAlreadyFollowing) {
+		http.Redirect(w, r, "/subscriptions?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	http.Redirect(w, r, "/subscriptions", http.StatusSeeOther)
+	return nil
+}
+
+func (s *Server) postUnfollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feedID, err := uuid.Parse(r.PostFormValue("feed_id"))
+	if err != nil {
+		return fmt.Errorf("%w: invalid feed id", follows.ErrNotFollowing)
+	}
+	if err := follows.UnfollowID(r.Context(), s.db, user, feedID); err != nil {
+		return err
+	}
+	http.Redirect(w, r, "/subscriptions", http.StatusSeeOther)
+	return nil
+}
+
+func (s *Server) getItems(w http.ResponseWriter, r *http.Request, user database.User) error {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+	unread := r.URL.Query().Get("unread") == "1"
+	category := r.URL.Query().Get("category")
+
+	postsP := database.GetPostsForUserParams{
+		UserID:     user.ID,
+		UnreadOnly: unread,
+		Category:   category,
+		MaxPosts:   pageSize + 1,
+		SkipPosts:  int32((page - 1) * pageSize),
+	}
+	posts, err := s.db.GetPostsForUser(r.Context(), postsP)
+	if err != nil {
+		return err
+	}
+	more := len(posts) > pageSize
+	if more {
+		posts = posts[:pageSize]
+	}
+
+	query := func(p int, unread bool) string {
+		v := url.Values{}
+		if p > 1 {
+			v.Set("page", strconv.Itoa(p))
+		}
+		if unread {
+			v.Set("unread", "1")
+		}
+		if category != "" {
+			v.Set("category", category)
+		}
+		if len(v) == 0 {
+			return "/items"
+		}
+		return "/items?" + v.Encode()
+	}
+	data := struct {
+		pageData
+		Posts     []database.GetPostsForUserRow
+		Unread    bool
+		Category  string
+		AllURL    string
+		UnreadURL string
+		PrevURL   string
+		NextURL   string
+	}{
+		pageData:  pageData{Title: "Items", User: user.Name},
+		Posts:     posts,
+		Unread:    unread,
+		Category:  category,
+		AllURL:    query(1, false),
+		UnreadURL: query(1, true),
+	}
+	if page > 1 {
+		data.PrevURL = query(page-1, unread)
+	}
+	if more {
+		data.NextURL = query(page+1, unread)
+	}
+	return s.render(w, "items", data)
+}
+
+// getItem shows a single item and marks it read, like opening it in a reader.
+func (s *Server) getItem(w http.ResponseWriter, r *http.Request, user database.User) error {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return sql.ErrNoRows
+	}
+	post, err := s.db.GetPostForUser(r.Context(), database.GetPostForUserParams{ID: id, UserID: user.ID})
+	if err != nil {
+		return err
+	}
+	if !post.ReadAt.Valid {
+		if err := s.db.MarkPostRead(r.Context(), database.MarkPostReadParams{UserID: user.ID, PostID: id, ReadAt: time.Now()}); err != nil {
+			return err
+		}
+	}
+
+	data := struct {
+		pageData
+		Post database.GetPostForUserRow
+	}{pageData{Title: post.Title, User: user.Name}, post}
+	return s.render(w, "item", data)
+}
+
+func (s *Server) postItemAction(w http.ResponseWriter, r *http.Request, user database.User) error {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return sql.ErrNoRows
+	}
+	if _, err := s.db.GetPostForUser(r.Context(), database.GetPostForUserParams{ID: id, UserID: user.ID}); err != nil {
+		return err
+	}
+
+	switch r.PathValue("action") {
+	case "read":
+		err = s.db.MarkPostRead(r.Context(), database.MarkPostReadParams{UserID: user.ID, PostID: id, ReadAt: time.Now()})
+	case "unread":
+		_, err = s.db.MarkPostUnread(r.Context(), database.MarkPostUnreadParams{UserID: user.ID, PostID: id})
+	case "star":
+		err = s.db.CreatePostStar(r.Context(), database.CreatePostStarParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			PostID:    id,
+			UserID:    user.ID,
+		})
+	case "unstar":
+		_, err = s.db.DeletePostStar(r.Context(), database.DeletePostStarParams{PostID: id, UserID: user.ID})
+	default:
+		return sql.ErrNoRows
+	}
+	if err != nil {
+		return err
+	}
+
+	back := "/items/" + id.String()
+	if ref := r.PostFormValue("back"); isLocalPath(ref) {
+		back = ref
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+	return nil
+}
+
+// isLocalPath reports whether p is a path on this server, so redirecting to
+// it cannot send the user to another site.
+func isLocalPath(p string) bool {
+	return len(p) > 0 && p[0] == '/' && (len(p) == 1 || (p[1] != '/' && p[1] != '\\'))
+}
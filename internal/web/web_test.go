@@ -0,0 +1,281 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/feedurl"
+	"github.com/theandyeh/gator/internal/rss"
+)
+
+type fakeStore struct {
+	user    database.User
+	feeds   []database.Feed
+	follows map[uuid.UUID]bool
+	posts   []database.Post
+	read    map[uuid.UUID]bool
+	starred map[uuid.UUID]bool
+}
+
+func newFakeStore() *fakeStore {
+	user := database.User{ID: uuid.New(), Name: "kahya"}
+	feed := database.Feed{ID: uuid.New(), Name: "Go Blog", Url: "https://go.dev/blog/feed.atom"}
+	return &fakeStore{
+		user:    user,
+		feeds:   []database.Feed{feed},
+		follows: map[uuid.UUID]bool{feed.ID: true},
+		posts: []database.Post{
+			{ID: uuid.New(), Title: "Go 1.30 <released>", Url: "https://go.dev/blog/go1.30", FeedID: feed.ID, Description: sql.NullString{String: `<p onclick="x()">Out <b>now</b><script>alert(1)</script></p>`, Valid: true}},
+			{ID: uuid.New(), Title: "Range over func", Url: "javascript:alert(1)", FeedID: feed.ID},
+		},
+		read:    map[uuid.UUID]bool{},
+		starred: map[uuid.UUID]bool{},
+	}
+}
+
+func (f *fakeStore) GetUser(ctx context.Context, name string) (database.User, error) {
+	if name != f.user.Name {
+		return database.User{}, sql.ErrNoRows
+	}
+	return f.user, nil
+}
+
+func (f *fakeStore) GetUsers(ctx context.Context) ([]database.User, error) {
+	return []database.User{f.user}, nil
+}
+
+func (f *fakeStore) GetFeedFollowsByUserID(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsByUserIDRow, error) {
+	var rows []database.GetFeedFollowsByUserIDRow
+	for _, feed := range f.feeds {
+		if f.follows[feed.ID] {
+			rows = append(rows, database.GetFeedFollowsByUserIDRow{FeedID: feed.ID, FeedName: feed.Name, FeedUrl: feed.Url})
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeStore) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	var rows []database.GetPostsForUserRow
+	for _, p := range f.posts {
+		if !f.follows[p.FeedID] || (arg.UnreadOnly && f.read[p.ID]) {
+			continue
+		}
+		row := database.GetPostsForUserRow{ID: p.ID, Title: p.Title, Url: p.Url, FeedID: p.FeedID, FeedName: "Go Blog"}
+		row.ReadAt.Valid = f.read[p.ID]
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (f *fakeStore) GetPostForUser(ctx context.Context, arg database.GetPostForUserParams) (database.GetPostForUserRow, error) {
+	for _, p := range f.posts {
+		if p.ID == arg.ID && f.follows[p.FeedID] {
+			row := database.GetPostForUserRow{ID: p.ID, Title: p.Title, Url: p.Url, Description: p.Description, FeedID: p.FeedID, FeedName: "Go Blog", Starred: f.starred[p.ID]}
+			row.ReadAt.Valid = f.read[p.ID]
+			return row, nil
+		}
+	}
+	return database.GetPostForUserRow{}, sql.ErrNoRows
+}
+
+func (f *fakeStore) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	f.read[arg.PostID] = true
+	return nil
+}
+
+func (f *fakeStore) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) (int64, error) {
+	delete(f.read, arg.PostID)
+	return 1, nil
+}
+
+func (f *fakeStore) CreatePostStar(ctx context.Context, arg database.CreatePostStarParams) error {
+	f.starred[arg.PostID] = true
+	return nil
+}
+
+func (f *fakeStore) DeletePostStar(ctx context.Context, arg database.DeletePostStarParams) (int64, error) {
+	delete(f.starred, arg.PostID)
+	return 1, nil
+}
+
+func (f *fakeStore) GetFeedByURL(ctx context.Context, url string) (database.Feed, error) {
+	for _, feed := range f.feeds {
+		if feedurl.SameFeed(feed.Url, url) {
+			return feed, nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (f *fakeStore) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	feed := database.Feed{ID: arg.ID, Name: arg.Name, Url: arg.Url}
+	f.feeds = append(f.feeds, feed)
+	return feed, nil
+}
+
+func (f *fakeStore) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	f.follows[arg.FeedID] = true
+	return database.CreateFeedFollowRow{FeedID: arg.FeedID, UserID: arg.UserID}, nil
+}
+
+func (f *fakeStore) GetFeedFollow(ctx context.Context, arg database.GetFeedFollowParams) (database.FeedFollow, error) {
+	if !f.follows[arg.FeedID] {
+		return database.FeedFollow{}, sql.ErrNoRows
+	}
+	return database.FeedFollow{FeedID: arg.FeedID, UserID: arg.UserID}, nil
+}
+
+func (f *fakeStore) DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) error {
+	delete(f.follows, arg.FeedID)
+	return nil
+}
+
+func newTestServer(t *testing.T) (*Server, *fakeStore) {
+	t.Helper()
+	store := newFakeStore()
+	srv, err := New(store, Options{Fetch: func(ctx context.Context, feedURL string) (*rss.RSSFeed, error) {
+		feed := &rss.RSSFeed{}
+		feed.Channel.Title = "Example"
+		return feed, nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, store
+}
+
+func request(t *testing.T, srv *Server, method, path string, form url.Values, user string) (*http.Response, string) {
+	t.Helper()
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req := httptest.NewRequest(method, path, body)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if user != "" {
+		req.AddCookie(&http.Cookie{Name: userCookie, Value: srv.signUser(user)})
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	data, _ := io.ReadAll(rec.Result().Body)
+	return rec.Result(), string(data)
+}
+
+func TestLogin(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	resp, _ := request(t, srv, "GET", "/items", nil, "")
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/login" {
+		t.Errorf("anonymous GET /items = %d %s, want redirect to /login", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp, body := request(t, srv, "GET", "/login", nil, "")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "<option>kahya</option>") {
+		t.Errorf("GET /login = %d\n%s", resp.StatusCode, body)
+	}
+
+	resp, _ = request(t, srv, "POST", "/login", url.Values{"user": {"kahya"}}, "")
+	cookies := resp.Cookies()
+	if resp.StatusCode != http.StatusSeeOther || len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("POST /login = %d, cookies %v", resp.StatusCode, cookies)
+	}
+	if name, ok := srv.verifyUser(cookies[0].Value); !ok || name != "kahya" {
+		t.Errorf("login cookie %q holds %q, %v", cookies[0].Value, name, ok)
+	}
+
+	// A cookie naming a user without a valid signature is not accepted.
+	for _, forged := range []string{"kahya", "a2FoeWE.AAAA", cookies[0].Value + "x"} {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.AddCookie(&http.Cookie{Name: userCookie, Value: forged})
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/login" {
+			t.Errorf("forged cookie %q = %d, want redirect to /login", forged, rec.Code)
+		}
+	}
+
+	resp, _ = request(t, srv, "POST", "/login", url.Values{"user": {"nobody"}}, "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST /login unknown user = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestItemsEscaping(t *testing.T) {
+	srv, store := newTestServer(t)
+
+	resp, body := request(t, srv, "GET", "/items", nil, "kahya")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /items = %d", resp.StatusCode)
+	}
+	if !strings.Contains(body, "Go 1.30 &lt;released&gt;") {
+		t.Errorf("title is not escaped:\n%s", body)
+	}
+
+	_, body = request(t, srv, "GET", "/items/"+store.posts[1].ID.String(), nil, "kahya")
+	if strings.Contains(body, "javascript:") {
+		t.Errorf("unsafe link was rendered:\n%s", body)
+	}
+	if !store.read[store.posts[1].ID] {
+		t.Error("viewing an item should mark it read")
+	}
+
+	_, body = request(t, srv, "GET", "/items/"+store.posts[0].ID.String(), nil, "kahya")
+	if !strings.Contains(body, "<p>Out <b>now</b></p>") || strings.Contains(body, "alert") || strings.Contains(body, "onclick") {
+		t.Errorf("item content is not sanitized:\n%s", body)
+	}
+}
+
+func TestItemActions(t *testing.T) {
+	srv, store := newTestServer(t)
+	id := store.posts[0].ID.String()
+
+	resp, _ := request(t, srv, "POST", "/items/"+id+"/star", url.Values{}, "kahya")
+	if resp.StatusCode != http.StatusSeeOther || !store.starred[store.posts[0].ID] {
+		t.Errorf("star = %d, starred %v", resp.StatusCode, store.starred)
+	}
+
+	resp, _ = request(t, srv, "POST", "/items/"+id+"/read", url.Values{"back": {"//evil.example.com"}}, "kahya")
+	if loc := resp.Header.Get("Location"); loc != "/items/"+id {
+		t.Errorf("redirect to %q, want the item page", loc)
+	}
+
+	resp, _ = request(t, srv, "POST", "/items/"+id+"/explode", url.Values{}, "kahya")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown action = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestFollowForms(t *testing.T) {
+	srv, store := newTestServer(t)
+
+	resp, _ := request(t, srv, "POST", "/follow", url.Values{"url": {"https://example.com/feed.xml"}}, "kahya")
+	if resp.StatusCode != http.StatusSeeOther || len(store.feeds) != 2 {
+		t.Fatalf("follow = %d, feeds %v", resp.StatusCode, store.feeds)
+	}
+	newFeed := store.feeds[1]
+
+	resp, _ = request(t, srv, "POST", "/follow", url.Values{"url": {"http://localhost/feed"}}, "kahya")
+	if loc := resp.Header.Get("Location"); !strings.HasPrefix(loc, "/subscriptions?error=") {
+		t.Errorf("invalid follow redirected to %q, want the error on the subscriptions page", loc)
+	}
+
+	_, body := request(t, srv, "GET", "/subscriptions", nil, "kahya")
+	if !strings.Contains(body, "Example") {
+		t.Errorf("new feed missing from subscriptions:\n%s", body)
+	}
+
+	resp, _ = request(t, srv, "POST", "/unfollow", url.Values{"feed_id": {newFeed.ID.String()}}, "kahya")
+	if resp.StatusCode != http.StatusSeeOther || store.follows[newFeed.ID] {
+		t.Errorf("unfollow = %d, follows %v", resp.StatusCode, store.follows)
+	}
+}
@@ -0,0 +1,193 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+const (
+	EventPostCreated = "post.created"
+	EventPing        = "ping"
+)
+
+const (
+	SignatureHeader = "X-Gator-Signature"
+	EventHeader     = "X-Gator-Event"
+	DeliveryHeader  = "X-Gator-Delivery"
+)
+
+// Payload is the JSON body sent for each delivery. Custom templates are
+// executed with it as their data.
+type Payload struct {
+	Event string    `json:"event"`
+	Feed  Feed      `json:"feed"`
+	Post  Post      `json:"post"`
+	Sent  time.Time `json:"sent_at"`
+}
+
+type Feed struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type Post struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	PublishedAt time.Time `json:"published_at,omitzero"`
+}
+
+// Body renders p with tmpl, a text/template, or as plain JSON when tmpl is
+// empty. Templates can use the json function to quote values, e.g.
+// {"text": {{json .Post.Title}}}.
+func Body(tmpl string, p Payload) ([]byte, error) {
+	if tmpl == "" {
+		body, err := json.Marshal(p)
+		if err != nil {
+			return nil, fmt.Errorf("webhook error: %w", err)
+		}
+		return body, nil
+	}
+
+	t, err := ParseTemplate(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, p); err != nil {
+		return nil, fmt.Errorf("webhook error executing template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func ParseTemplate(tmpl string) (*template.Template, error) {
+	t, err := template.New("payload").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("webhook error parsing template: %w", err)
+	}
+	return t, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body, prefixed with "sha256=".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the valid signature of body.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Result describes the outcome of a delivery after all attempts.
+type Result struct {
+	Attempts   int
+	StatusCode int
+	Err        error
+	// Retry reports whether a failed delivery may succeed later, after a
+	// network error, 429 or 5xx response.
+	Retry bool
+}
+
+func (r Result) Succeeded() bool {
+	return r.Err == nil
+}
+
+var ErrStatus = errors.New("unexpected response status")
+
+// Sender posts payloads, retrying network errors, 429 and 5xx responses with
+// exponential backoff. Other 4xx responses are not retried.
+type Sender struct {
+	Client   *http.Client
+	Attempts int
+	Backoff  time.Duration
+	// Sleep waits between attempts; tests replace it to avoid real delays.
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewSender(client *http.Client) *Sender {
+	return &Sender{Client: client, Attempts: 3, Backoff: time.Second, Sleep: sleep}
+}
+
+func (s *Sender) Send(ctx context.Context, url, secret, event, delivery string, body []byte) Result {
+	var res Result
+	backoff := s.Backoff
+	for res.Attempts < max(s.Attempts, 1) {
+		if res.Attempts > 0 {
+			if err := s.Sleep(ctx, backoff); err != nil {
+				res.Err = fmt.Errorf("webhook error: %w", err)
+				return res
+			}
+			backoff *= 2
+		}
+		res.Attempts++
+
+		res.StatusCode, res.Retry, res.Err = s.post(ctx, url, secret, event, delivery, body)
+		if res.Err == nil || !res.Retry {
+			return res
+		}
+	}
+	return res
+}
+
+func (s *Sender) post(ctx context.Context, url, secret, event, delivery string, body []byte) (int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, fmt.Errorf("webhook error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, delivery)
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, true, fmt.Errorf("webhook error: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return resp.StatusCode, retry, fmt.Errorf("webhook error: %w: %d %s", ErrStatus, resp.StatusCode, http.StatusText(resp.StatusCode))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
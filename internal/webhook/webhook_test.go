@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testPayload = Payload{
+	Event: EventPostCreated,
+	Feed:  Feed{Name: "Go Blog", URL: "https://go.dev/blog/feed.atom"},
+	Post:  Post{ID: "1", Title: `Go "1.30" is released`, URL: "https://go.dev/blog/go1.30"},
+	Sent:  time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func noSleep(ctx context.Context, d time.Duration) error {
+	return nil
+}
+
+func TestBody(t *testing.T) {
+	body, err := Body("", testPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Payload
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("default body is not JSON: %v", err)
+	}
+	if got.Post.Title != testPayload.Post.Title {
+		t.Errorf("title = %q, want %q", got.Post.Title, testPayload.Post.Title)
+	}
+
+	body, err = Body(`{"text": {{json (printf "%s: %s" .Feed.Name .Post.Title)}}}`, testPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"text": "Go Blog: Go \"1.30\" is released"}`
+	if string(body) != want {
+		t.Errorf("template body = %s, want %s", body, want)
+	}
+
+	if _, err := Body("{{.Missing", testPayload); err == nil {
+		t.Error("expected error for broken template")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	sig := Sign("s3cret", body)
+	if !Verify("s3cret", body, sig) {
+		t.Error("signature did not verify")
+	}
+	if Verify("other", body, sig) {
+		t.Error("signature verified with wrong secret")
+	}
+	// printf '{"event":"ping"}' | openssl dgst -sha256 -hmac s3cret
+	want := "sha256=dfdb9d36759a59dc252c24d194f7122a8df08cb88f87fa8d5c6b24ff48fe45d8"
+	if sig != want {
+		t.Errorf("Sign() = %q, want %q", sig, want)
+	}
+}
+
+func TestSendSignsAndRetries(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if !Verify("s3cret", body, r.Header.Get(SignatureHeader)) {
+			t.Errorf("bad signature %q", r.Header.Get(SignatureHeader))
+		}
+		if r.Header.Get(EventHeader) != EventPostCreated || r.Header.Get(DeliveryHeader) != "d1" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var waits []time.Duration
+	s := &Sender{Client: srv.Client(), Attempts: 3, Backoff: time.Second, Sleep: func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}}
+	res := s.Send(context.Background(), srv.URL, "s3cret", EventPostCreated, "d1", []byte(`{}`))
+	if !res.Succeeded() || res.Attempts != 3 || res.StatusCode != http.StatusNoContent {
+		t.Errorf("Send() = %+v, want success after 3 attempts", res)
+	}
+	if len(waits) != 2 || waits[0] != time.Second || waits[1] != 2*time.Second {
+		t.Errorf("backoff waits = %v, want [1s 2s]", waits)
+	}
+}
+
+func TestSendClientErrorNotRetried(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer srv.Close()
+
+	s := &Sender{Client: srv.Client(), Attempts: 5, Sleep: noSleep}
+	res := s.Send(context.Background(), srv.URL, "", EventPostCreated, "d1", []byte(`{}`))
+	if !errors.Is(res.Err, ErrStatus) || res.StatusCode != http.StatusGone || res.Retry {
+		t.Errorf("Send() = %+v, want %v with 410", res, ErrStatus)
+	}
+	if calls != 1 {
+		t.Errorf("server called %d times, want 1", calls)
+	}
+}
+
+func TestSendGivesUp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	s := &Sender{Client: srv.Client(), Attempts: 2, Sleep: noSleep}
+	res := s.Send(context.Background(), srv.URL, "", EventPostCreated, "d1", []byte(`{}`))
+	if res.Succeeded() || res.Attempts != 2 || !res.Retry {
+		t.Errorf("Send() = %+v, want retryable failure after 2 attempts", res)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"time"
 
 	_ "github.com/lib/pq"
 
@@ -11,40 +12,304 @@ import (
 	"github.com/theandyeh/gator/internal/cmd"
 	"github.com/theandyeh/gator/internal/config"
 	"github.com/theandyeh/gator/internal/database"
+	"github.com/theandyeh/gator/internal/rss"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("No command provided")
+	globals, args, err := cmd.ParseGlobalFlags(os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if len(args) < 1 {
+		fmt.Println("No command provided, run 'gator help' for a list of commands")
 		os.Exit(1)
 	}
 
-	state := &app.State{}
-	var err error
+	state := &app.State{
+		Output:  globals.Output,
+		Out:     os.Stdout,
+		Verbose: globals.Verbose,
+	}
 
-	state.Cfg, err = config.Read()
+	if globals.Config != "" {
+		state.Cfg, err = config.ReadFrom(globals.Config)
+	} else {
+		state.Cfg, err = config.Read()
+	}
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
+	// --user only applies to this invocation, login changes the stored user
+	if globals.User != "" {
+		state.Cfg.Current_db_user = globals.User
+	}
+
+	policy, err := rss.NewDialPolicy(state.Cfg.Fetch_allow_cidrs, state.Cfg.Fetch_deny_cidrs, state.Cfg.Allow_private_urls)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rss.SetDialPolicy(policy)
+
 	db, err := sql.Open("postgres", state.Cfg.Db_url)
 	dbQueries := database.New(db)
+	state.Conn = db
 	state.Db = dbQueries
 
 	cmd_list := cmd.CreateCommandsList()
-	cmd_list.Register("login", cmd.HandlerLogin)
-	cmd_list.Register("register", cmd.HandlerRegister)
-	cmd_list.Register("reset", cmd.HandlerReset)
-	cmd_list.Register("users", cmd.HandlerUsers)
-	cmd_list.Register("agg", cmd.HandlerAgg)
-	cmd_list.Register("addfeed", cmd.MiddlewareLoggedIn(cmd.HandlerAddFeed))
-	cmd_list.Register("feeds", cmd.HandlerFeeds)
-	cmd_list.Register("follow", cmd.MiddlewareLoggedIn(cmd.HandlerFollow))
-	cmd_list.Register("following", cmd.MiddlewareLoggedIn(cmd.HandlerFollowing))
-
-	c_name := os.Args[1]
-	c_args := os.Args[2:]
+	cmd_list.Register("help", cmd_list.HandlerHelp, cmd.Spec{
+		Description: "List commands or show the usage of one command",
+		Args:        []cmd.Arg{{Name: "command", Optional: true}},
+	})
+	cmd_list.Register("login", cmd.HandlerLogin, cmd.Spec{
+		Description: "Set the current user",
+		Args:        []cmd.Arg{{Name: "username"}},
+	})
+	cmd_list.Register("register", cmd.HandlerRegister, cmd.Spec{
+		Description: "Create a user and set it as the current user",
+		Args:        []cmd.Arg{{Name: "username"}},
+	})
+	cmd_list.Register("reset", cmd.HandlerReset, cmd.Spec{
+		Description: "Delete all users and their data",
+	})
+	cmd_list.Register("users", cmd.HandlerUsers, cmd.Spec{
+		Description: "List registered users",
+	})
+	cmd_list.Register("agg", cmd.HandlerAgg, cmd.Spec{
+		Description: "Fetch feeds and store their posts, once or continuously",
+		Flags: []cmd.Flag{
+			{Name: "every", Usage: "fetch the least recently fetched feed at this interval, 0 fetches all feeds once", Default: time.Duration(0)},
+			{Name: "digest-every", Usage: "also email users with an address a digest of unread posts at this interval, e.g. 24h", Default: time.Duration(0)},
+		},
+	})
+	cmd_list.Register("addfeed", cmd.MiddlewareLoggedIn(cmd.HandlerAddFeed), cmd.Spec{
+		Description: "Add a feed and follow it",
+		Args:        []cmd.Arg{{Name: "name"}, {Name: "url"}},
+	})
+	cmd_list.Register("feeds", cmd.HandlerFeeds, cmd.Spec{
+		Description: "List feeds, or merge duplicate feeds with 'feeds dedupe'",
+		Args:        []cmd.Arg{{Name: "dedupe", Optional: true}},
+		Flags: []cmd.Flag{
+			{Name: "dry-run", Usage: "show what dedupe would change without writing", Default: false},
+		},
+	})
+	cmd_list.Register("follow", cmd.MiddlewareLoggedIn(cmd.HandlerFollow), cmd.Spec{
+		Description: "Follow a feed by url, adding it if needed",
+		Args:        []cmd.Arg{{Name: "url"}},
+	})
+	cmd_list.Register("unfollow", cmd.MiddlewareLoggedIn(cmd.HandlerUnfollow), cmd.Spec{
+		Description: "Stop following a feed, starred posts are kept",
+		Args:        []cmd.Arg{{Name: "url"}},
+	})
+	cmd_list.Register("following", cmd.MiddlewareLoggedIn(cmd.HandlerFollowing), cmd.Spec{
+		Description: "List feeds followed by the current user with unread counts",
+		Flags: []cmd.Flag{
+			{Name: "by-category", Usage: "group feeds by category", Default: false},
+		},
+	})
+	cmd_list.Register("follow-edit", cmd.MiddlewareLoggedIn(cmd.HandlerFollowEdit), cmd.Spec{
+		Description: "Set your own title, priority, notes or mute state for a followed feed",
+		Args:        []cmd.Arg{{Name: "url"}},
+		Flags: []cmd.Flag{
+			{Name: "title", Usage: "display title, empty to use the feed's name", Default: ""},
+			{Name: "priority", Usage: "higher priority feeds are listed first", Default: 0},
+			{Name: "notes", Usage: "free form notes, empty to clear", Default: ""},
+			{Name: "mute", Usage: "hide the feed's posts from browse", Default: false},
+			{Name: "unmute", Usage: "show the feed's posts in browse again", Default: false},
+		},
+	})
+	cmd_list.Register("full-content", cmd.MiddlewareLoggedIn(cmd.HandlerFullContent), cmd.Spec{
+		Description: "Show or switch downloading the full article of each new post of a feed",
+		Args:        []cmd.Arg{{Name: "url"}, {Name: "on|off", Optional: true}},
+		Flags: []cmd.Flag{
+			{Name: "backfill", Usage: "also fetch the articles of up to this many stored posts", Default: 0},
+		},
+	})
+	cmd_list.Register("browse", cmd.MiddlewareLoggedIn(cmd.HandlerBrowse), cmd.Spec{
+		Description: "List the latest posts from followed feeds",
+		Flags: []cmd.Flag{
+			{Name: "limit", Usage: "number of posts to show", Default: 10},
+			{Name: "unread", Usage: "only show unread posts", Default: false},
+			{Name: "category", Usage: "only show posts of feeds in this category", Default: ""},
+			{Name: "author", Usage: "only show posts by this author", Default: ""},
+			{Name: "item-category", Usage: "only show posts the feed put in this category", Default: ""},
+		},
+	})
+	cmd_list.Register("read", cmd.MiddlewareLoggedIn(cmd.HandlerRead), cmd.Spec{
+		Description: "Mark a post as read",
+		Args:        []cmd.Arg{{Name: "post-id"}},
+	})
+	cmd_list.Register("unread", cmd.MiddlewareLoggedIn(cmd.HandlerUnread), cmd.Spec{
+		Description: "Mark a post as unread",
+		Args:        []cmd.Arg{{Name: "post-id"}},
+	})
+	cmd_list.Register("mark-all-read", cmd.MiddlewareLoggedIn(cmd.HandlerMarkAllRead), cmd.Spec{
+		Description: "Mark all posts from followed feeds as read",
+		Flags: []cmd.Flag{
+			{Name: "feed", Usage: "only mark posts of this feed url", Default: ""},
+			{Name: "before", Usage: "only mark posts published before this date", Default: ""},
+		},
+	})
+	cmd_list.Register("star", cmd.MiddlewareLoggedIn(cmd.HandlerStar), cmd.Spec{
+		Description: "Star a post, keeping it through prune and unfollow",
+		Args:        []cmd.Arg{{Name: "post-id"}},
+	})
+	cmd_list.Register("unstar", cmd.MiddlewareLoggedIn(cmd.HandlerUnstar), cmd.Spec{
+		Description: "Remove the star from a post",
+		Args:        []cmd.Arg{{Name: "post-id"}},
+	})
+	cmd_list.Register("starred", cmd.MiddlewareLoggedIn(cmd.HandlerStarred), cmd.Spec{
+		Description: "List starred posts, including those of unfollowed feeds",
+		Flags: []cmd.Flag{
+			{Name: "limit", Usage: "number of posts to show", Default: 50},
+		},
+	})
+	cmd_list.Register("search", cmd.MiddlewareLoggedIn(cmd.HandlerSearch), cmd.Spec{
+		Description: "Search stored posts, quote the query to use \"phrases\", -exclusions and or",
+		Args:        []cmd.Arg{{Name: "query", Variadic: true}},
+		Flags: []cmd.Flag{
+			{Name: "all", Usage: "search posts of all feeds instead of followed feeds", Default: false},
+			{Name: "limit", Usage: "number of results to show", Default: 20},
+		},
+	})
+	cmd_list.Register("category", cmd.MiddlewareLoggedIn(cmd.HandlerCategory), cmd.Spec{
+		Description: "Manage categories: add <name>, list, rm <name>, assign <feed> <category>, unassign <feed>",
+		Args:        []cmd.Arg{{Name: "action"}, {Name: "args", Variadic: true}},
+	})
+	cmd_list.Register("rule", cmd.MiddlewareLoggedIn(cmd.HandlerRule), cmd.Spec{
+		Description: "Manage rules run on new posts: add, list, rm <id>, test <id>",
+		Args:        []cmd.Arg{{Name: "action"}, {Name: "id", Optional: true}},
+		Flags: []cmd.Flag{
+			{Name: "field", Usage: "field to match: feed, title, description, text, author, domain or category", Default: "title"},
+			{Name: "match", Usage: "match type: substring, regex or keywords", Default: "substring"},
+			{Name: "pattern", Usage: "text, regular expression or comma separated keywords to look for", Default: ""},
+			{Name: "action", Usage: "action on match: hide, read, star, tag or notify", Default: "hide"},
+			{Name: "arg", Usage: "tag name for the tag action", Default: ""},
+			{Name: "limit", Usage: "number of recent posts to test against", Default: 100},
+		},
+	})
+	cmd_list.Register("watch", cmd.MiddlewareLoggedIn(cmd.HandlerWatch), cmd.Spec{
+		Description: "Get notified when new posts match: add <name>, list, rm <name>",
+		Args:        []cmd.Arg{{Name: "action"}, {Name: "name", Optional: true}},
+		Flags: []cmd.Flag{
+			{Name: "field", Usage: "field to match: text, title, description, author, domain, feed or category", Default: "text"},
+			{Name: "match", Usage: "match type: substring, regex or keywords", Default: "keywords"},
+			{Name: "pattern", Usage: "text, regular expression or comma separated keywords to look for", Default: ""},
+			{Name: "notifier", Usage: "how to deliver: desktop, command (post as JSON on stdin) or file (JSON lines, may be a fifo); command and file targets must be listed in notify_commands or notify_files of the config", Default: "desktop"},
+			{Name: "target", Usage: "shell command for command notifiers, path for file notifiers", Default: ""},
+		},
+	})
+	cmd_list.Register("webhook", cmd.MiddlewareLoggedIn(cmd.HandlerWebhook), cmd.Spec{
+		Description: "POST new posts to a url, queued deliveries are sent by agg: add <url>, list, rm <id>, test <id>, log <id>",
+		Args:        []cmd.Arg{{Name: "action"}, {Name: "url-or-id", Optional: true}},
+		Flags: []cmd.Flag{
+			{Name: "feed", Usage: "only deliver posts of this feed", Default: ""},
+			{Name: "filter", Usage: "only deliver posts matching <field> <contains|matches|any> <pattern>", Default: ""},
+			{Name: "secret", Usage: "HMAC-SHA256 signing secret, generated when empty", Default: ""},
+			{Name: "template", Usage: "text/template file rendering the request body instead of the JSON payload", Default: ""},
+			{Name: "limit", Usage: "number of deliveries to show in the log", Default: 20},
+		},
+	})
+	cmd_list.Register("email", cmd.MiddlewareLoggedIn(cmd.HandlerEmail), cmd.Spec{
+		Description: "Show or set the address digests are sent to, none removes it",
+		Args:        []cmd.Arg{{Name: "address", Optional: true}},
+	})
+	cmd_list.Register("digest", cmd.MiddlewareLoggedIn(cmd.HandlerDigest), cmd.Spec{
+		Description: "Email unread posts since the last digest, using the smtp settings of the config",
+		Flags: []cmd.Flag{
+			{Name: "stdout", Usage: "print the email instead of sending it", Default: false},
+			{Name: "mbox", Usage: "append the email to this mbox file instead of sending it", Default: ""},
+		},
+	})
+	cmd_list.Register("token", cmd.MiddlewareLoggedIn(cmd.HandlerToken), cmd.Spec{
+		Description: "Manage API tokens of the current user: add <name>, list, rm <id>",
+		Args:        []cmd.Arg{{Name: "action"}, {Name: "name-or-id", Optional: true}},
+	})
+	cmd_list.Register("fever", cmd.MiddlewareLoggedIn(cmd.HandlerFever), cmd.Spec{
+		Description: "Set the password Fever clients use with the user name as email, or none to disable",
+		Args:        []cmd.Arg{{Name: "password", Optional: true}},
+	})
+	cmd_list.Register("feed-token", cmd.MiddlewareLoggedIn(cmd.HandlerFeedToken), cmd.Spec{
+		Description: "Show whether serve --feeds serves the feeds of the current user, new to create the token they are served with or none to stop serving them",
+		Args:        []cmd.Arg{{Name: "new|none", Optional: true}},
+	})
+	cmd_list.Register("serve", cmd.HandlerServe, cmd.Spec{
+		Description: "Serve the REST JSON API under /api/v1, the Fever API under /fever/ and with --web the web reading UI",
+		Flags: []cmd.Flag{
+			{Name: "addr", Usage: "address to listen on", Default: "localhost:8080"},
+			{Name: "web", Usage: "serve the web UI, which trusts anyone who can reach it like the login command; set web_secret in the config to keep logins across restarts", Default: false},
+			{Name: "feeds", Usage: "serve output feeds at /feeds/<user>.<rss|atom|json>?token=<token>, see feed-token", Default: false},
+		},
+	})
+	cmd_list.Register("publish", cmd.MiddlewareLoggedIn(cmd.HandlerPublish), cmd.Spec{
+		Description: "Write posts of the current user as an RSS, Atom or JSON feed",
+		Flags: []cmd.Flag{
+			{Name: "format", Usage: "feed format: rss, atom or json", Default: "atom"},
+			{Name: "category", Usage: "only include feeds in this category", Default: ""},
+			{Name: "starred", Usage: "only include starred posts", Default: false},
+			{Name: "filter", Usage: "only include posts matching <field> <contains|matches|any> <pattern>", Default: ""},
+			{Name: "limit", Usage: "maximum number of posts", Default: 50},
+			{Name: "title", Usage: "feed title", Default: ""},
+			{Name: "url", Usage: "public URL the feed is published at", Default: ""},
+			{Name: "out", Usage: "write to this file instead of stdout", Default: ""},
+		},
+	})
+	cmd_list.Register("planet", cmd.MiddlewareLoggedIn(cmd.HandlerPlanet), cmd.Spec{
+		Description: "Build a static HTML site of the latest posts of followed feeds: build --out <dir>",
+		Args:        []cmd.Arg{{Name: "action"}},
+		Flags: []cmd.Flag{
+			{Name: "out", Usage: "directory to write the site to", Default: ""},
+			{Name: "category", Usage: "only include feeds in this category", Default: ""},
+			{Name: "title", Usage: "site title, Planet <user or category> by default", Default: ""},
+			{Name: "url", Usage: "public URL of the site, used in the Atom feed", Default: ""},
+			{Name: "per-page", Usage: "posts per page", Default: 20},
+			{Name: "limit", Usage: "maximum number of posts on the site", Default: 200},
+		},
+	})
+	cmd_list.Register("tui", cmd.MiddlewareLoggedIn(cmd.HandlerTUI), cmd.Spec{
+		Description: "Open a full screen terminal reader, press ? for keys",
+		Flags: []cmd.Flag{
+			{Name: "limit", Usage: "maximum number of items listed per feed or category", Default: 200},
+		},
+	})
+	cmd_list.Register("episodes", cmd.MiddlewareLoggedIn(cmd.HandlerEpisodes), cmd.Spec{
+		Description: "List the latest posts with audio or video attached",
+		Flags: []cmd.Flag{
+			{Name: "feed", Usage: "only list episodes of this feed url", Default: ""},
+			{Name: "downloaded", Usage: "only list downloaded episodes", Default: false},
+			{Name: "limit", Usage: "number of episodes to show", Default: 20},
+		},
+	})
+	cmd_list.Register("download", cmd.MiddlewareLoggedIn(cmd.HandlerDownload), cmd.Spec{
+		Description: "Download the media file of a post, resuming an interrupted download",
+		Args:        []cmd.Arg{{Name: "post-id"}},
+		Flags: []cmd.Flag{
+			{Name: "dir", Usage: "directory to download into, defaults to download_dir from the config or ~/Podcasts", Default: ""},
+		},
+	})
+	cmd_list.Register("import", cmd.MiddlewareLoggedIn(cmd.HandlerImport), cmd.Spec{
+		Description: "Follow the feeds of an OPML file, using its folders as categories",
+		Args:        []cmd.Arg{{Name: "file"}},
+	})
+	cmd_list.Register("export", cmd.MiddlewareLoggedIn(cmd.HandlerExport), cmd.Spec{
+		Description: "Export followed feeds and their categories as OPML",
+		Flags: []cmd.Flag{
+			{Name: "file", Usage: "write to this file instead of stdout", Default: ""},
+		},
+	})
+	cmd_list.Register("prune", cmd.HandlerPrune, cmd.Spec{
+		Description: "Delete posts older than a given age, except starred posts",
+		Flags: []cmd.Flag{
+			{Name: "older-than", Usage: "minimum age of posts to delete", Default: 30 * 24 * time.Hour},
+		},
+	})
+
+	c_name := args[0]
+	c_args := args[1:]
 	usr_command := cmd.Command{
 		Name: c_name,
 		Args: c_args,
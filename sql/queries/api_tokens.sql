@@ -0,0 +1,20 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (id, created_at, user_id, name, token_hash)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetAPITokensForUser :many
+SELECT * FROM api_tokens
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteAPIToken :execrows
+DELETE FROM api_tokens
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetUserByAPIToken :one
+UPDATE api_tokens
+SET last_used_at = $2
+FROM users
+WHERE api_tokens.token_hash = $1 AND users.id = api_tokens.user_id
+RETURNING users.*;
@@ -0,0 +1,25 @@
+-- name: CreateCategory :one
+INSERT INTO categories (id, created_at, updated_at, name, user_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetCategoryByName :one
+SELECT * FROM categories
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetCategoriesForUser :many
+SELECT
+    categories.*,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.category_id = categories.id) AS feed_count
+FROM categories
+WHERE categories.user_id = $1
+ORDER BY categories.name;
+
+-- name: DeleteCategory :execrows
+DELETE FROM categories
+WHERE user_id = $1 AND name = $2;
+
+-- name: SetFeedFollowCategory :execrows
+UPDATE feed_follows
+SET category_id = $1, updated_at = $2
+WHERE user_id = $3 AND feed_id = $4;
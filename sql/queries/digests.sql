@@ -0,0 +1,23 @@
+-- name: GetDigestPostsForUser :many
+SELECT
+    posts.*,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    categories.name AS category_name,
+    feed_follows.priority
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+LEFT JOIN categories ON feed_follows.category_id = categories.id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND post_reads.read_at IS NULL
+AND posts.created_at > sqlc.arg(since)::timestamp
+AND NOT feed_follows.muted
+AND NOT EXISTS (
+    SELECT 1 FROM post_hides
+    WHERE post_hides.post_id = posts.id AND post_hides.user_id = feed_follows.user_id
+)
+-- The oldest posts go first, so a capped digest leaves the newest ones for
+-- the next digest instead of skipping posts.
+ORDER BY posts.created_at
+LIMIT sqlc.arg(max_posts);
@@ -0,0 +1,47 @@
+-- name: CreateEnclosure :exec
+INSERT INTO enclosures (id, created_at, updated_at, post_id, position, url, mime_type, length)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+ON CONFLICT (post_id, url) DO NOTHING;
+
+-- name: GetEnclosuresForPost :many
+SELECT * FROM enclosures
+WHERE post_id = $1
+ORDER BY position;
+
+-- name: MarkEnclosureDownloaded :exec
+UPDATE enclosures
+SET downloaded_path = $2, downloaded_at = $3, updated_at = $3
+WHERE id = $1;
+
+-- name: GetEpisodesForUser :many
+SELECT
+    posts.id AS post_id,
+    posts.title,
+    posts.published_at,
+    posts.duration_seconds,
+    posts.season,
+    posts.episode,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    enclosures.url,
+    enclosures.mime_type,
+    enclosures.length,
+    enclosures.downloaded_path
+FROM enclosures
+INNER JOIN posts ON posts.id = enclosures.post_id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND enclosures.position = 0
+AND (sqlc.arg(feed_url)::text = '' OR feeds.url_key = regexp_replace(sqlc.arg(feed_url), '^https?://', ''))
+AND (NOT sqlc.arg(downloaded_only)::bool OR enclosures.downloaded_path IS NOT NULL)
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT sqlc.arg(max_results);
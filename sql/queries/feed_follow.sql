@@ -19,12 +19,26 @@ WHERE feed_id = $1 AND user_id = $2;
 -- name: GetFeedFollowsByUserID :many
 SELECT
     feed_follows.*,
-    feeds.name AS feed_name,
-    users.name AS user_name
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    users.name AS user_name,
+    (
+        SELECT COUNT(*)
+        FROM posts
+        LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+        WHERE posts.feed_id = feed_follows.feed_id AND post_reads.post_id IS NULL
+        AND NOT EXISTS (
+            SELECT 1 FROM post_hides
+            WHERE post_hides.post_id = posts.id AND post_hides.user_id = feed_follows.user_id
+        )
+    ) AS unread_count,
+    categories.name AS category_name,
+    feeds.url AS feed_url
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 INNER JOIN users ON feed_follows.user_id = users.id
-WHERE feed_follows.user_id = $1;
+LEFT JOIN categories ON feed_follows.category_id = categories.id
+WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.priority DESC, feed_name;
 
 -- name: GetFeedFollowsByUserName :many
 SELECT
@@ -44,4 +58,42 @@ SELECT
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 INNER JOIN users ON feed_follows.user_id = users.id
-WHERE feeds.url = $1;
+WHERE feeds.url_key = regexp_replace($1, '^https?://', '');
+
+-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = sqlc.arg(keep_id), updated_at = sqlc.arg(updated_at)
+WHERE feed_id = sqlc.arg(duplicate_id)
+AND user_id NOT IN (
+    SELECT user_id FROM feed_follows WHERE feed_id = sqlc.arg(keep_id)
+);
+
+-- name: MergeFeedFollows :exec
+UPDATE feed_follows AS kept
+SET title = COALESCE(kept.title, dup.title),
+    notes = COALESCE(kept.notes, dup.notes),
+    category_id = COALESCE(kept.category_id, dup.category_id),
+    muted = kept.muted OR dup.muted,
+    priority = GREATEST(kept.priority, dup.priority),
+    updated_at = sqlc.arg(updated_at)
+FROM feed_follows AS dup
+-- Users who follow both feeds keep their follow of the kept feed, which
+-- takes over the overrides only set on the duplicate.
+WHERE kept.feed_id = sqlc.arg(keep_id)
+AND dup.feed_id = sqlc.arg(duplicate_id)
+AND dup.user_id = kept.user_id;
+
+-- name: GetFeedFollow :one
+SELECT * FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: UpdateFeedFollowOverrides :one
+UPDATE feed_follows
+SET
+    title = sqlc.narg(title),
+    muted = sqlc.arg(muted),
+    priority = sqlc.arg(priority),
+    notes = sqlc.narg(notes),
+    updated_at = sqlc.arg(updated_at)
+WHERE user_id = sqlc.arg(user_id) AND feed_id = sqlc.arg(feed_id)
+RETURNING *;
@@ -17,3 +17,43 @@ DELETE FROM feeds;
 SELECT feeds.id, feeds.url, feeds.name, feeds.user_id, users.name AS username
 FROM feeds
 LEFT JOIN users ON feeds.user_id = users.id;
+
+-- name: GetFeedByURL :one
+SELECT * FROM feeds
+-- http and https spellings name the same feed, an exact match wins.
+WHERE url_key = regexp_replace($1, '^https?://', '')
+ORDER BY url = $1 DESC
+LIMIT 1;
+
+-- name: GetFeedsByAge :many
+SELECT * FROM feeds
+ORDER BY created_at ASC;
+
+-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: GetNextFeedToFetch :one
+SELECT * FROM feeds
+ORDER BY last_fetched_at ASC NULLS FIRST
+LIMIT 1;
+
+-- name: MarkFeedFetched :exec
+UPDATE feeds
+SET last_fetched_at = $2, updated_at = $2
+WHERE id = $1;
+
+-- name: SetFeedFullContent :exec
+UPDATE feeds
+SET fetch_full_content = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET image_url = $2, language = $3, generator = $4, updated_at = $5
+WHERE id = $1;
@@ -0,0 +1,129 @@
+-- name: GetUserByFeverKey :one
+SELECT * FROM users
+WHERE fever_api_key = $1;
+
+-- name: SetUserFeverKey :exec
+UPDATE users
+SET fever_api_key = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: GetFeverGroups :many
+SELECT seq, name FROM categories
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: GetFeverFeeds :many
+SELECT
+    feeds.seq,
+    COALESCE(feed_follows.title, feeds.name) AS title,
+    feeds.url,
+    feeds.last_fetched_at,
+    categories.seq AS group_seq
+FROM feed_follows
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+LEFT JOIN categories ON categories.id = feed_follows.category_id
+WHERE feed_follows.user_id = $1
+ORDER BY feeds.seq;
+
+-- name: GetFeverItems :many
+SELECT
+    posts.seq,
+    feeds.seq AS feed_seq,
+    posts.title,
+    posts.author,
+    posts.description,
+    posts.content,
+    posts.url,
+    COALESCE(posts.published_at, posts.created_at)::timestamp AS created_on,
+    (post_reads.read_at IS NOT NULL)::bool AS is_read,
+    EXISTS (
+        SELECT 1 FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+    ) AS is_saved
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg(user_id)
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg(user_id)
+-- Saved items of unfollowed feeds are kept, clients fetch them by id.
+WHERE (feed_follows.id IS NOT NULL OR EXISTS (
+    SELECT 1 FROM post_stars
+    WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+))
+AND NOT EXISTS (
+    SELECT 1 FROM post_hides
+    WHERE post_hides.post_id = posts.id AND post_hides.user_id = sqlc.arg(user_id)
+)
+AND (sqlc.arg(with_ids)::text = '' OR posts.seq::text = ANY (string_to_array(sqlc.arg(with_ids)::text, ',')))
+AND posts.seq > sqlc.arg(since_id)::bigint
+AND (sqlc.arg(max_id)::bigint = 0 OR posts.seq < sqlc.arg(max_id)::bigint)
+ORDER BY CASE WHEN sqlc.arg(max_id)::bigint > 0 THEN -posts.seq ELSE posts.seq END
+LIMIT 50;
+
+-- name: CountFeverItems :one
+SELECT COUNT(*)
+FROM posts
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+WHERE (feed_follows.id IS NOT NULL OR EXISTS (
+    SELECT 1 FROM post_stars
+    WHERE post_stars.post_id = posts.id AND post_stars.user_id = $1
+))
+AND NOT EXISTS (
+    SELECT 1 FROM post_hides
+    WHERE post_hides.post_id = posts.id AND post_hides.user_id = $1
+);
+
+-- name: GetFeverUnreadItemIDs :many
+SELECT posts.seq
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+AND post_reads.read_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM post_hides
+    WHERE post_hides.post_id = posts.id AND post_hides.user_id = feed_follows.user_id
+)
+ORDER BY posts.seq;
+
+-- name: GetFeverSavedItemIDs :many
+SELECT posts.seq
+FROM posts
+-- Starred posts are kept after their feed is unfollowed, no follow needed.
+INNER JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = $1
+ORDER BY posts.seq;
+
+-- name: GetFeverPostID :one
+SELECT posts.id
+FROM posts
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $2
+WHERE posts.seq = $1
+AND (feed_follows.id IS NOT NULL OR EXISTS (
+    SELECT 1 FROM post_stars
+    WHERE post_stars.post_id = posts.id AND post_stars.user_id = $2
+));
+
+-- name: MarkFeverFeedRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, sqlc.arg(read_at)::timestamp
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND feeds.seq = sqlc.arg(feed_seq)
+-- before is when the client last refreshed, posts stored later stay unread
+-- whenever they claim to be published.
+AND posts.created_at < sqlc.arg(before)::timestamp
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkFeverGroupRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, sqlc.arg(read_at)::timestamp
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN categories ON categories.id = feed_follows.category_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND (sqlc.arg(group_seq)::bigint = 0 OR categories.seq = sqlc.arg(group_seq)::bigint)
+-- before is when the client last refreshed, posts stored later stay unread
+-- whenever they claim to be published.
+AND posts.created_at < sqlc.arg(before)::timestamp
+ON CONFLICT (user_id, post_id) DO NOTHING;
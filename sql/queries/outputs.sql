@@ -0,0 +1,35 @@
+-- name: GetOutputPostsForUser :many
+SELECT
+    posts.*,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    feeds.url AS feed_url,
+    categories.name AS category_name,
+    COALESCE((
+        SELECT string_agg(post_tags.tag, ',' ORDER BY post_tags.tag)
+        FROM post_tags
+        WHERE post_tags.post_id = posts.id AND post_tags.user_id = sqlc.arg(user_id)
+    ), '')::text AS tags,
+    COALESCE((
+        SELECT string_agg(post_categories.name, ',' ORDER BY post_categories.name)
+        FROM post_categories
+        WHERE post_categories.post_id = posts.id
+    ), '')::text AS item_categories
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg(user_id)
+LEFT JOIN categories ON feed_follows.category_id = categories.id
+WHERE (sqlc.arg(category)::text = '' OR categories.name = sqlc.arg(category)::text)
+-- Starred posts are kept after their feed is unfollowed, so starred feeds
+-- do not need a follow.
+AND (feed_follows.id IS NOT NULL OR sqlc.arg(starred_only)::bool)
+AND (NOT sqlc.arg(starred_only)::bool OR EXISTS (
+    SELECT 1 FROM post_stars
+    WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+))
+AND NOT COALESCE(feed_follows.muted, false)
+AND NOT EXISTS (
+    SELECT 1 FROM post_hides
+    WHERE post_hides.post_id = posts.id AND post_hides.user_id = sqlc.arg(user_id)
+)
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, posts.id
+LIMIT sqlc.arg(max_posts) OFFSET sqlc.arg(skip_posts);
@@ -0,0 +1,20 @@
+-- name: CreatePostStar :exec
+INSERT INTO post_stars (id, created_at, updated_at, post_id, user_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (post_id, user_id) DO NOTHING;
+
+-- name: DeletePostStar :execrows
+DELETE FROM post_stars
+WHERE post_id = $1 AND user_id = $2;
+
+-- name: GetStarredPostsForUser :many
+SELECT
+    posts.*,
+    feeds.name AS feed_name,
+    post_stars.created_at AS starred_at
+FROM post_stars
+INNER JOIN posts ON post_stars.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE post_stars.user_id = $1
+ORDER BY post_stars.created_at DESC
+LIMIT $2;
@@ -0,0 +1,190 @@
+-- name: CreatePost :one
+INSERT INTO posts (
+    id, created_at, updated_at, title, url, description, published_at, feed_id, author, content,
+    duration_seconds, season, episode, image_url, guid, comments_url, source_title, source_url
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17,
+    $18
+)
+-- Either the url or the guid may identify an item that was stored before.
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetPostsForUser :many
+SELECT
+    posts.*,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    post_reads.read_at,
+    COALESCE((
+        SELECT string_agg(post_tags.tag, ',' ORDER BY post_tags.tag)
+        FROM post_tags
+        WHERE post_tags.post_id = posts.id AND post_tags.user_id = feed_follows.user_id
+    ), '')::text AS tags,
+    COALESCE((
+        SELECT string_agg(post_categories.name, ',' ORDER BY post_categories.name)
+        FROM post_categories
+        WHERE post_categories.post_id = posts.id
+    ), '')::text AS categories,
+    EXISTS (
+        SELECT 1 FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = feed_follows.user_id
+    ) AS starred
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+LEFT JOIN categories ON feed_follows.category_id = categories.id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND (NOT sqlc.arg(unread_only)::bool OR post_reads.read_at IS NULL)
+AND (sqlc.arg(category)::text = '' OR categories.name = sqlc.arg(category)::text)
+AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+AND (sqlc.arg(author)::text = '' OR lower(posts.author) = lower(sqlc.arg(author)::text))
+AND (sqlc.arg(item_category)::text = '' OR EXISTS (
+    SELECT 1 FROM post_categories
+    WHERE post_categories.post_id = posts.id AND lower(post_categories.name) = lower(sqlc.arg(item_category)::text)
+))
+AND NOT feed_follows.muted
+AND NOT EXISTS (
+    SELECT 1 FROM post_hides
+    WHERE post_hides.post_id = posts.id AND post_hides.user_id = feed_follows.user_id
+)
+ORDER BY feed_follows.priority DESC, posts.published_at DESC NULLS LAST, posts.id
+LIMIT sqlc.arg(max_posts)
+OFFSET sqlc.arg(skip_posts);
+
+-- name: GetPostForUser :one
+SELECT
+    posts.*,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    post_reads.read_at,
+    COALESCE((
+        SELECT string_agg(post_tags.tag, ',' ORDER BY post_tags.tag)
+        FROM post_tags
+        WHERE post_tags.post_id = posts.id AND post_tags.user_id = sqlc.arg(user_id)
+    ), '')::text AS tags,
+    COALESCE((
+        SELECT string_agg(post_categories.name, ',' ORDER BY post_categories.name)
+        FROM post_categories
+        WHERE post_categories.post_id = posts.id
+    ), '')::text AS categories,
+    EXISTS (
+        SELECT 1 FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+    ) AS starred
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg(user_id)
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg(user_id)
+WHERE posts.id = sqlc.arg(id)
+-- Starred posts stay readable after their feed is unfollowed.
+AND (feed_follows.id IS NOT NULL OR EXISTS (
+    SELECT 1 FROM post_stars
+    WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+));
+
+-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = sqlc.arg(keep_id), updated_at = sqlc.arg(updated_at)
+WHERE feed_id = sqlc.arg(duplicate_id)
+-- Posts whose guid the kept feed already has stay behind and are deleted
+-- with the duplicate feed, after MoveDuplicatePostStars and friends.
+AND (guid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM posts AS kept
+    WHERE kept.feed_id = sqlc.arg(keep_id) AND kept.guid = posts.guid
+));
+
+-- name: MoveDuplicatePostStars :exec
+INSERT INTO post_stars (id, created_at, updated_at, post_id, user_id)
+-- Posts MovePosts leaves behind are deleted with the duplicate feed, their
+-- stars, reads, tags and hides go to the kept post with the same guid first.
+SELECT gen_random_uuid(), post_stars.created_at, sqlc.arg(updated_at), kept.id, post_stars.user_id
+FROM post_stars
+INNER JOIN posts AS dup ON dup.id = post_stars.post_id
+INNER JOIN posts AS kept ON kept.feed_id = sqlc.arg(keep_id) AND kept.guid = dup.guid
+WHERE dup.feed_id = sqlc.arg(duplicate_id)
+ON CONFLICT (post_id, user_id) DO NOTHING;
+
+-- name: MoveDuplicatePostReads :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT post_reads.user_id, kept.id, post_reads.read_at
+FROM post_reads
+INNER JOIN posts AS dup ON dup.id = post_reads.post_id
+INNER JOIN posts AS kept ON kept.feed_id = sqlc.arg(keep_id) AND kept.guid = dup.guid
+WHERE dup.feed_id = sqlc.arg(duplicate_id)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MoveDuplicatePostTags :exec
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+SELECT post_tags.user_id, kept.id, post_tags.tag, post_tags.created_at
+FROM post_tags
+INNER JOIN posts AS dup ON dup.id = post_tags.post_id
+INNER JOIN posts AS kept ON kept.feed_id = sqlc.arg(keep_id) AND kept.guid = dup.guid
+WHERE dup.feed_id = sqlc.arg(duplicate_id)
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
+
+-- name: MoveDuplicatePostHides :exec
+INSERT INTO post_hides (user_id, post_id, created_at)
+SELECT post_hides.user_id, kept.id, post_hides.created_at
+FROM post_hides
+INNER JOIN posts AS dup ON dup.id = post_hides.post_id
+INNER JOIN posts AS kept ON kept.feed_id = sqlc.arg(keep_id) AND kept.guid = dup.guid
+WHERE dup.feed_id = sqlc.arg(duplicate_id)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: DeletePostsBefore :execrows
+DELETE FROM posts
+WHERE COALESCE(published_at, created_at) < $1
+AND NOT EXISTS (
+    SELECT 1 FROM post_stars WHERE post_stars.post_id = posts.id
+);
+
+-- name: GetRecentPostsForRules :many
+SELECT
+    posts.*,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    categories.name AS category_name,
+    COALESCE((
+        SELECT string_agg(post_categories.name, ',' ORDER BY post_categories.name)
+        FROM post_categories
+        WHERE post_categories.post_id = posts.id
+    ), '')::text AS item_categories
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN categories ON feed_follows.category_id = categories.id
+WHERE feed_follows.user_id = $1
+ORDER BY posts.created_at DESC
+LIMIT $2;
+
+-- name: UpdatePostContent :exec
+UPDATE posts
+SET content = $2, updated_at = $3
+WHERE id = $1 AND content IS NULL;
+
+-- name: GetPostsWithoutContent :many
+SELECT * FROM posts
+WHERE feed_id = $1 AND content IS NULL
+ORDER BY COALESCE(published_at, created_at) DESC
+LIMIT $2;
+
+-- name: CreatePostCategory :exec
+INSERT INTO post_categories (post_id, name)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
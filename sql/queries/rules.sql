@@ -0,0 +1,39 @@
+-- name: CreateRule :one
+INSERT INTO rules (id, created_at, updated_at, user_id, field, match_type, pattern, action, action_arg)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetRulesForUser :many
+SELECT * FROM rules
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: GetRule :one
+SELECT * FROM rules
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteRule :execrows
+DELETE FROM rules
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetRulesForFeedFollowers :many
+SELECT
+    rules.*,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    categories.name AS category_name
+FROM rules
+INNER JOIN feed_follows ON feed_follows.user_id = rules.user_id
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+LEFT JOIN categories ON categories.id = feed_follows.category_id
+WHERE feed_follows.feed_id = $1
+ORDER BY rules.created_at;
+
+-- name: HidePost :exec
+INSERT INTO post_hides (user_id, post_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: TagPost :exec
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
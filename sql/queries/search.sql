@@ -0,0 +1,26 @@
+-- name: SearchPosts :many
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    feeds.name AS feed_name,
+    ts_rank(posts.search, q)::real AS rank,
+    -- Tags are dropped before the headline is cut, so fragments never hold
+    -- half a tag. Entities are decoded by the caller.
+    ts_headline(
+        'english',
+        posts.title || ' ' || regexp_replace(coalesce(posts.content, posts.description, ''), '<[^>]*>', ' ', 'g'),
+        q,
+        'StartSel=**, StopSel=**, MaxFragments=2, MaxWords=20, MinWords=5'
+    )::text AS snippet
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id,
+websearch_to_tsquery('english', sqlc.arg(query)::text) AS q
+WHERE posts.search @@ q
+AND (
+    sqlc.arg(all_feeds)::bool
+    OR posts.feed_id IN (SELECT feed_id FROM feed_follows WHERE feed_follows.user_id = sqlc.arg(user_id))
+)
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST
+LIMIT sqlc.arg(max_results);
@@ -17,3 +17,25 @@ DELETE FROM users;
 
 -- name: GetUsers :many
 SELECT * FROM users;
+
+-- name: SetUserEmail :one
+UPDATE users
+SET email = $2, updated_at = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkUserDigested :exec
+UPDATE users
+SET last_digest_at = $2
+WHERE id = $1;
+
+-- name: GetUsersDueForDigest :many
+SELECT * FROM users
+WHERE email IS NOT NULL
+AND (last_digest_at IS NULL OR last_digest_at <= $1)
+ORDER BY name;
+
+-- name: SetUserFeedToken :exec
+UPDATE users
+SET feed_token_hash = $2, updated_at = $3
+WHERE id = $1;
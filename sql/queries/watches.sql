@@ -0,0 +1,28 @@
+-- name: CreateWatch :one
+INSERT INTO watches (id, created_at, updated_at, user_id, name, field, match_type, pattern, notifier, target)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: GetWatchesForUser :many
+SELECT * FROM watches
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: DeleteWatch :execrows
+DELETE FROM watches
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetWatchesForFeedFollowers :many
+SELECT
+    watches.*,
+    users.name AS user_name,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    categories.name AS category_name
+FROM watches
+INNER JOIN users ON users.id = watches.user_id
+INNER JOIN feed_follows ON feed_follows.user_id = watches.user_id
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+LEFT JOIN categories ON categories.id = feed_follows.category_id
+WHERE feed_follows.feed_id = $1
+AND NOT feed_follows.muted
+ORDER BY watches.created_at;
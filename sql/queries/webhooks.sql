@@ -0,0 +1,74 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (id, created_at, updated_at, user_id, url, feed_id, filter, secret, template)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetWebhooksForUser :many
+SELECT webhooks.*, feeds.url AS feed_url
+FROM webhooks
+LEFT JOIN feeds ON feeds.id = webhooks.feed_id
+WHERE webhooks.user_id = $1
+ORDER BY webhooks.created_at;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetWebhooksForFeed :many
+SELECT
+    webhooks.*,
+    COALESCE(feed_follows.title, feeds.name) AS feed_name,
+    categories.name AS category_name
+FROM webhooks
+INNER JOIN feed_follows ON feed_follows.user_id = webhooks.user_id
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+LEFT JOIN categories ON feed_follows.category_id = categories.id
+WHERE feed_follows.feed_id = sqlc.arg(feed_id)
+AND (webhooks.feed_id IS NULL OR webhooks.feed_id = sqlc.arg(feed_id))
+ORDER BY webhooks.created_at;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, created_at, webhook_id, post_id, event, attempts, status_code, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: QueueWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, created_at, webhook_id, post_id, event, attempts, status_code, body, pending, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, 0, 0, $6, true, $2);
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)
+FROM webhooks
+WHERE webhooks.id = webhook_deliveries.webhook_id
+-- Claimed deliveries are leased, so concurrent workers skip them and an
+-- interrupted worker's deliveries are retried once the lease runs out.
+AND webhook_deliveries.id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE pending AND next_attempt_at <= sqlc.arg(now)
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg(max_deliveries)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING webhook_deliveries.*, webhooks.url, webhooks.secret;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET attempts = $2, status_code = $3, error = $4, pending = $5, next_attempt_at = $6
+WHERE id = $1;
+
+-- name: GetWebhookDeliveries :many
+SELECT webhook_deliveries.*, posts.title AS post_title
+FROM webhook_deliveries
+LEFT JOIN posts ON posts.id = webhook_deliveries.post_id
+WHERE webhook_deliveries.webhook_id = $1
+ORDER BY webhook_deliveries.created_at DESC
+LIMIT $2;
+
+-- name: MoveWebhooks :exec
+UPDATE webhooks
+SET feed_id = sqlc.arg(keep_id), updated_at = sqlc.arg(updated_at)
+WHERE feed_id = sqlc.arg(duplicate_id);
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feed_follows
+    ADD COLUMN title TEXT,
+    ADD COLUMN muted BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN priority INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN notes TEXT;
+
+-- +goose Down
+ALTER TABLE feed_follows
+    DROP COLUMN title,
+    DROP COLUMN muted,
+    DROP COLUMN priority,
+    DROP COLUMN notes;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE watches (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    field TEXT NOT NULL,
+    match_type TEXT NOT NULL,
+    pattern TEXT NOT NULL,
+    notifier TEXT NOT NULL,
+    target TEXT NOT NULL DEFAULT '',
+    UNIQUE (user_id, name)
+);
+
+-- +goose Down
+DROP TABLE watches;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Fever clients identify feeds, items and groups by integers.
+ALTER TABLE feeds ADD COLUMN seq BIGSERIAL UNIQUE;
+ALTER TABLE posts ADD COLUMN seq BIGSERIAL UNIQUE;
+ALTER TABLE categories ADD COLUMN seq BIGSERIAL UNIQUE;
+ALTER TABLE users ADD COLUMN fever_api_key TEXT UNIQUE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN fever_api_key;
+ALTER TABLE categories DROP COLUMN seq;
+ALTER TABLE posts DROP COLUMN seq;
+ALTER TABLE feeds DROP COLUMN seq;
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN duration_seconds INTEGER;
+ALTER TABLE posts ADD COLUMN season INTEGER;
+ALTER TABLE posts ADD COLUMN episode INTEGER;
+ALTER TABLE posts ADD COLUMN image_url TEXT;
+
+CREATE TABLE enclosures (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    mime_type TEXT NOT NULL,
+    length BIGINT NOT NULL,
+    downloaded_path TEXT,
+    downloaded_at TIMESTAMP,
+    UNIQUE (post_id, url)
+);
+
+-- +goose Down
+DROP TABLE enclosures;
+ALTER TABLE posts DROP COLUMN image_url;
+ALTER TABLE posts DROP COLUMN episode;
+ALTER TABLE posts DROP COLUMN season;
+ALTER TABLE posts DROP COLUMN duration_seconds;
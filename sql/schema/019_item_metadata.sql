@@ -0,0 +1,29 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN guid TEXT;
+ALTER TABLE posts ADD COLUMN comments_url TEXT;
+ALTER TABLE posts ADD COLUMN source_title TEXT;
+ALTER TABLE posts ADD COLUMN source_url TEXT;
+-- Items keep their guid when a feed moves them to another url.
+ALTER TABLE posts ADD CONSTRAINT posts_feed_id_guid_key UNIQUE (feed_id, guid);
+
+CREATE TABLE post_categories (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    PRIMARY KEY (post_id, name)
+);
+CREATE INDEX post_categories_name_idx ON post_categories (lower(name));
+
+ALTER TABLE feeds ADD COLUMN image_url TEXT;
+ALTER TABLE feeds ADD COLUMN language TEXT;
+ALTER TABLE feeds ADD COLUMN generator TEXT;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN generator;
+ALTER TABLE feeds DROP COLUMN language;
+ALTER TABLE feeds DROP COLUMN image_url;
+DROP TABLE post_categories;
+ALTER TABLE posts DROP CONSTRAINT posts_feed_id_guid_key;
+ALTER TABLE posts DROP COLUMN source_url;
+ALTER TABLE posts DROP COLUMN source_title;
+ALTER TABLE posts DROP COLUMN comments_url;
+ALTER TABLE posts DROP COLUMN guid;
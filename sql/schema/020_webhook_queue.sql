@@ -0,0 +1,13 @@
+-- +goose Up
+-- Deliveries are queued by the aggregator and sent by a separate worker,
+-- pending rows are waiting for their first or next attempt.
+ALTER TABLE webhook_deliveries ADD COLUMN body TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_deliveries ADD COLUMN pending BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE webhook_deliveries ADD COLUMN next_attempt_at TIMESTAMP;
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE pending;
+
+-- +goose Down
+DROP INDEX webhook_deliveries_pending_idx;
+ALTER TABLE webhook_deliveries DROP COLUMN next_attempt_at;
+ALTER TABLE webhook_deliveries DROP COLUMN pending;
+ALTER TABLE webhook_deliveries DROP COLUMN body;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- url_key is the feed url without its scheme, so the http and https
+-- spellings of a feed are found through an index.
+ALTER TABLE feeds ADD COLUMN url_key TEXT GENERATED ALWAYS AS (regexp_replace(url, '^https?://', '')) STORED;
+CREATE INDEX feeds_url_key_idx ON feeds (url_key);
+
+-- +goose Down
+DROP INDEX feeds_url_key_idx;
+ALTER TABLE feeds DROP COLUMN url_key;
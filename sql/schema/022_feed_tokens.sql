@@ -0,0 +1,6 @@
+-- +goose Up
+-- Served output feeds are only handed out with the token behind this hash.
+ALTER TABLE users ADD COLUMN feed_token_hash TEXT;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN feed_token_hash;